@@ -0,0 +1,77 @@
+/*
+@Time : 2021/6/13 10:05 上午
+@Author : shiguantian
+@File : checksum
+@Software: GoLand
+*/
+package book
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/shiguantian/wsex"
+)
+
+// ChecksumFunc calculate the checksum of the local order book in the exchange's way
+type ChecksumFunc func(book wsex.OrderBook) int64
+
+// VerifyChecksum the VerifyFunc comparing the checksum calculated by f with the exchange's
+func VerifyChecksum(f ChecksumFunc) VerifyFunc {
+	return func(book wsex.OrderBook, checksum int64) error {
+		if local := f(book); local != checksum {
+			return fmt.Errorf("checksum mismatch, local: %v, remote: %v", local, checksum)
+		}
+		return nil
+	}
+}
+
+// OkxChecksum the signed crc32 of the best 25 levels, "bid1price:bid1amount:ask1price:ask1amount:bid2price:...",
+// the missing level of the shorter side is skipped
+func OkxChecksum(book wsex.OrderBook) int64 {
+	var b strings.Builder
+	for i := 0; i < 25 && (i < len(book.Bids) || i < len(book.Asks)); i++ {
+		if i < len(book.Bids) {
+			writeLevel(&b, book.Bids[i])
+		}
+		if i < len(book.Asks) {
+			writeLevel(&b, book.Asks[i])
+		}
+	}
+	return int64(int32(crc32.ChecksumIEEE([]byte(b.String()))))
+}
+
+func writeLevel(b *strings.Builder, item wsex.DepthItem) {
+	if b.Len() > 0 {
+		b.WriteByte(':')
+	}
+	b.WriteString(item.Price)
+	b.WriteByte(':')
+	b.WriteString(item.Amount)
+}
+
+// KrakenChecksum the unsigned crc32 of the best 10 asks followed by the best 10 bids,
+// each price and amount is written without the point and the leading zeros
+func KrakenChecksum(book wsex.OrderBook) int64 {
+	var b strings.Builder
+	for _, depth := range []wsex.Depth{book.Asks, book.Bids} {
+		for i := 0; i < 10 && i < len(depth); i++ {
+			writeDigits(&b, depth[i].Price)
+			writeDigits(&b, depth[i].Amount)
+		}
+	}
+	return int64(crc32.ChecksumIEEE([]byte(b.String())))
+}
+
+func writeDigits(b *strings.Builder, number string) {
+	leading := true
+	for i := 0; i < len(number); i++ {
+		c := number[i]
+		if c == '.' || (leading && c == '0') {
+			continue
+		}
+		leading = false
+		b.WriteByte(c)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+@Time : 2021/6/13 11:20 上午
+@Author : shiguantian
+@File : checksum_test
+@Software: GoLand
+*/
+package book
+
+import (
+	"hash/crc32"
+	"testing"
+
+	"github.com/shiguantian/wsex"
+)
+
+func TestOkxChecksum(t *testing.T) {
+	book := wsex.OrderBook{
+		Bids: wsex.Depth{{Price: "3366.1", Amount: "7"}, {Price: "3366", Amount: "6"}},
+		Asks: wsex.Depth{{Price: "3366.8", Amount: "9"}},
+	}
+	want := int64(int32(crc32.ChecksumIEEE([]byte("3366.1:7:3366.8:9:3366:6"))))
+	if got := OkxChecksum(book); got != want {
+		t.Fatalf("expect %v, got %v", want, got)
+	}
+	if err := VerifyChecksum(OkxChecksum)(book, want); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChecksum(OkxChecksum)(book, want+1); err == nil {
+		t.Fatal("the mismatch should be reported")
+	}
+}
+
+func TestKrakenChecksum(t *testing.T) {
+	book := wsex.OrderBook{
+		Bids: wsex.Depth{{Price: "0.05005", Amount: "0.00000500"}},
+		Asks: wsex.Depth{{Price: "0.05010", Amount: "1.50000000"}, {Price: "0.05015", Amount: "0.10000000"}},
+	}
+	want := int64(crc32.ChecksumIEEE([]byte("5010150000000" + "501510000000" + "5005500")))
+	if got := KrakenChecksum(book); got != want {
+		t.Fatalf("expect %v, got %v", want, got)
+	}
+}
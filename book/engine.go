@@ -0,0 +1,599 @@
+/*
+@Time : 2021/6/11 10:20 上午
+@Author : shiguantian
+@File : engine
+@Software: GoLand
+*/
+package book
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+const (
+	maxPending   = 1000 // the max deltas buffered while waiting for the snapshot
+	compareDepth = 20   // the levels of each side compared with the snapshot
+
+	minBackoff = time.Second // the wait before requesting the snapshot again after a failure, doubled up to maxBackoff
+	maxBackoff = time.Minute
+)
+
+// Snapshot the full order book with its sequence
+type Snapshot struct {
+	Bids     wsex.RawDepth
+	Asks     wsex.RawDepth
+	Sequence int64         // 0 means the exchange has no sequence
+	Checksum int64         // 0 means no checksum
+	Time     time.Duration // the event time of the exchange in ms, 0 if unknown
+}
+
+// Delta the incremental update of the order book, the sequence fields which the exchange doesn't provide are 0
+type Delta struct {
+	Bids     wsex.RawDepth
+	Asks     wsex.RawDepth
+	First    int64         // first update id of the delta
+	Last     int64         // last update id of the delta
+	Prev     int64         // last update id of the previous delta
+	Checksum int64         // 0 means no checksum
+	Time     time.Duration // the event time of the exchange in ms, 0 if unknown
+	RecvTime time.Duration // the local time receiving the frame in ms, the time of Update if 0, see Engine.SetAsync
+}
+
+func (d Delta) sequenced() bool {
+	return d.First != 0 || d.Last != 0 || d.Prev != 0
+}
+
+// follows whether the delta is right after the sequence
+func (d Delta) follows(seq int64) bool {
+	if d.Prev != 0 {
+		return d.Prev == seq
+	}
+	if d.First != 0 {
+		return d.First == seq+1
+	}
+	return true
+}
+
+// bridges whether the delta covers the next sequence of the snapshot, the delta older than the snapshot is dropped before
+func (d Delta) bridges(seq int64) bool {
+	if d.Prev != 0 {
+		return d.Prev <= seq
+	}
+	if d.First != 0 {
+		return d.First <= seq+1
+	}
+	return true
+}
+
+// SnapshotFunc get the snapshot of the symbol, usually by rest api.
+// return nil snapshot without error if the snapshot is requested asynchronously, then pass it by Engine.Snapshot
+type SnapshotFunc func(symbol string) (*Snapshot, error)
+
+// PublishFunc receive the messages of the snapshot fetched in background, see Engine.SetAsync
+type PublishFunc func(symbol string, msgs []wsex.Message)
+
+// VerifyFunc verify the local order book with the checksum of the exchange, the book is only valid during the call
+type VerifyFunc func(book wsex.OrderBook, checksum int64) error
+
+type localBook struct {
+	symbol    string
+	ladder    *Ladder
+	seq       int64
+	time      time.Duration // the event time of the last snapshot or delta
+	recv      time.Duration // the local time receiving the last delta in ms
+	ready     bool          // the snapshot is loaded
+	synced    bool          // a delta is applied after the snapshot
+	requested bool          // the snapshot is requested asynchronously
+	invalid   bool          // BookInvalid is emitted, waiting for resync
+	pending   []Delta       // deltas received before the snapshot
+	gen       int64         // increased when the book is reset, the snapshot fetched in background for an older one is dropped
+	retryAt   time.Time     // the snapshot isn't requested before it after a failure
+	backoff   time.Duration
+
+	compared  time.Time // the last time of comparing with the snapshot
+	comparing bool      // the snapshot for comparing is requested asynchronously
+	shadowing bool      // the shadow is catching up with the book
+	shadow    *Ladder   // the snapshot for comparing, updated by the same deltas until it's at the sequence of the book
+	shadowSeq int64
+
+	bids, asks             wsex.Depth // reused buffers for verifying
+	shadowBids, shadowAsks wsex.Depth
+}
+
+func (b *localBook) load(snapshot Snapshot) {
+	b.ladder.Reset()
+	b.ladder.Update(snapshot.Bids, snapshot.Asks)
+	b.seq, b.time = snapshot.Sequence, snapshot.Time
+	b.ready, b.synced, b.requested = true, false, false
+	b.compared, b.comparing, b.shadowing = time.Now(), false, false
+}
+
+func (b *localBook) reset() {
+	b.ladder.Reset()
+	b.seq = 0
+	b.gen++
+	b.ready, b.synced, b.requested = false, false, false
+	b.comparing, b.shadowing = false, false
+	b.pending = nil
+}
+
+// failed delay the next request of the snapshot
+func (b *localBook) failed() {
+	b.backoff *= 2
+	if b.backoff < minBackoff {
+		b.backoff = minBackoff
+	}
+	if b.backoff > maxBackoff {
+		b.backoff = maxBackoff
+	}
+	b.retryAt = time.Now().Add(b.backoff)
+}
+
+// mismatch the book differs from the exchange, which is reported by MsgError besides MsgBookInvalid
+type mismatch struct {
+	wsex.ExError
+}
+
+// Stats the counters of the engine for metrics
+type Stats struct {
+	Gaps       int64 // sequence gaps
+	Mismatches int64 // checksum or snapshot comparison mismatches
+	Compares   int64 // comparisons with the snapshot
+	Resyncs    int64 // books rebuilt after invalidated
+}
+
+// Engine maintain the local order books from snapshots and deltas.
+// A sequence gap or checksum mismatch invalidates the book and resyncs it from a new snapshot automatically,
+// MsgBookInvalid is emitted when the book becomes invalid and MsgBookResynced when it's rebuilt,
+// the mismatch is also reported by MsgError.
+// The book of the exchange without checksum can be compared with the snapshot periodically by SetCompareInterval.
+// After the snapshot failed, the deltas are buffered and the snapshot isn't requested again until the backoff passes.
+type Engine struct {
+	mu       sync.Mutex
+	books    map[string]*localBook // key: symbol
+	snapshot SnapshotFunc
+	deliver  PublishFunc // not nil if the snapshot is fetched in background
+	metrics  wsex.Metrics
+	exchange string
+	verify   VerifyFunc
+	interval time.Duration
+	decimals int
+	maxDepth int
+	stats    Stats
+}
+
+// NewEngine the snapshot may be nil if it's pushed by websocket after subscribing
+func NewEngine(snapshot SnapshotFunc) *Engine {
+	return &Engine{
+		books:    make(map[string]*localBook),
+		snapshot: snapshot,
+		decimals: DefaultDecimals,
+		maxDepth: DefaultMaxDepth,
+	}
+}
+
+// SetDepth set the max levels of each side of the books created later, n <= 0 means DefaultMaxDepth
+func (e *Engine) SetDepth(n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if n <= 0 {
+		n = DefaultMaxDepth
+	}
+	e.maxDepth = n
+}
+
+// SetDecimals set the precision of the price ticks of the books created later
+func (e *Engine) SetDecimals(decimals int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.decimals = decimals
+}
+
+// SetVerify verify the book with the checksum of snapshots and deltas
+func (e *Engine) SetVerify(verify VerifyFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.verify = verify
+}
+
+// SetCompareInterval compare the books with the snapshots every interval, 0 means never.
+// the snapshot is matched with the book by sequence, so it only works for the sequenced feeds
+func (e *Engine) SetCompareInterval(interval time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.interval = interval
+}
+
+// SetAsync call the snapshot func in background goroutines, eg: it requests the rest api, so the deltas aren't blocked by it.
+// the deltas are buffered until the snapshot arrives, the messages of loading or comparing it are passed to publish,
+// their RecvTime is set to the RecvTime of the last delta, so the deltas should carry the receive time of their frames
+func (e *Engine) SetAsync(publish PublishFunc) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.deliver = publish
+}
+
+// SetMetrics report the mismatches of the books to metrics
+func (e *Engine) SetMetrics(exchange string, metrics wsex.Metrics) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.exchange, e.metrics = exchange, metrics
+}
+
+// Stats the counters since the engine is created
+func (e *Engine) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+// Snapshot load the snapshot of the symbol, the deltas received before are replayed.
+// the snapshot requested for comparing is compared with the book instead
+func (e *Engine) Snapshot(symbol string, snapshot Snapshot) []wsex.Message {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.loadSnapshot(e.get(symbol), snapshot)
+}
+
+func (e *Engine) loadSnapshot(b *localBook, snapshot Snapshot) []wsex.Message {
+	if b.comparing && b.synced {
+		b.comparing = false
+		return e.compareWith(b, snapshot)
+	}
+	pending := b.pending
+	b.pending = nil
+	b.load(snapshot)
+	if err := e.check(b, snapshot.Checksum); err != nil {
+		return e.invalidate(b, err)
+	}
+	for _, delta := range pending {
+		applied, err := e.apply(b, delta)
+		if err == nil && applied {
+			err = e.check(b, delta.Checksum)
+		}
+		if err != nil {
+			return e.invalidate(b, err)
+		}
+	}
+	return e.publish(b)
+}
+
+// Update apply the delta of the symbol, the snapshot is loaded first if the book isn't ready
+func (e *Engine) Update(symbol string, delta Delta) []wsex.Message {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b := e.get(symbol)
+	b.recv = delta.RecvTime
+	if b.recv == 0 {
+		b.recv = millis(time.Now())
+	}
+	var msgs []wsex.Message
+	// retry once with a new snapshot if the delta doesn't match the book
+	for i := 0; i < 2; i++ {
+		if !b.ready {
+			loaded, err := e.load(b)
+			if err != nil {
+				return append(msgs, e.invalidate(b, err)...)
+			}
+			if !loaded {
+				// the delta without sequence can't be matched with the snapshot later
+				if delta.sequenced() {
+					if len(b.pending) >= maxPending {
+						b.pending = b.pending[1:]
+					}
+					b.pending = append(b.pending, delta)
+				}
+				return msgs
+			}
+		}
+		applied, err := e.apply(b, delta)
+		if err == nil && applied {
+			if err = e.check(b, delta.Checksum); err == nil {
+				err = e.catchUp(b, delta)
+			}
+		}
+		if err == nil {
+			if applied {
+				msgs = append(msgs, e.publish(b)...)
+				msgs = append(msgs, e.schedule(b)...)
+			}
+			return msgs
+		}
+		msgs = append(msgs, e.invalidate(b, err)...)
+	}
+	return msgs
+}
+
+// Invalidate invalidate the book of the symbol and resync it, eg: the exchange reports the book is broken
+func (e *Engine) Invalidate(symbol string, err error) []wsex.Message {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b := e.get(symbol)
+	msgs := e.invalidate(b, err)
+	if _, err := e.load(b); err != nil {
+		msgs = append(msgs, e.invalidate(b, err)...)
+	}
+	return msgs
+}
+
+// Book the current order book of the symbol
+func (e *Engine) Book(symbol string) (wsex.OrderBook, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b, ok := e.books[symbol]
+	if !ok || !b.ready || b.invalid {
+		return wsex.OrderBook{}, false
+	}
+	orderBook := b.ladder.OrderBook(symbol)
+	orderBook.Timestamp = b.time
+	return orderBook, true
+}
+
+// Top append the best n levels of the symbol to bids[:0] and asks[:0] without allocating, n <= 0 means all levels
+func (e *Engine) Top(symbol string, n int, bids, asks wsex.Depth) (wsex.Depth, wsex.Depth, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	b, ok := e.books[symbol]
+	if !ok || !b.ready || b.invalid {
+		return bids[:0], asks[:0], false
+	}
+	bids, asks = b.ladder.Top(n, bids, asks)
+	return bids, asks, true
+}
+
+// Remove remove the book of the symbol, eg: unsubscribed
+func (e *Engine) Remove(symbol string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.books, symbol)
+}
+
+func (e *Engine) get(symbol string) *localBook {
+	b, ok := e.books[symbol]
+	if !ok {
+		b = &localBook{symbol: symbol, ladder: NewLadder(e.decimals, e.maxDepth)}
+		e.books[symbol] = b
+	}
+	return b
+}
+
+// load get the snapshot, false if the snapshot isn't available now
+func (e *Engine) load(b *localBook) (bool, error) {
+	if e.snapshot == nil || b.requested || time.Now().Before(b.retryAt) {
+		return false, nil
+	}
+	if e.deliver != nil {
+		b.requested = true
+		go e.fetch(b.symbol, b.gen, false)
+		return false, nil
+	}
+	snapshot, err := e.snapshot(b.symbol)
+	if err != nil {
+		b.failed()
+		return false, err
+	}
+	if snapshot == nil {
+		b.requested = true
+		return false, nil
+	}
+	b.backoff = 0
+	b.load(*snapshot)
+	if err := e.check(b, snapshot.Checksum); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// apply the delta to the ready book, false if it's an old delta, error if it doesn't match the book
+func (e *Engine) apply(b *localBook, delta Delta) (bool, error) {
+	if delta.Last != 0 && delta.Last <= b.seq {
+		// old delta which is included in the book already
+		return false, nil
+	}
+	if (b.synced && !delta.follows(b.seq)) || (!b.synced && !delta.bridges(b.seq)) {
+		e.stats.Gaps++
+		return false, wsex.ExError{Code: wsex.ErrInvalidDepth,
+			Message: fmt.Sprintf("sequence gap, book: %v, delta first: %v prev: %v last: %v", b.seq, delta.First, delta.Prev, delta.Last),
+			Data:    map[string]interface{}{"symbol": b.symbol}}
+	}
+	b.ladder.Update(delta.Bids, delta.Asks)
+	if delta.Last != 0 {
+		b.seq = delta.Last
+	}
+	if delta.Time != 0 {
+		b.time = delta.Time
+	}
+	b.synced = true
+	return true, nil
+}
+
+func (e *Engine) check(b *localBook, checksum int64) error {
+	if checksum == 0 || e.verify == nil {
+		return nil
+	}
+	b.bids, b.asks = b.ladder.Top(0, b.bids, b.asks)
+	if err := e.verify(wsex.OrderBook{Symbol: b.symbol, Bids: b.bids, Asks: b.asks}, checksum); err != nil {
+		return mismatch{wsex.ExError{Code: wsex.ErrInvalidDepth, Message: err.Error(),
+			Data: map[string]interface{}{"symbol": b.symbol, "checksum": checksum}}}
+	}
+	return nil
+}
+
+// schedule get the snapshot for comparing if it's time, it's fetched in background if SetAsync
+func (e *Engine) schedule(b *localBook) []wsex.Message {
+	if e.interval <= 0 || e.snapshot == nil || b.comparing || b.shadowing || time.Since(b.compared) < e.interval {
+		return nil
+	}
+	b.compared = time.Now()
+	if e.deliver != nil {
+		b.comparing = true
+		go e.fetch(b.symbol, b.gen, true)
+		return nil
+	}
+	snapshot, err := e.snapshot(b.symbol)
+	if err != nil {
+		// the book is still valid, try again next time
+		return []wsex.Message{wsex.ErrorMessage(err)}
+	}
+	if snapshot == nil {
+		b.comparing = true
+		return nil
+	}
+	return e.compareWith(b, *snapshot)
+}
+
+// fetch call the snapshot func in background and pass the result to Snapshot,
+// it's dropped if the book is reset or the request is finished during the call
+func (e *Engine) fetch(symbol string, gen int64, comparing bool) {
+	snapshot, err := e.snapshot(symbol)
+	var msgs []wsex.Message
+	e.mu.Lock()
+	b, ok := e.books[symbol]
+	switch {
+	case !ok || b.gen != gen || (comparing && !b.comparing) || (!comparing && !b.requested):
+	case err != nil && comparing:
+		// the book is still valid, try again next time
+		b.comparing = false
+		msgs = []wsex.Message{wsex.ErrorMessage(err)}
+	case err != nil:
+		b.requested = false
+		b.failed()
+		msgs = append([]wsex.Message{wsex.ErrorMessage(err)}, e.invalidate(b, err)...)
+	case snapshot != nil:
+		if !comparing {
+			b.backoff = 0
+		}
+		msgs = e.loadSnapshot(b, *snapshot)
+	}
+	// the book is at the last delta received, the other messages are made now
+	now := millis(time.Now())
+	for i := range msgs {
+		if msgs[i].Type == wsex.MsgOrderBook && ok && b.recv > 0 {
+			msgs[i].RecvTime = b.recv
+		} else {
+			msgs[i].RecvTime = now
+		}
+	}
+	deliver := e.deliver
+	e.mu.Unlock()
+	if len(msgs) > 0 && deliver != nil {
+		deliver(symbol, msgs)
+	}
+}
+
+// compareWith keep the snapshot as the shadow book, which is compared when the book reaches its sequence
+func (e *Engine) compareWith(b *localBook, snapshot Snapshot) []wsex.Message {
+	if snapshot.Sequence == 0 || snapshot.Sequence < b.seq {
+		// the deltas before the book can't be replayed to the snapshot
+		return nil
+	}
+	if b.shadow == nil {
+		b.shadow = NewLadder(e.decimals, e.maxDepth)
+	}
+	b.shadow.Reset()
+	b.shadow.Update(snapshot.Bids, snapshot.Asks)
+	b.shadowSeq, b.shadowing = snapshot.Sequence, true
+	if b.seq == b.shadowSeq {
+		b.shadowing = false
+		if err := e.compare(b); err != nil {
+			return e.invalidate(b, err)
+		}
+	}
+	return nil
+}
+
+// catchUp compare the book with the shadow once it passes the sequence of the shadow,
+// the delta passing it is applied to the shadow too, so they're at the same sequence
+func (e *Engine) catchUp(b *localBook, delta Delta) error {
+	if !b.shadowing || b.seq < b.shadowSeq {
+		return nil
+	}
+	b.shadowing = false
+	if b.seq > b.shadowSeq {
+		if !delta.bridges(b.shadowSeq) {
+			return nil
+		}
+		b.shadow.Update(delta.Bids, delta.Asks)
+	}
+	return e.compare(b)
+}
+
+func (e *Engine) compare(b *localBook) error {
+	e.stats.Compares++
+	b.bids, b.asks = b.ladder.Top(compareDepth, b.bids, b.asks)
+	b.shadowBids, b.shadowAsks = b.shadow.Top(compareDepth, b.shadowBids, b.shadowAsks)
+	side, level := "bids", diff(b.bids, b.shadowBids, e.decimals)
+	if level < 0 {
+		side, level = "asks", diff(b.asks, b.shadowAsks, e.decimals)
+	}
+	if level < 0 {
+		return nil
+	}
+	return mismatch{wsex.ExError{Code: wsex.ErrInvalidDepth,
+		Message: fmt.Sprintf("the book differs from the snapshot at sequence %v, %s level %v", b.seq, side, level),
+		Data:    map[string]interface{}{"symbol": b.symbol, "sequence": b.seq}}}
+}
+
+// diff the first different level, -1 if same. the snapshot may be shallower than the book
+func diff(local, snapshot wsex.Depth, decimals int) int {
+	for i := range snapshot {
+		if i >= len(local) {
+			return i
+		}
+		if !sameTicks(local[i].Price, snapshot[i].Price, decimals) || !sameTicks(local[i].Amount, snapshot[i].Amount, decimals) {
+			return i
+		}
+	}
+	return -1
+}
+
+// sameTicks compare the numbers regardless of the format, eg: "1.0" and "1"
+func sameTicks(a, b string, decimals int) bool {
+	if a == b {
+		return true
+	}
+	x, ok := ParseTicks(a, decimals)
+	y, ok2 := ParseTicks(b, decimals)
+	return ok && ok2 && x == y
+}
+
+// invalidate reset the book, BookInvalid is emitted only once until it's resynced
+func (e *Engine) invalidate(b *localBook, err error) []wsex.Message {
+	var msgs []wsex.Message
+	if m, ok := err.(mismatch); ok {
+		e.stats.Mismatches++
+		if e.metrics != nil {
+			e.metrics.BookMismatch(e.exchange, b.symbol)
+		}
+		err = m.ExError
+		msgs = append(msgs, wsex.ErrorMessage(err))
+	}
+	b.reset()
+	if b.invalid {
+		return msgs
+	}
+	b.invalid = true
+	return append(msgs, wsex.Message{Type: wsex.MsgBookInvalid, Data: wsex.BookInvalid{Symbol: b.symbol, Err: err}})
+}
+
+func (e *Engine) publish(b *localBook) []wsex.Message {
+	var msgs []wsex.Message
+	if b.invalid {
+		b.invalid = false
+		e.stats.Resyncs++
+		msgs = append(msgs, wsex.Message{Type: wsex.MsgBookResynced, Data: wsex.BookResynced{Symbol: b.symbol, Sequence: b.seq},
+			Symbol: b.symbol, Sequence: b.seq})
+	}
+	orderBook := b.ladder.OrderBook(b.symbol)
+	orderBook.Timestamp = b.time
+	return append(msgs, wsex.Message{Type: wsex.MsgOrderBook, Data: orderBook, Symbol: b.symbol, Time: b.time, Sequence: b.seq})
+}
+
+// millis the timestamp in ms, same as the timestamps of the exchanges
+func millis(t time.Time) time.Duration {
+	return time.Duration(t.UnixNano() / 1e6)
+}
@@ -0,0 +1,259 @@
+/*
+@Time : 2021/6/11 3:42 下午
+@Author : shiguantian
+@File : engine_test
+@Software: GoLand
+*/
+package book
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+func level(price, amount string) wsex.RawDepth {
+	return wsex.RawDepth{{price, amount}}
+}
+
+func types(msgs []wsex.Message) []wsex.MessageType {
+	var t []wsex.MessageType
+	for _, msg := range msgs {
+		t = append(t, msg.Type)
+	}
+	return t
+}
+
+func expect(t *testing.T, msgs []wsex.Message, want ...wsex.MessageType) {
+	t.Helper()
+	got := types(msgs)
+	if len(got) != len(want) {
+		t.Fatalf("expect messages %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expect messages %v, got %v", want, got)
+		}
+	}
+}
+
+func TestEngine_RestSnapshot(t *testing.T) {
+	var fetched int
+	seq := int64(10)
+	engine := NewEngine(func(symbol string) (*Snapshot, error) {
+		fetched++
+		return &Snapshot{Bids: level("100", "1"), Asks: level("101", "1"), Sequence: seq}, nil
+	})
+
+	// the delta included in the snapshot is dropped
+	expect(t, engine.Update("BTC/USDT", Delta{First: 8, Last: 10, Bids: level("99", "1")}))
+	if fetched != 1 {
+		t.Fatalf("the snapshot should be fetched once, %v", fetched)
+	}
+	expect(t, engine.Update("BTC/USDT", Delta{First: 9, Last: 12, Bids: level("100", "2")}), wsex.MsgOrderBook)
+	msgs := engine.Update("BTC/USDT", Delta{First: 13, Last: 13, Asks: level("101", "0"), Time: 1000})
+	expect(t, msgs, wsex.MsgOrderBook)
+	book := msgs[0].Data.(wsex.OrderBook)
+	if len(book.Asks) != 0 || book.Bids[0].Amount != "2" || book.Timestamp != 1000 {
+		t.Fatalf("unexpected book %+v", book)
+	}
+	if msgs[0].Time != 1000 || msgs[0].Sequence != 13 || msgs[0].Symbol != "BTC/USDT" {
+		t.Fatalf("unexpected message %+v", msgs[0])
+	}
+
+	// gap, resync with a new snapshot
+	seq = 20
+	expect(t, engine.Update("BTC/USDT", Delta{First: 15, Last: 16}), wsex.MsgBookInvalid)
+	if fetched != 2 {
+		t.Fatalf("the snapshot should be fetched again, %v", fetched)
+	}
+	if _, ok := engine.Book("BTC/USDT"); ok {
+		t.Fatal("the invalid book should not be used")
+	}
+	msgs = engine.Update("BTC/USDT", Delta{First: 21, Last: 21})
+	expect(t, msgs, wsex.MsgBookResynced, wsex.MsgOrderBook)
+	if resynced := msgs[0].Data.(wsex.BookResynced); resynced.Sequence != 21 || resynced.Symbol != "BTC/USDT" {
+		t.Fatalf("unexpected resynced %+v", resynced)
+	}
+}
+
+func TestEngine_AsyncSnapshot(t *testing.T) {
+	var requested int
+	engine := NewEngine(func(symbol string) (*Snapshot, error) {
+		requested++
+		return nil, nil
+	})
+	expect(t, engine.Update("BTC/USDT", Delta{Prev: 4, Last: 5, Bids: level("100", "1")}))
+	expect(t, engine.Update("BTC/USDT", Delta{Prev: 5, Last: 6, Bids: level("100", "3")}))
+	if requested != 1 {
+		t.Fatalf("the snapshot should be requested once, %v", requested)
+	}
+	msgs := engine.Snapshot("BTC/USDT", Snapshot{Bids: level("100", "2"), Sequence: 5})
+	expect(t, msgs, wsex.MsgOrderBook)
+	if book := msgs[0].Data.(wsex.OrderBook); book.Bids[0].Amount != "3" {
+		t.Fatalf("the buffered delta should be replayed, %+v", book)
+	}
+
+	// prevSeqNum doesn't match
+	expect(t, engine.Update("BTC/USDT", Delta{Prev: 7, Last: 8}), wsex.MsgBookInvalid)
+	if requested != 2 {
+		t.Fatalf("the snapshot should be requested again, %v", requested)
+	}
+	expect(t, engine.Update("BTC/USDT", Delta{Prev: 8, Last: 9}))
+	expect(t, engine.Snapshot("BTC/USDT", Snapshot{Sequence: 9}), wsex.MsgBookResynced, wsex.MsgOrderBook)
+}
+
+func TestEngine_Checksum(t *testing.T) {
+	engine := NewEngine(nil)
+	engine.SetVerify(func(book wsex.OrderBook, checksum int64) error {
+		if int64(len(book.Bids)) != checksum {
+			return wsex.ExError{Message: "checksum error"}
+		}
+		return nil
+	})
+	// no snapshot pushed yet
+	expect(t, engine.Update("BTC/USDT", Delta{Bids: level("99", "1")}))
+	expect(t, engine.Snapshot("BTC/USDT", Snapshot{Bids: level("100", "1"), Checksum: 1}), wsex.MsgOrderBook)
+	expect(t, engine.Update("BTC/USDT", Delta{Bids: level("99", "1"), Checksum: 2}), wsex.MsgOrderBook)
+	expect(t, engine.Update("BTC/USDT", Delta{Bids: level("98", "1"), Checksum: 2}), wsex.MsgError, wsex.MsgBookInvalid)
+	expect(t, engine.Update("BTC/USDT", Delta{Bids: level("98", "1"), Checksum: 2}))
+	if stats := engine.Stats(); stats.Mismatches != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+}
+
+func TestEngine_Compare(t *testing.T) {
+	snapshot := Snapshot{Bids: level("100", "1"), Asks: level("101", "1"), Sequence: 10}
+	engine := NewEngine(func(symbol string) (*Snapshot, error) {
+		return &snapshot, nil
+	})
+	engine.SetCompareInterval(time.Nanosecond)
+	expect(t, engine.Update("BTC/USDT", Delta{First: 11, Last: 11, Bids: level("100", "2")}), wsex.MsgOrderBook)
+
+	// the snapshot is ahead of the book, compared after the delta passing it
+	snapshot = Snapshot{Bids: level("100", "3"), Asks: level("101", "1.0"), Sequence: 13}
+	expect(t, engine.Update("BTC/USDT", Delta{First: 12, Last: 12, Bids: level("100", "3")}), wsex.MsgOrderBook)
+	expect(t, engine.Update("BTC/USDT", Delta{First: 13, Last: 14, Bids: level("99", "1")}), wsex.MsgOrderBook)
+	if stats := engine.Stats(); stats.Compares != 1 || stats.Mismatches != 0 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+
+	// the book is broken, eg: a delta is lost by the exchange without a gap
+	snapshot = Snapshot{Bids: level("100", "5"), Asks: level("101", "1"), Sequence: 16}
+	expect(t, engine.Update("BTC/USDT", Delta{First: 15, Last: 15, Asks: level("102", "1")}), wsex.MsgOrderBook)
+	msgs := engine.Update("BTC/USDT", Delta{First: 16, Last: 16, Asks: level("103", "1")})
+	expect(t, msgs, wsex.MsgError, wsex.MsgBookInvalid)
+	if err := msgs[0].Data.(wsex.ExError); err.Code != wsex.ErrInvalidDepth || err.Data["symbol"] != "BTC/USDT" {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if stats := engine.Stats(); stats.Compares != 2 || stats.Mismatches != 1 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	// resynced from the snapshot
+	expect(t, engine.Update("BTC/USDT", Delta{First: 17, Last: 17}), wsex.MsgBookResynced, wsex.MsgOrderBook)
+}
+
+func TestEngine_AsyncCompare(t *testing.T) {
+	var requested int
+	engine := NewEngine(func(symbol string) (*Snapshot, error) {
+		requested++
+		return nil, nil
+	})
+	engine.SetCompareInterval(time.Nanosecond)
+	expect(t, engine.Update("BTC/USDT", Delta{Prev: 4, Last: 5}))
+	expect(t, engine.Snapshot("BTC/USDT", Snapshot{Bids: level("100", "1"), Sequence: 4}), wsex.MsgOrderBook)
+	expect(t, engine.Update("BTC/USDT", Delta{Prev: 5, Last: 6, Bids: level("100", "2")}), wsex.MsgOrderBook)
+	if requested != 2 {
+		t.Fatalf("the snapshot should be requested for comparing, %v", requested)
+	}
+	// the snapshot at the same sequence is compared immediately
+	expect(t, engine.Snapshot("BTC/USDT", Snapshot{Bids: level("100", "1"), Sequence: 6}), wsex.MsgError, wsex.MsgBookInvalid)
+	if _, ok := engine.Book("BTC/USDT"); ok {
+		t.Fatal("the invalid book should not be used")
+	}
+}
+
+func TestEngine_BackgroundSnapshot(t *testing.T) {
+	var requested int32
+	release := make(chan *Snapshot)
+	published := make(chan []wsex.Message, 10)
+	engine := NewEngine(func(symbol string) (*Snapshot, error) {
+		atomic.AddInt32(&requested, 1)
+		if snapshot := <-release; snapshot != nil {
+			return snapshot, nil
+		}
+		return nil, wsex.ExError{Code: wsex.ErrBadRequest, Message: "snapshot failed"}
+	})
+	engine.SetAsync(func(symbol string, msgs []wsex.Message) { published <- msgs })
+	wait := func() []wsex.Message {
+		select {
+		case msgs := <-published:
+			return msgs
+		case <-time.After(time.Second):
+			t.Fatal("the snapshot should be published")
+		}
+		return nil
+	}
+
+	// the deltas aren't blocked by the snapshot
+	expect(t, engine.Update("BTC/USDT", Delta{First: 9, Last: 10, Bids: level("100", "1")}))
+	expect(t, engine.Update("BTC/USDT", Delta{First: 11, Last: 11, Bids: level("100", "2"), RecvTime: 1000}))
+	release <- &Snapshot{Bids: level("100", "1"), Sequence: 10}
+	msgs := wait()
+	expect(t, msgs, wsex.MsgOrderBook)
+	if book := msgs[0].Data.(wsex.OrderBook); book.Bids[0].Amount != "2" || msgs[0].Sequence != 11 {
+		t.Fatalf("the buffered delta should be replayed, %+v", msgs[0])
+	}
+	if msgs[0].RecvTime != 1000 {
+		t.Fatalf("the book should carry the receive time of the last delta, %v", msgs[0].RecvTime)
+	}
+
+	// the failed snapshot isn't requested again until the backoff passes
+	expect(t, engine.Update("BTC/USDT", Delta{First: 13, Last: 13}), wsex.MsgBookInvalid)
+	release <- nil
+	expect(t, wait(), wsex.MsgError)
+	expect(t, engine.Update("BTC/USDT", Delta{First: 14, Last: 14}))
+	if n := atomic.LoadInt32(&requested); n != 2 {
+		t.Fatalf("the snapshot should not be requested during the backoff, %v", n)
+	}
+}
+
+type mismatchMetrics struct {
+	wsex.NopMetrics
+	mismatches int32
+}
+
+func (m *mismatchMetrics) BookMismatch(exchange, symbol string) {
+	atomic.AddInt32(&m.mismatches, 1)
+}
+
+func TestEngine_BackgroundCompare(t *testing.T) {
+	snapshots := make(chan Snapshot, 2)
+	snapshots <- Snapshot{Bids: level("100", "1"), Sequence: 10}
+	published := make(chan []wsex.Message, 10)
+	engine := NewEngine(func(symbol string) (*Snapshot, error) {
+		snapshot := <-snapshots
+		return &snapshot, nil
+	})
+	engine.SetAsync(func(symbol string, msgs []wsex.Message) { published <- msgs })
+	engine.SetCompareInterval(time.Nanosecond)
+	metrics := &mismatchMetrics{}
+	engine.SetMetrics("binance", metrics)
+
+	expect(t, engine.Update("BTC/USDT", Delta{First: 11, Last: 11}))
+	expect(t, <-published, wsex.MsgOrderBook)
+	// the snapshot for comparing is fetched in background, the delta isn't blocked
+	expect(t, engine.Update("BTC/USDT", Delta{First: 12, Last: 12, Bids: level("100", "2")}), wsex.MsgOrderBook)
+	snapshots <- Snapshot{Bids: level("100", "3"), Sequence: 12}
+	select {
+	case msgs := <-published:
+		expect(t, msgs, wsex.MsgError, wsex.MsgBookInvalid)
+	case <-time.After(time.Second):
+		t.Fatal("the mismatch should be published")
+	}
+	if n := atomic.LoadInt32(&metrics.mismatches); n != 1 {
+		t.Fatalf("the mismatch should be reported to metrics, %v", n)
+	}
+}
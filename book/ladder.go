@@ -0,0 +1,368 @@
+/*
+@Time : 2021/6/12 2:15 下午
+@Author : shiguantian
+@File : ladder
+@Software: GoLand
+*/
+package book
+
+import (
+	"strconv"
+
+	"github.com/shiguantian/wsex"
+)
+
+const (
+	DefaultDecimals = 10  // the price is scaled to integer ticks of 1e-10, same as utils.ZERO
+	DefaultMaxDepth = 400 // the max levels of each side
+)
+
+var pow10 = [...]int64{1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18}
+
+// ParseTicks parse the decimal price into integer ticks of 10^-decimals, the digits beyond are rounded
+func ParseTicks(price string, decimals int) (int64, bool) {
+	if len(price) == 0 || decimals < 0 || decimals >= len(pow10) {
+		return 0, false
+	}
+	var (
+		ticks    int64
+		fraction = -1 // digits after the point, -1 means no point yet
+		round    bool
+	)
+	for i := 0; i < len(price); i++ {
+		c := price[i]
+		switch {
+		case c == '.' && fraction < 0:
+			fraction = 0
+		case c >= '0' && c <= '9':
+			if fraction >= decimals {
+				// only the first dropped digit decides the rounding
+				if fraction == decimals {
+					round = c >= '5'
+				}
+				fraction++
+				continue
+			}
+			if ticks > (1<<63-1-9)/10 {
+				return 0, false
+			}
+			ticks = ticks*10 + int64(c-'0')
+			if fraction >= 0 {
+				fraction++
+			}
+		default:
+			// scientific notation etc, rarely used by exchanges
+			return parseFloatTicks(price, decimals)
+		}
+	}
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > decimals {
+		fraction = decimals
+	}
+	if ticks > (1<<63-1)/pow10[decimals-fraction] {
+		return 0, false
+	}
+	ticks *= pow10[decimals-fraction]
+	if round {
+		ticks++
+	}
+	return ticks, true
+}
+
+func parseFloatTicks(price string, decimals int) (int64, bool) {
+	f, err := strconv.ParseFloat(price, 64)
+	if err != nil || f < 0 || f*float64(pow10[decimals]) >= 1<<63 {
+		return 0, false
+	}
+	return int64(f*float64(pow10[decimals]) + 0.5), true
+}
+
+// isZero whether the amount is 0, which means removing the level
+func isZero(amount string) bool {
+	for i := 0; i < len(amount); i++ {
+		switch c := amount[i]; {
+		case c == '0' || c == '.':
+		case c >= '1' && c <= '9':
+			return false
+		default:
+			f, err := strconv.ParseFloat(amount, 64)
+			return err != nil || f < 1e-10
+		}
+	}
+	return true
+}
+
+type node struct {
+	ticks       int64
+	item        wsex.DepthItem
+	left, right *node
+	height      int8
+}
+
+func height(n *node) int8 {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *node) fix() {
+	if l, r := height(n.left), height(n.right); l > r {
+		n.height = l + 1
+	} else {
+		n.height = r + 1
+	}
+}
+
+func rotateRight(n *node) *node {
+	l := n.left
+	n.left, l.right = l.right, n
+	n.fix()
+	l.fix()
+	return l
+}
+
+func rotateLeft(n *node) *node {
+	r := n.right
+	n.right, r.left = r.left, n
+	n.fix()
+	r.fix()
+	return r
+}
+
+func balance(n *node) *node {
+	n.fix()
+	switch bf := height(n.left) - height(n.right); {
+	case bf > 1:
+		if height(n.left.left) < height(n.left.right) {
+			n.left = rotateLeft(n.left)
+		}
+		return rotateRight(n)
+	case bf < -1:
+		if height(n.right.right) < height(n.right.left) {
+			n.right = rotateRight(n.right)
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+// Side one side of the order book, the levels are kept in an AVL tree keyed by the price ticks,
+// so each update is O(log n) without sorting, and the removed nodes are reused by the later levels
+type Side struct {
+	root *node
+	size int
+	desc bool  // the best is the highest price, for bids
+	free *node // released nodes, linked by right
+}
+
+func NewSide(desc bool) *Side {
+	return &Side{desc: desc}
+}
+
+// Len the count of the levels
+func (s *Side) Len() int {
+	return s.size
+}
+
+// Set set the level of the price, replace the old one
+func (s *Side) Set(ticks int64, item wsex.DepthItem) {
+	s.root = s.insert(s.root, ticks, item)
+}
+
+// Delete remove the level of the price if exists
+func (s *Side) Delete(ticks int64) {
+	s.root = s.remove(s.root, ticks)
+}
+
+// Best the best level, false if the side is empty
+func (s *Side) Best() (wsex.DepthItem, bool) {
+	n := s.edge(!s.desc)
+	if n == nil {
+		return wsex.DepthItem{}, false
+	}
+	return n.item, true
+}
+
+// Top append the best n levels to dst[:0] from the best, n <= 0 means all levels.
+// nothing is allocated if dst has enough capacity
+func (s *Side) Top(n int, dst wsex.Depth) wsex.Depth {
+	if n <= 0 || n > s.size {
+		n = s.size
+	}
+	return s.walk(s.root, dst[:0], n)
+}
+
+// Trim remove the worst levels beyond max
+func (s *Side) Trim(max int) {
+	for max > 0 && s.size > max {
+		s.Delete(s.edge(s.desc).ticks)
+	}
+}
+
+// Reset remove all levels, the nodes are kept for reuse
+func (s *Side) Reset() {
+	s.release(s.root)
+	s.root, s.size = nil, 0
+}
+
+// edge the node of the lowest price if min, otherwise the highest
+func (s *Side) edge(min bool) *node {
+	n := s.root
+	for n != nil {
+		next := n.right
+		if min {
+			next = n.left
+		}
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return n
+}
+
+func (s *Side) walk(n *node, dst wsex.Depth, max int) wsex.Depth {
+	if n == nil || len(dst) >= max {
+		return dst
+	}
+	first, second := n.left, n.right
+	if s.desc {
+		first, second = n.right, n.left
+	}
+	dst = s.walk(first, dst, max)
+	if len(dst) < max {
+		dst = append(dst, n.item)
+	}
+	return s.walk(second, dst, max)
+}
+
+func (s *Side) insert(n *node, ticks int64, item wsex.DepthItem) *node {
+	if n == nil {
+		s.size++
+		return s.alloc(ticks, item)
+	}
+	switch {
+	case ticks < n.ticks:
+		n.left = s.insert(n.left, ticks, item)
+	case ticks > n.ticks:
+		n.right = s.insert(n.right, ticks, item)
+	default:
+		n.item = item
+		return n
+	}
+	return balance(n)
+}
+
+func (s *Side) remove(n *node, ticks int64) *node {
+	if n == nil {
+		return nil
+	}
+	switch {
+	case ticks < n.ticks:
+		n.left = s.remove(n.left, ticks)
+	case ticks > n.ticks:
+		n.right = s.remove(n.right, ticks)
+	default:
+		if n.left == nil || n.right == nil {
+			child := n.left
+			if child == nil {
+				child = n.right
+			}
+			s.size--
+			n.left, n.right = nil, nil
+			s.release(n)
+			return child
+		}
+		// replaced by the lowest level of the right subtree, which is removed then
+		next := n.right
+		for next.left != nil {
+			next = next.left
+		}
+		n.ticks, n.item = next.ticks, next.item
+		n.right = s.remove(n.right, next.ticks)
+	}
+	return balance(n)
+}
+
+func (s *Side) alloc(ticks int64, item wsex.DepthItem) *node {
+	n := s.free
+	if n == nil {
+		n = &node{}
+	} else {
+		s.free = n.right
+	}
+	*n = node{ticks: ticks, item: item, height: 1}
+	return n
+}
+
+func (s *Side) release(n *node) {
+	if n == nil {
+		return
+	}
+	s.release(n.left)
+	right := n.right
+	*n = node{right: s.free}
+	s.free = n
+	s.release(right)
+}
+
+// Ladder the local order book with integer price ticks
+type Ladder struct {
+	Bids     *Side
+	Asks     *Side
+	decimals int
+	maxDepth int
+}
+
+// NewLadder decimals is the precision of the price ticks, maxDepth is the max levels of each side, 0 means no limit
+func NewLadder(decimals, maxDepth int) *Ladder {
+	return &Ladder{Bids: NewSide(true), Asks: NewSide(false), decimals: decimals, maxDepth: maxDepth}
+}
+
+// Reset remove all levels
+func (l *Ladder) Reset() {
+	l.Bids.Reset()
+	l.Asks.Reset()
+}
+
+// Update update the levels, the level of 0 amount is removed
+func (l *Ladder) Update(bids, asks wsex.RawDepth) {
+	l.update(l.Bids, bids)
+	l.update(l.Asks, asks)
+}
+
+func (l *Ladder) update(side *Side, depth wsex.RawDepth) {
+	for _, raw := range depth {
+		item, err := raw.ParseRawDepthItem()
+		if err != nil {
+			continue
+		}
+		ticks, ok := ParseTicks(item.Price, l.decimals)
+		if !ok {
+			continue
+		}
+		if isZero(item.Amount) {
+			side.Delete(ticks)
+		} else {
+			side.Set(ticks, item)
+		}
+	}
+	side.Trim(l.maxDepth)
+}
+
+// Top the best n levels of both sides appended to bids[:0] and asks[:0], n <= 0 means all levels
+func (l *Ladder) Top(n int, bids, asks wsex.Depth) (wsex.Depth, wsex.Depth) {
+	return l.Bids.Top(n, bids), l.Asks.Top(n, asks)
+}
+
+// OrderBook a copy of the whole book
+func (l *Ladder) OrderBook(symbol string) wsex.OrderBook {
+	return wsex.OrderBook{
+		Symbol: symbol,
+		Bids:   l.Bids.Top(0, make(wsex.Depth, 0, l.Bids.Len())),
+		Asks:   l.Asks.Top(0, make(wsex.Depth, 0, l.Asks.Len())),
+	}
+}
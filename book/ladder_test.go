@@ -0,0 +1,139 @@
+/*
+@Time : 2021/6/12 5:08 下午
+@Author : shiguantian
+@File : ladder_test
+@Software: GoLand
+*/
+package book
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/shiguantian/wsex"
+)
+
+func TestParseTicks(t *testing.T) {
+	cases := []struct {
+		price    string
+		decimals int
+		ticks    int64
+		ok       bool
+	}{
+		{"100", 2, 10000, true},
+		{"100.5", 2, 10050, true},
+		{"0.00012300", 8, 12300, true},
+		{"1.23456", 2, 123, true},
+		{"1.235", 2, 124, true},
+		{"1e-3", 4, 10, true},
+		{"-1", 2, 0, false},
+		{"abc", 2, 0, false},
+		{"", 2, 0, false},
+		{"99999999999", 10, 0, false},
+	}
+	for _, c := range cases {
+		ticks, ok := ParseTicks(c.price, c.decimals)
+		if ticks != c.ticks || ok != c.ok {
+			t.Errorf("ParseTicks(%q, %d) = %d, %v, expect %d, %v", c.price, c.decimals, ticks, ok, c.ticks, c.ok)
+		}
+	}
+}
+
+func TestSide(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, desc := range []bool{true, false} {
+		side := NewSide(desc)
+		levels := map[int64]bool{}
+		for i := 0; i < 5000; i++ {
+			ticks := r.Int63n(500)
+			if r.Intn(3) == 0 {
+				side.Delete(ticks)
+				delete(levels, ticks)
+			} else {
+				side.Set(ticks, wsex.DepthItem{Price: fmt.Sprint(ticks), Amount: "1"})
+				levels[ticks] = true
+			}
+		}
+		var expect []int64
+		for ticks := range levels {
+			expect = append(expect, ticks)
+		}
+		sort.Slice(expect, func(i, j int) bool { return (expect[i] < expect[j]) != desc })
+		top := side.Top(0, nil)
+		if side.Len() != len(expect) || len(top) != len(expect) {
+			t.Fatalf("expect %d levels, got %d %d", len(expect), side.Len(), len(top))
+		}
+		for i, item := range top {
+			if item.Price != fmt.Sprint(expect[i]) {
+				t.Fatalf("level %d expect %d, got %s", i, expect[i], item.Price)
+			}
+		}
+		if best, _ := side.Best(); best.Price != fmt.Sprint(expect[0]) {
+			t.Fatalf("expect best %d, got %s", expect[0], best.Price)
+		}
+		side.Trim(10)
+		if top = side.Top(20, top); len(top) != 10 || top[0].Price != fmt.Sprint(expect[0]) || top[9].Price != fmt.Sprint(expect[9]) {
+			t.Fatalf("the worst levels should be trimmed, %v", top)
+		}
+	}
+}
+
+func TestLadder_Update(t *testing.T) {
+	ladder := NewLadder(DefaultDecimals, 2)
+	ladder.Update(wsex.RawDepth{{"100", "1"}, {"99.5", "2"}, {"99", "3"}}, wsex.RawDepth{{101.0, 1.0}, {"100.5", "0.000"}})
+	book := ladder.OrderBook("BTC/USDT")
+	if len(book.Bids) != 2 || book.Bids[0].Price != "100" || book.Bids[1].Price != "99.5" {
+		t.Fatalf("unexpected bids %v", book.Bids)
+	}
+	if len(book.Asks) != 1 || book.Asks[0].Price != "101" {
+		t.Fatalf("unexpected asks %v", book.Asks)
+	}
+	ladder.Update(wsex.RawDepth{{"100.0", "0"}}, nil)
+	if best, _ := ladder.Bids.Best(); best.Price != "99.5" {
+		t.Fatalf("the level should be removed by the same price, %v", best)
+	}
+}
+
+func TestLadder_TopNoAlloc(t *testing.T) {
+	ladder := NewLadder(DefaultDecimals, DefaultMaxDepth)
+	for i := 0; i < 100; i++ {
+		ladder.Update(wsex.RawDepth{{fmt.Sprint(1000 - i), "1"}}, wsex.RawDepth{{fmt.Sprint(1001 + i), "1"}})
+	}
+	bids, asks := make(wsex.Depth, 0, 20), make(wsex.Depth, 0, 20)
+	if allocs := testing.AllocsPerRun(100, func() { bids, asks = ladder.Top(20, bids, asks) }); allocs != 0 {
+		t.Fatalf("Top should not allocate, %v", allocs)
+	}
+}
+
+func benchmarkDeltas(n int) []wsex.RawDepth {
+	r := rand.New(rand.NewSource(1))
+	deltas := make([]wsex.RawDepth, n)
+	for i := range deltas {
+		amount := "0"
+		if r.Intn(4) != 0 {
+			amount = fmt.Sprintf("%.4f", r.Float64())
+		}
+		deltas[i] = wsex.RawDepth{{fmt.Sprintf("%.2f", 30000+r.Float64()*50), amount}}
+	}
+	return deltas
+}
+
+func BenchmarkLadder_Update(b *testing.B) {
+	deltas := benchmarkDeltas(10000)
+	ladder := NewLadder(DefaultDecimals, DefaultMaxDepth)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ladder.Update(deltas[i%len(deltas)], nil)
+	}
+}
+
+func BenchmarkDepth_Update(b *testing.B) {
+	deltas := benchmarkDeltas(10000)
+	depth := wsex.Depth{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		depth = depth.Update(deltas[i%len(deltas)], true)
+	}
+}
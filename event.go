@@ -0,0 +1,482 @@
+/*
+@Time : 2021/6/14 9:30 上午
+@Author : shiguantian
+@File : event
+@Software: GoLand
+*/
+package wsex
+
+import "time"
+
+// Event the common fields of the typed events
+type Event struct {
+	Exchange    string
+	Symbol      string
+	Time        time.Duration // the event time of the exchange in ms, 0 if unknown
+	RecvTime    time.Duration // the local time receiving the message in ms
+	PublishTime time.Duration // the local time publishing the message in ms
+	Sequence    int64         // 0 if unknown
+}
+
+type OrderBookEvent struct {
+	Event
+	OrderBook OrderBook
+}
+
+type TickerEvent struct {
+	Event
+	Ticker Ticker
+}
+
+type TradeEvent struct {
+	Event
+	Trade Trade
+}
+
+type KLineEvent struct {
+	Event
+	KLine KLine
+}
+
+type OrderEvent struct {
+	Event
+	Order Order
+}
+
+type BalanceEvent struct {
+	Event
+	Balance BalanceUpdate
+}
+
+type PositionsEvent struct {
+	Event
+	Positions FuturePositonsUpdate
+}
+
+type MarkPriceEvent struct {
+	Event
+	MarkPrice MarkPrice
+}
+
+type IndexPriceEvent struct {
+	Event
+	IndexPrice IndexPrice
+}
+
+type LiquidationEvent struct {
+	Event
+	Liquidation Liquidation
+}
+
+type AccountEvent struct {
+	Event
+	Account AccountUpdate
+}
+
+type MarginCallEvent struct {
+	Event
+	MarginCall MarginCall
+}
+
+// Event the common fields of the message
+func (m Message) Event() Event {
+	return Event{Exchange: m.Exchange, Symbol: m.Symbol, Time: m.Time, RecvTime: m.RecvTime, PublishTime: m.PublishTime, Sequence: m.Sequence}
+}
+
+// Err the error of MsgError, or the error carried by other types, eg: BookInvalid
+func (m Message) Err() (error, bool) {
+	switch data := m.Data.(type) {
+	case error:
+		return data, true
+	case BookInvalid:
+		return data.Err, data.Err != nil
+	}
+	return nil, false
+}
+
+func (m Message) OrderBook() (OrderBook, bool) {
+	switch data := m.Data.(type) {
+	case OrderBook:
+		return data, true
+	case *OrderBook:
+		return *data, data != nil
+	}
+	return OrderBook{}, false
+}
+
+// Tickers the tickers of MsgTicker, some exchanges push one ticker and others push a batch
+func (m Message) Tickers() ([]Ticker, bool) {
+	switch data := m.Data.(type) {
+	case Ticker:
+		return []Ticker{data}, true
+	case []Ticker:
+		return data, true
+	}
+	return nil, false
+}
+
+// Trades the trades of MsgTrade, some exchanges push one trade and others push a batch
+func (m Message) Trades() ([]Trade, bool) {
+	switch data := m.Data.(type) {
+	case Trade:
+		return []Trade{data}, true
+	case []Trade:
+		return data, true
+	}
+	return nil, false
+}
+
+// KLines the klines of MsgKLine, some exchanges push one kline and others push a batch
+func (m Message) KLines() ([]KLine, bool) {
+	switch data := m.Data.(type) {
+	case KLine:
+		return []KLine{data}, true
+	case []KLine:
+		return data, true
+	}
+	return nil, false
+}
+
+func (m Message) Order() (Order, bool) {
+	switch data := m.Data.(type) {
+	case Order:
+		return data, true
+	case *Order:
+		return *data, data != nil
+	}
+	return Order{}, false
+}
+
+func (m Message) Balance() (BalanceUpdate, bool) {
+	data, ok := m.Data.(BalanceUpdate)
+	return data, ok
+}
+
+func (m Message) Positions() (FuturePositonsUpdate, bool) {
+	data, ok := m.Data.(FuturePositonsUpdate)
+	return data, ok
+}
+
+func (m Message) MarkPrice() (MarkPrice, bool) {
+	data, ok := m.Data.(MarkPrice)
+	return data, ok
+}
+
+func (m Message) IndexPrice() (IndexPrice, bool) {
+	data, ok := m.Data.(IndexPrice)
+	return data, ok
+}
+
+func (m Message) Liquidation() (Liquidation, bool) {
+	data, ok := m.Data.(Liquidation)
+	return data, ok
+}
+
+func (m Message) Account() (AccountUpdate, bool) {
+	data, ok := m.Data.(AccountUpdate)
+	return data, ok
+}
+
+func (m Message) MarginCall() (MarginCall, bool) {
+	data, ok := m.Data.(MarginCall)
+	return data, ok
+}
+
+// Complete fill the empty Symbol and Time from the Data, it's called before publishing
+func (m *Message) Complete() {
+	if m.Symbol == "" {
+		m.Symbol = m.dataSymbol()
+	}
+	if m.Time == 0 {
+		m.Time = m.dataTime()
+	}
+}
+
+func (m Message) dataSymbol() string {
+	switch data := m.Data.(type) {
+	case OrderBook:
+		return data.Symbol
+	case Ticker:
+		return data.Symbol
+	case Trade:
+		return data.Symbol
+	case KLine:
+		return data.Symbol
+	case Order:
+		return data.Symbol
+	case FuturePositonsUpdate:
+		return data.Symbol
+	case MarkPrice:
+		return data.Symbol
+	case IndexPrice:
+		return data.Symbol
+	case Liquidation:
+		return data.Symbol
+	case BookInvalid:
+		return data.Symbol
+	case BookResynced:
+		return data.Symbol
+	case []Ticker:
+		if len(data) > 0 {
+			return data[0].Symbol
+		}
+	case []Trade:
+		if len(data) > 0 {
+			return data[0].Symbol
+		}
+	case []KLine:
+		if len(data) > 0 {
+			return data[0].Symbol
+		}
+	}
+	return ""
+}
+
+func (m Message) dataTime() time.Duration {
+	switch data := m.Data.(type) {
+	case OrderBook:
+		return data.Timestamp
+	case Ticker:
+		return data.Timestamp
+	case Trade:
+		return data.Timestamp
+	case KLine:
+		return data.Timestamp
+	case Order:
+		return data.TransactionTime
+	case BalanceUpdate:
+		return data.UpdateTime
+	case MarkPrice:
+		return data.Timestamp
+	case IndexPrice:
+		return data.Timestamp
+	case Liquidation:
+		return data.Timestamp
+	case AccountUpdate:
+		return data.UpdateTime
+	case MarginCall:
+		return data.Timestamp
+	case []Ticker:
+		if len(data) > 0 {
+			return data[len(data)-1].Timestamp
+		}
+	case []Trade:
+		if len(data) > 0 {
+			return data[len(data)-1].Timestamp
+		}
+	case []KLine:
+		if len(data) > 0 {
+			return data[len(data)-1].Timestamp
+		}
+	}
+	return 0
+}
+
+// Handler dispatch the messages to the callbacks of their types,
+// the message without callback is passed to the callback of OnMessage
+type Handler struct {
+	onOrderBook   func(OrderBookEvent)
+	onTicker      func(TickerEvent)
+	onTrade       func(TradeEvent)
+	onKLine       func(KLineEvent)
+	onOrder       func(OrderEvent)
+	onBalance     func(BalanceEvent)
+	onPositions   func(PositionsEvent)
+	onMarkPrice   func(MarkPriceEvent)
+	onIndexPrice  func(IndexPriceEvent)
+	onLiquidation func(LiquidationEvent)
+	onAccount     func(AccountEvent)
+	onMarginCall  func(MarginCallEvent)
+	onError       func(Event, error)
+	onMessage     func(Message)
+}
+
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+func (h *Handler) OnOrderBook(f func(OrderBookEvent)) *Handler {
+	h.onOrderBook = f
+	return h
+}
+
+func (h *Handler) OnTicker(f func(TickerEvent)) *Handler {
+	h.onTicker = f
+	return h
+}
+
+// OnTrade f is called for each trade of the message
+func (h *Handler) OnTrade(f func(TradeEvent)) *Handler {
+	h.onTrade = f
+	return h
+}
+
+func (h *Handler) OnKLine(f func(KLineEvent)) *Handler {
+	h.onKLine = f
+	return h
+}
+
+func (h *Handler) OnOrder(f func(OrderEvent)) *Handler {
+	h.onOrder = f
+	return h
+}
+
+func (h *Handler) OnBalance(f func(BalanceEvent)) *Handler {
+	h.onBalance = f
+	return h
+}
+
+func (h *Handler) OnPositions(f func(PositionsEvent)) *Handler {
+	h.onPositions = f
+	return h
+}
+
+func (h *Handler) OnMarkPrice(f func(MarkPriceEvent)) *Handler {
+	h.onMarkPrice = f
+	return h
+}
+
+func (h *Handler) OnIndexPrice(f func(IndexPriceEvent)) *Handler {
+	h.onIndexPrice = f
+	return h
+}
+
+func (h *Handler) OnLiquidation(f func(LiquidationEvent)) *Handler {
+	h.onLiquidation = f
+	return h
+}
+
+func (h *Handler) OnAccount(f func(AccountEvent)) *Handler {
+	h.onAccount = f
+	return h
+}
+
+func (h *Handler) OnMarginCall(f func(MarginCallEvent)) *Handler {
+	h.onMarginCall = f
+	return h
+}
+
+// OnError MsgError and the message of any type carrying an error
+func (h *Handler) OnError(f func(Event, error)) *Handler {
+	h.onError = f
+	return h
+}
+
+// OnMessage the messages not handled by the other callbacks, eg: MsgReConnected, MsgBookInvalid
+func (h *Handler) OnMessage(f func(Message)) *Handler {
+	h.onMessage = f
+	return h
+}
+
+// Handle dispatch the message
+func (h *Handler) Handle(msg Message) {
+	if h.handle(msg) {
+		return
+	}
+	if err, ok := msg.Data.(error); ok && h.onError != nil {
+		h.onError(msg.Event(), err)
+		return
+	}
+	if h.onMessage != nil {
+		h.onMessage(msg)
+	}
+}
+
+func (h *Handler) handle(msg Message) bool {
+	event := msg.Event()
+	switch msg.Type {
+	case MsgOrderBook:
+		if data, ok := msg.OrderBook(); ok && h.onOrderBook != nil {
+			h.onOrderBook(OrderBookEvent{Event: event, OrderBook: data})
+			return true
+		}
+	case MsgTicker:
+		if data, ok := msg.Tickers(); ok && h.onTicker != nil {
+			for _, ticker := range data {
+				event.Symbol = ticker.Symbol
+				if ticker.Timestamp != 0 {
+					event.Time = ticker.Timestamp
+				}
+				h.onTicker(TickerEvent{Event: event, Ticker: ticker})
+			}
+			return true
+		}
+	case MsgTrade:
+		if data, ok := msg.Trades(); ok && h.onTrade != nil {
+			for _, trade := range data {
+				event.Symbol = trade.Symbol
+				if trade.Timestamp != 0 {
+					event.Time = trade.Timestamp
+				}
+				h.onTrade(TradeEvent{Event: event, Trade: trade})
+			}
+			return true
+		}
+	case MsgKLine:
+		if data, ok := msg.KLines(); ok && h.onKLine != nil {
+			for _, kline := range data {
+				event.Symbol = kline.Symbol
+				if kline.Timestamp != 0 {
+					event.Time = kline.Timestamp
+				}
+				h.onKLine(KLineEvent{Event: event, KLine: kline})
+			}
+			return true
+		}
+	case MsgOrder:
+		if data, ok := msg.Order(); ok && h.onOrder != nil {
+			h.onOrder(OrderEvent{Event: event, Order: data})
+			return true
+		}
+	case MsgBalance:
+		if data, ok := msg.Balance(); ok && h.onBalance != nil {
+			h.onBalance(BalanceEvent{Event: event, Balance: data})
+			return true
+		}
+	case MsgPositions:
+		if data, ok := msg.Positions(); ok && h.onPositions != nil {
+			h.onPositions(PositionsEvent{Event: event, Positions: data})
+			return true
+		}
+	case MsgMarkPrice:
+		if data, ok := msg.MarkPrice(); ok && h.onMarkPrice != nil {
+			h.onMarkPrice(MarkPriceEvent{Event: event, MarkPrice: data})
+			return true
+		}
+	case MsgIndexPrice:
+		if data, ok := msg.IndexPrice(); ok && h.onIndexPrice != nil {
+			h.onIndexPrice(IndexPriceEvent{Event: event, IndexPrice: data})
+			return true
+		}
+	case MsgLiquidation:
+		if data, ok := msg.Liquidation(); ok && h.onLiquidation != nil {
+			h.onLiquidation(LiquidationEvent{Event: event, Liquidation: data})
+			return true
+		}
+	case MsgAccount:
+		if data, ok := msg.Account(); ok && h.onAccount != nil {
+			h.onAccount(AccountEvent{Event: event, Account: data})
+			return true
+		}
+	case MsgMarginCall:
+		if data, ok := msg.MarginCall(); ok && h.onMarginCall != nil {
+			h.onMarginCall(MarginCallEvent{Event: event, MarginCall: data})
+			return true
+		}
+	}
+	return false
+}
+
+// Serve dispatch the messages of the channel until it's closed
+func (h *Handler) Serve(ch MessageChan) {
+	for msg := range ch {
+		h.Handle(msg)
+	}
+}
+
+// Chan a channel served by the handler, pass it to the Subscribe methods
+func (h *Handler) Chan(size int) MessageChan {
+	ch := make(MessageChan, size)
+	go h.Serve(ch)
+	return ch
+}
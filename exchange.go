@@ -6,6 +6,8 @@
 */
 package wsex
 
+import "time"
+
 type IExchange interface {
 	//websocket api
 	SubscribeOrderBook(symbol string, level, speed int, isIncremental bool, sub MessageChan) (string, error)
@@ -41,31 +43,107 @@ type IExchange interface {
 
 	CreateOrder(symbol string, price, amount float64, side Side, tradeType TradeType, orderType OrderType, useClientID bool) (Order, error)
 
+	// PlaceOrder place an order with all the options of OrderRequest, options not supported by the exchange return ErrRequestParams
+	PlaceOrder(request OrderRequest) (Order, error)
+
+	// CreateOrders place orders in batch, the result of each order is in the same position as its request.
+	// error is returned only when the whole batch can not be sent
+	CreateOrders(requests []OrderRequest) ([]OrderResult, error)
+
 	CancelOrder(symbol, orderID string) error
 
+	CancelOrders(symbol string, orderIDs []string) ([]CancelResult, error)
+
+	// AmendOrder change price or amount of an open order, zero means unchanged, newAmount is the new total amount include filled.
+	// native amend endpoint is preferred, otherwise the order is cancelled and created again, Method of the result tells the way
+	AmendOrder(symbol, orderID string, newPrice, newAmount float64) (AmendResult, error)
+
 	CancelAllOrders(symbol string) error
 
 	FetchOrder(symbol, orderID string) (Order, error)
 
 	FetchOpenOrders(symbol string, pageIndex, pageSize int) ([]Order, error)
+
+	// FetchConditionalOrders untriggered stop/take-profit/trailing orders placed by PlaceOrder
+	FetchConditionalOrders(symbol string) ([]Order, error)
+
+	// CancelConditionalOrder cancel an untriggered conditional order
+	CancelConditionalOrder(symbol, orderID string) error
+
+	FetchTradingFees(symbol string) (TradingFee, error)
 }
 
 type IFutureExchange interface {
 	IExchange
 
+	// Setting set the position mode, margin mode and leverage in order, stops at the first failure.
+	// the ExError reports the failed step and the applied ones by Data["step"] and Data["applied"]
 	Setting(symbol string, leverage int, marginMode FutureMarginMode, positionMode FuturePositionsMode) error
 
+	GetLeverage(symbol string) (int, error)
+
+	SetLeverage(symbol string, leverage int) error
+
+	GetMarginMode(symbol string) (FutureMarginMode, error)
+
+	SetMarginMode(symbol string, marginMode FutureMarginMode) error
+
+	// GetPositionMode the position mode is account wide on some exchanges, eg: binance, symbol is ignored then
+	GetPositionMode(symbol string) (FuturePositionsMode, error)
+
+	SetPositionMode(symbol string, positionMode FuturePositionsMode) error
+
+	// FetchLeverageBrackets ascending by the notional
+	FetchLeverageBrackets(symbol string) ([]LeverageBracket, error)
+
+	// FetchMaxLeverage the max leverage of the first bracket
+	FetchMaxLeverage(symbol string) (int, error)
+
 	FetchMarkPrice(symbol string) (MarkPrice, error)
 
+	FetchIndexPrice(symbol string) (IndexPrice, error)
+
+	// FetchOpenInterest 当前持仓量, 交易所没有持仓量推送, 需要轮询. zb合约返回ErrNotSupport
+	FetchOpenInterest(symbol string) (OpenInterest, error)
+
+	// FetchOpenInterestHistory 按时间升序, period为统计周期, limit为0时使用交易所默认值. zb合约返回ErrNotSupport
+	FetchOpenInterestHistory(symbol string, period KLineType, limit int) ([]OpenInterest, error)
+
+	// FetchLongShortRatio 大户持仓多空比, 按时间升序. zb合约返回ErrNotSupport
+	FetchLongShortRatio(symbol string, period KLineType, limit int) ([]LongShortRatio, error)
+
 	FetchFundingRate(symbol string) (FundingRate, error)
 
+	// FetchFundingRateHistory 按时间升序的结算费率, since为毫秒时间戳, 0为最近的记录, limit为0时使用交易所默认值.
+	// zb合约没有对应接口, 返回ErrNotSupport
+	FetchFundingRateHistory(symbol string, since time.Duration, limit int) ([]FundingRate, error)
+
+	// FetchFundingPayments 实际收取或支付的资金费用, since为毫秒时间戳. zb合约没有对应接口, 返回ErrNotSupport
+	FetchFundingPayments(symbol string, since time.Duration) ([]FundingPayment, error)
+
 	FetchAccountInfo() (FutureAccountInfo, error)
 
-	FetchPositions(symbol string) (positions []FuturePositons, err error)
+	FetchPositions(symbol string) (positions []Position, err error)
+
+	FetchAllPositions() (positions []Position, err error)
+
+	// ClosePosition 市价平掉symbol的整个多/空仓位
+	ClosePosition(symbol string, positionType PositionType) (Order, error)
+
+	// AdjustPositionMargin 调整逐仓仓位的保证金, amount为正增加,为负减少, 返回调整后的仓位
+	AdjustPositionMargin(symbol string, positionType PositionType, amount float64) (Position, error)
 
-	FetchAllPositions() (positions []FuturePositons, err error)
+	FetchMarginHistory(symbol string) ([]MarginChange, error)
 
 	SubscribePositions(symbol string, sub MessageChan) (string, error)
 
 	SubscribeMarkPrice(symbol string, sub MessageChan) (string, error)
+
+	SubscribeIndexPrice(symbol string, sub MessageChan) (string, error)
+
+	// SubscribeLiquidations 强平订单, symbol为空时订阅所有币对
+	SubscribeLiquidations(symbol string, sub MessageChan) (string, error)
+
+	// SubscribeAccount 账户变动(MsgAccount)和追加保证金通知(MsgMarginCall), zb合约没有追加保证金通知, 只推送MsgAccount
+	SubscribeAccount(sub MessageChan) (string, error)
 }
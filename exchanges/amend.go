@@ -0,0 +1,115 @@
+/*
+@Time : 2021/6/4 10:42 上午
+@Author : shiguantian
+@File : amend
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"fmt"
+
+	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/utils"
+)
+
+// OrderAPI order methods used by the amend fallback
+type OrderAPI interface {
+	FetchOrder(symbol, orderID string) (wsex.Order, error)
+	CancelOrder(symbol, orderID string) error
+	PlaceOrder(request wsex.OrderRequest) (wsex.Order, error)
+}
+
+// ResolveAmend price and unfilled amount of the order after amended, zero newPrice/newAmount means unchanged
+func ResolveAmend(order wsex.Order, newPrice, newAmount float64) (price, remaining float64, err error) {
+	if order.Status != wsex.Open && order.Status != wsex.Partial {
+		err = wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("order %s is %s, can not be amended", order.ID, order.Status)}
+		return
+	}
+	price, amount := newPrice, newAmount
+	if price == 0 {
+		price = utils.SafeParseFloat(order.Price)
+	}
+	if amount == 0 {
+		amount = utils.SafeParseFloat(order.Amount)
+	}
+	remaining = amount - utils.SafeParseFloat(order.Filled)
+	if remaining <= utils.ZERO {
+		err = wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("new amount %v of order %s is not greater than filled %s", amount, order.ID, order.Filled)}
+	}
+	return
+}
+
+// AmendOrderByCancelCreate cancel the order and place a new one with the unfilled amount.
+// the new order is not placed if the cancel fails, so the original order is untouched.
+// the unfilled amount is computed from the final state fetched after cancelled, the order may be filled before the cancel,
+// nothing is placed if the cancelled state can not be confirmed.
+// the new order keeps the type, time in force, stop price, trigger type and reduce only of the original one,
+// the trailing stop order can't be reproduced and is not amended
+func AmendOrderByCancelCreate(api OrderAPI, symbol, orderID string, newPrice, newAmount float64) (result wsex.AmendResult, err error) {
+	result.Method = wsex.AmendCancelCreate
+	order, err := api.FetchOrder(symbol, orderID)
+	if err != nil {
+		return
+	}
+	if order.Type == wsex.TRAILING_STOP {
+		err = wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("trailing stop order %s can not be amended", orderID)}
+		return
+	}
+	price, _, err := ResolveAmend(order, newPrice, newAmount)
+	if err != nil {
+		return
+	}
+	amount := newAmount
+	if amount == 0 {
+		amount = utils.SafeParseFloat(order.Amount)
+	}
+	if err = api.CancelOrder(symbol, orderID); err != nil {
+		return
+	}
+	final, err := api.FetchOrder(symbol, orderID)
+	if err != nil {
+		err = wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("order %s was cancelled but its final state is unknown: %v", orderID, err)}
+		return
+	}
+	// the cancelled order with fills is reported as Close by the adapters
+	filled := utils.SafeParseFloat(final.Filled)
+	cancelled := final.Status == wsex.Canceled ||
+		final.Status == wsex.Close && filled < utils.SafeParseFloat(final.Amount)-utils.ZERO
+	if !cancelled {
+		err = wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("order %s is %s after cancelled, the new order is not placed", orderID, final.Status)}
+		return
+	}
+	remaining := amount - filled
+	if remaining <= utils.ZERO {
+		err = wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("order %s filled %s before cancelled, nothing to place", orderID, final.Filled)}
+		return
+	}
+	tradeType := order.Type
+	if tradeType == "" || tradeType == wsex.TradeTypeUnKnown {
+		tradeType = wsex.LIMIT
+	}
+	request := wsex.OrderRequest{
+		Symbol:      symbol,
+		Price:       price,
+		Amount:      remaining,
+		Side:        order.Side,
+		Type:        tradeType,
+		OrderType:   order.OrderType,
+		UseClientID: order.ClientID != "",
+		ReduceOnly:  order.ReduceOnly,
+	}
+	if tradeType.IsConditional() {
+		request.StopPrice = utils.SafeParseFloat(order.StopPrice)
+		request.TriggerType = order.TriggerType
+	}
+	result.Order, err = api.PlaceOrder(request)
+	if err != nil {
+		code := wsex.UnHandleError
+		if exErr, ok := err.(wsex.ExError); ok {
+			code = exErr.Code
+		}
+		err = wsex.ExError{Code: code, Message: fmt.Sprintf("order %s was cancelled but the new order failed: %v", orderID, err)}
+	}
+	return
+}
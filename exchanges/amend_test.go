@@ -0,0 +1,119 @@
+/*
+@Time : 2021/6/4 11:20 上午
+@Author : shiguantian
+@File : amend_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/utils"
+)
+
+type fakeOrderAPI struct {
+	order     wsex.Order
+	final     *wsex.Order // the order fetched after cancelled, the canceled order by default
+	cancelErr error
+	cancelled bool
+	created   []float64
+	requests  []wsex.OrderRequest
+}
+
+func (f *fakeOrderAPI) FetchOrder(symbol, orderID string) (wsex.Order, error) {
+	if !f.cancelled {
+		return f.order, nil
+	}
+	if f.final != nil {
+		return *f.final, nil
+	}
+	// the adapters report the cancelled order with fills as Close
+	order := f.order
+	order.Status = wsex.Canceled
+	if utils.SafeParseFloat(order.Filled) > 0 {
+		order.Status = wsex.Close
+	}
+	return order, nil
+}
+
+func (f *fakeOrderAPI) CancelOrder(symbol, orderID string) error {
+	f.cancelled = f.cancelErr == nil
+	return f.cancelErr
+}
+
+func (f *fakeOrderAPI) PlaceOrder(request wsex.OrderRequest) (wsex.Order, error) {
+	f.created = append(f.created, request.Price, request.Amount)
+	f.requests = append(f.requests, request)
+	return wsex.Order{ID: "2", Symbol: request.Symbol, Side: request.Side}, nil
+}
+
+func TestAmendOrderByCancelCreate(t *testing.T) {
+	api := &fakeOrderAPI{order: wsex.Order{ID: "1", Price: "100", Amount: "3", Filled: "1", Status: wsex.Partial, Side: wsex.Buy, Type: wsex.LIMIT}}
+	result, err := AmendOrderByCancelCreate(api, "BTC/USDT", "1", 101, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Method != wsex.AmendCancelCreate || result.Order.ID != "2" {
+		t.Errorf("unexpected result %v", result)
+	}
+	if !api.cancelled || len(api.created) != 2 || api.created[0] != 101 || api.created[1] != 2 {
+		t.Errorf("expected cancel then create 2@101, got %v", api.created)
+	}
+
+	api = &fakeOrderAPI{order: api.order, cancelErr: errors.New("order not found")}
+	if _, err = AmendOrderByCancelCreate(api, "BTC/USDT", "1", 101, 0); err == nil || len(api.created) != 0 {
+		t.Errorf("order should not be created when cancel fails")
+	}
+
+	api = &fakeOrderAPI{order: wsex.Order{ID: "1", Price: "100", Amount: "3", Filled: "1", Status: wsex.Partial}}
+	if _, err = AmendOrderByCancelCreate(api, "BTC/USDT", "1", 0, 1); err == nil || api.cancelled {
+		t.Errorf("amount less than filled should be rejected before cancel")
+	}
+}
+
+func TestAmendOrderByCancelCreate_FilledBeforeCancel(t *testing.T) {
+	order := wsex.Order{ID: "1", Price: "100", Amount: "3", Filled: "1", Status: wsex.Partial, Side: wsex.Buy, Type: wsex.LIMIT}
+	// one more is filled between the fetch and the cancel
+	api := &fakeOrderAPI{order: order, final: &wsex.Order{ID: "1", Price: "100", Amount: "3", Filled: "2", Status: wsex.Close}}
+	if _, err := AmendOrderByCancelCreate(api, "BTC/USDT", "1", 101, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.created) != 2 || api.created[1] != 1 {
+		t.Errorf("expected the final unfilled 1 to be placed, got %v", api.created)
+	}
+
+	// fully filled before the cancel
+	api = &fakeOrderAPI{order: order, final: &wsex.Order{ID: "1", Amount: "3", Filled: "3", Status: wsex.Close}}
+	if _, err := AmendOrderByCancelCreate(api, "BTC/USDT", "1", 101, 0); err == nil || len(api.created) != 0 {
+		t.Errorf("nothing should be placed when the order is filled, got %v", api.created)
+	}
+
+	// the cancel is not confirmed yet
+	api = &fakeOrderAPI{order: order, final: &wsex.Order{ID: "1", Amount: "3", Filled: "1", Status: wsex.Partial}}
+	if _, err := AmendOrderByCancelCreate(api, "BTC/USDT", "1", 101, 0); err == nil || len(api.created) != 0 {
+		t.Errorf("nothing should be placed before the cancel is confirmed, got %v", api.created)
+	}
+}
+
+func TestAmendOrderByCancelCreate_KeepOptions(t *testing.T) {
+	order := wsex.Order{ID: "1", ClientID: "c1", Price: "100", Amount: "3", Filled: "0", Status: wsex.Open, Side: wsex.Sell,
+		Type: wsex.STOP_LIMIT, OrderType: wsex.PostOnly, StopPrice: "99", TriggerType: wsex.TriggerMark, ReduceOnly: true}
+	api := &fakeOrderAPI{order: order}
+	if _, err := AmendOrderByCancelCreate(api, "BTC/USDT", "1", 101, 0); err != nil {
+		t.Fatal(err)
+	}
+	request := api.requests[0]
+	if request.Type != wsex.STOP_LIMIT || request.OrderType != wsex.PostOnly || request.StopPrice != 99 ||
+		request.TriggerType != wsex.TriggerMark || !request.ReduceOnly || !request.UseClientID || request.Side != wsex.Sell {
+		t.Errorf("the options of the original order should be kept, got %+v", request)
+	}
+
+	order.Type = wsex.TRAILING_STOP
+	api = &fakeOrderAPI{order: order}
+	if _, err := AmendOrderByCancelCreate(api, "BTC/USDT", "1", 101, 0); err == nil || api.cancelled {
+		t.Errorf("the trailing stop order should not be amended")
+	}
+}
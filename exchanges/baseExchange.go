@@ -10,11 +10,15 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/shiguantian/wsex"
 )
 
@@ -31,9 +35,13 @@ const (
 	GET     = "GET"
 	POST    = "POST"
 	PUT     = "PUT"
+	PATCH   = "PATCH"
 	DELETE  = "DELETE"
 )
 
+// SnapshotTimeout the timeout of requesting the snapshot of the order book by rest api
+const SnapshotTimeout = time.Second * 10
+
 type FetchCallBack interface {
 	Sign(access, method, function string, param url.Values, header http.Header) Request
 	HandleError(request Request, response []byte) error
@@ -42,12 +50,14 @@ type FetchCallBack interface {
 type BaseExchange struct {
 	Option        wsex.Options
 	ConnectionMgr *ConnectionManager
+	SubscribeAck  *SubscribeAck
 
 	RwLock sync.RWMutex
 }
 
 func (b *BaseExchange) Init() {
 	b.ConnectionMgr = NewConnectionManager()
+	b.SubscribeAck = NewSubscribeAck()
 	b.RwLock = sync.RWMutex{}
 }
 
@@ -84,11 +94,14 @@ func (b *BaseExchange) Fetch(callBack FetchCallBack, access, method, function st
 	}
 	req.Header = header
 
+	start := time.Now()
 	res, err := client.Do(req)
 	if err != nil {
+		b.observeRest(function, 0, time.Since(start), nil)
 		return nil, wsex.ExError{Code: wsex.ErrBadRequest, Message: err.Error()}
 	}
 	defer res.Body.Close()
+	b.observeRest(function, res.StatusCode, time.Since(start), res.Header)
 
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
@@ -101,39 +114,129 @@ func (b *BaseExchange) Fetch(callBack FetchCallBack, access, method, function st
 	return body, nil
 }
 
+// observeRest record the latency and the rate limit usage of the rest request
+func (b *BaseExchange) observeRest(function string, status int, latency time.Duration, header http.Header) {
+	metrics := b.Option.Metrics
+	if metrics == nil {
+		return
+	}
+	metrics.ObserveRest(b.Option.ExchangeName, b.endpoint(function), status, latency)
+	for key, values := range header {
+		name := strings.ToLower(key)
+		if !strings.Contains(name, "used-weight") && !strings.Contains(name, "order-count") && !strings.Contains(name, "ratelimit") {
+			continue
+		}
+		if value, err := strconv.ParseFloat(values[0], 64); err == nil {
+			metrics.RateLimit(b.Option.ExchangeName, name, value)
+		}
+	}
+}
+
+// endpoint the label of the rest api for metrics, the query is removed and the path segments of the order ids and symbols
+// are replaced with {id} and {symbol}, eg: /api/spot/v3/orders/123?instrument_id=BTC-USDT -> /api/spot/v3/orders/{id}
+func (b *BaseExchange) endpoint(function string) string {
+	if i := strings.IndexByte(function, '?'); i >= 0 {
+		function = function[:i]
+	}
+	segments := strings.Split(function, "/")
+	for i, segment := range segments {
+		if segment == "" || versionSegment(segment) {
+			continue
+		}
+		if _, err := b.GetMarketByID(segment); err == nil {
+			segments[i] = "{symbol}"
+		} else if strings.ContainsAny(segment, "0123456789") {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// versionSegment the version of the api, eg: v3
+func versionSegment(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(segment[1:])
+	return err == nil
+}
+
+// WaitSubscribeAck wait the ack of the subscribe request registered with id
+func (b *BaseExchange) WaitSubscribeAck(id string, ack <-chan error) error {
+	return b.SubscribeAck.Wait(id, ack, b.Option.SubscribeTimeout)
+}
+
 func (b *BaseExchange) ReConnectedHandler(url string, f func()) {
+	if b.Option.Metrics != nil {
+		b.Option.Metrics.ConnectionEvent(b.Option.ExchangeName, url, wsex.ConnReconnected, "")
+	}
 	if f != nil {
 		f()
 	}
 	//Notify subscribers of reconnection message, then clean up the channel
 	//because after receiving the reconnection notification, the subscribers will resubscribe and use the new channel
-	b.ConnectionMgr.PublishAfterClear(url, wsex.ReConnectedMessage)
+	b.ConnectionMgr.BroadcastAfterClear(url, wsex.ReConnectedMessage)
 }
 
 func (b *BaseExchange) DisConnectedHandler(url string, err error, f func()) {
 	// clear cache data, Prevent getting dirty data
 	b.RwLock.Lock()
 	defer b.RwLock.Unlock()
+	if b.Option.Metrics != nil {
+		b.Option.Metrics.ConnectionEvent(b.Option.ExchangeName, url, wsex.ConnDisconnected, disconnectReason(err))
+	}
 	if f != nil {
 		f()
 	}
-	b.ConnectionMgr.Publish(url, wsex.DisConnectedMessage)
+	b.ConnectionMgr.Broadcast(url, wsex.DisConnectedMessage)
 }
 
 func (b *BaseExchange) CloseHandler(url string, f func()) {
 	// clear cache data and the connection
 	b.RwLock.Lock()
 	defer b.RwLock.Unlock()
+	if b.Option.Metrics != nil {
+		reason := "closed"
+		if conn, err := b.ConnectionMgr.GetConnection(url, nil); err == nil {
+			reason = conn.CloseReason()
+		}
+		b.Option.Metrics.ConnectionEvent(b.Option.ExchangeName, url, wsex.ConnClosed, reason)
+	}
 	if f != nil {
 		f()
 	}
-	b.ConnectionMgr.Publish(url, wsex.CloseMessage)
+	b.ConnectionMgr.Broadcast(url, wsex.CloseMessage)
 	b.ConnectionMgr.RemoveConnection(url)
 }
 
 func (b *BaseExchange) ErrorHandler(url string, err error, f func()) {
+	if b.Option.Metrics != nil {
+		b.Option.Metrics.MessageError(b.Option.ExchangeName, url, errorKind(err))
+	}
 	if f != nil {
 		f()
 	}
-	b.ConnectionMgr.Publish(url, wsex.ErrorMessage(err))
+	b.ConnectionMgr.Broadcast(url, wsex.ErrorMessage(err))
+}
+
+// disconnectReason a few kinds of the read error, to keep the metrics labels bounded
+func disconnectReason(err error) string {
+	if closeErr, ok := err.(*websocket.CloseError); ok {
+		return fmt.Sprintf("close %d", closeErr.Code)
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return "timeout"
+	}
+	return "error"
+}
+
+// errorKind the code of ExError, or "decode" for the unmarshal errors of the message handlers
+func errorKind(err error) string {
+	if exErr, ok := err.(wsex.ExError); ok {
+		return strconv.Itoa(exErr.Code)
+	}
+	if strings.Contains(err.Error(), "Unmarshal") || strings.Contains(err.Error(), "unmarshal") {
+		return "decode"
+	}
+	return "other"
 }
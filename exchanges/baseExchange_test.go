@@ -0,0 +1,31 @@
+/*
+@Time : 2021/6/18 4:10 下午
+@Author : shiguantian
+@File : baseExchange_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"testing"
+
+	"github.com/shiguantian/wsex"
+)
+
+func TestBaseExchange_Endpoint(t *testing.T) {
+	b := BaseExchange{Option: wsex.Options{Markets: map[string]wsex.Market{"BTC/USDT": {Symbol: "BTC/USDT", SymbolID: "BTC-USDT"}}}}
+	cases := map[string]string{
+		"/api/v3/depth":                             "/api/v3/depth",
+		"/fapi/v1/leverageBracket?symbol=X":         "/fapi/v1/leverageBracket",
+		"/api/spot/v3/orders/6503234651":            "/api/spot/v3/orders/{id}",
+		"/api/spot/v3/amend_order/BTC-USDT":         "/api/spot/v3/amend_order/{symbol}",
+		"/api/spot/v3/instruments/btc-usdt/book":    "/api/spot/v3/instruments/{symbol}/book",
+		"/spot/orders/12345?currency_pair=BTC_USDT": "/spot/orders/{id}",
+		"getUnfinishedOrdersIgnoreTradeType":        "getUnfinishedOrdersIgnoreTradeType",
+	}
+	for function, want := range cases {
+		if got := b.endpoint(function); got != want {
+			t.Fatalf("the endpoint of %s should be %s, got %s", function, want, got)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+/*
+@Time : 2021/6/2 2:17 下午
+@Author : shiguantian
+@File : batch
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"sync"
+
+	"github.com/shiguantian/wsex"
+)
+
+// MaxBatchConcurrency max number of requests in flight when an exchange has no native batch endpoint
+const MaxBatchConcurrency = 5
+
+// CreateOrdersConcurrently place orders one by one with bounded concurrency, results keep the order of requests
+func CreateOrdersConcurrently(requests []wsex.OrderRequest, create func(req wsex.OrderRequest) (wsex.Order, error)) []wsex.OrderResult {
+	results := make([]wsex.OrderResult, len(requests))
+	runConcurrently(len(requests), func(i int) {
+		order, err := create(requests[i])
+		results[i] = wsex.OrderResult{Order: order, Err: err}
+	})
+	return results
+}
+
+// CancelOrdersConcurrently cancel orders one by one with bounded concurrency, results keep the order of ids
+func CancelOrdersConcurrently(orderIDs []string, cancel func(orderID string) error) []wsex.CancelResult {
+	results := make([]wsex.CancelResult, len(orderIDs))
+	runConcurrently(len(orderIDs), func(i int) {
+		results[i] = wsex.CancelResult{OrderID: orderIDs[i], Err: cancel(orderIDs[i])}
+	})
+	return results
+}
+
+func runConcurrently(n int, f func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, MaxBatchConcurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
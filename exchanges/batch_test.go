@@ -0,0 +1,69 @@
+/*
+@Time : 2021/6/2 3:05 下午
+@Author : shiguantian
+@File : batch_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+func TestCreateOrdersConcurrently(t *testing.T) {
+	requests := make([]wsex.OrderRequest, 20)
+	for i := range requests {
+		requests[i] = wsex.OrderRequest{Symbol: "BTC/USDT", Price: float64(i)}
+	}
+	var running, maxRunning int32
+	results := CreateOrdersConcurrently(requests, func(req wsex.OrderRequest) (wsex.Order, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 10)
+		atomic.AddInt32(&running, -1)
+		if int(req.Price)%2 == 1 {
+			return wsex.Order{}, errors.New("odd")
+		}
+		return wsex.Order{ID: fmt.Sprintf("%v", req.Price)}, nil
+	})
+	if maxRunning > MaxBatchConcurrency {
+		t.Errorf("max concurrency %d exceeds %d", maxRunning, MaxBatchConcurrency)
+	}
+	for i, result := range results {
+		if i%2 == 1 && result.Err == nil {
+			t.Errorf("result %d should fail", i)
+		}
+		if i%2 == 0 && result.Order.ID != fmt.Sprintf("%v", i) {
+			t.Errorf("result %d out of order: %v", i, result.Order.ID)
+		}
+	}
+}
+
+func TestCancelOrdersConcurrently(t *testing.T) {
+	ids := []string{"1", "2", "3"}
+	results := CancelOrdersConcurrently(ids, func(orderID string) error {
+		if orderID == "2" {
+			return errors.New("not found")
+		}
+		return nil
+	})
+	for i, result := range results {
+		if result.OrderID != ids[i] {
+			t.Errorf("result %d out of order: %v", i, result.OrderID)
+		}
+		if (result.Err != nil) != (ids[i] == "2") {
+			t.Errorf("unexpected result of %v: %v", result.OrderID, result.Err)
+		}
+	}
+}
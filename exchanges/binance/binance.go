@@ -17,6 +17,7 @@ func New(options wsex.Options) *Binance {
 	instance := &Binance{}
 	instance.BinanceRest.Init(options)
 	instance.BinanceWs.Init(options)
+	instance.BinanceWs.userData.backfill = instance.backfillUserData
 
 	if len(options.Markets) == 0 {
 		instance.BinanceWs.Option.Markets, _ = instance.FetchMarkets()
@@ -24,3 +25,16 @@ func New(options wsex.Options) *Binance {
 
 	return instance
 }
+
+// backfillUserData publish the balances and the orders which may be missed while the listenKey was renewed
+func (e *Binance) backfillUserData(gap userDataGap, publish func(topic string, msg wsex.Message)) (err error) {
+	balances, err := e.FetchBalance()
+	if err == nil {
+		publish(topicBalance, wsex.Message{Type: wsex.MsgBalance, Data: wsex.BalanceUpdate{Balances: balances}})
+	}
+	fetcher := orderFetcher{open: e.BinanceRest.FetchOpenOrders, since: e.BinanceRest.fetchOrdersSince, order: e.BinanceRest.FetchOrder}
+	if orderErr := backfillOrders(gap, fetcher, publish); orderErr != nil {
+		err = orderErr
+	}
+	return
+}
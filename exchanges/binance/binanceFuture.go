@@ -20,9 +20,35 @@ func NewFuture(options wsex.Options, futureOptions wsex.FutureOptions)  *Binance
 	instance.BinanceFutureWs.accountType = futureOptions.FutureAccountType
 	instance.BinanceFutureWs.contractType = futureOptions.ContractType
 	instance.BinanceFutureWs.futuresKind = futureOptions.FuturesKind
+	instance.BinanceFutureWs.userData.backfill = instance.backfillUserData
 
 	if len(options.Markets) == 0 {
 		instance.BinanceFutureWs.Option.Markets, _ = instance.BinanceFutureRest.FetchMarkets()
 	}
 	return instance
-}
\ No newline at end of file
+}
+
+// backfillUserData publish the balances, positions and orders which may be missed while the listenKey was renewed
+func (e *BinanceFuture) backfillUserData(gap userDataGap, publish func(topic string, msg wsex.Message)) (err error) {
+	balances, balanceErr := e.BinanceFutureRest.FetchBalance()
+	if balanceErr != nil {
+		err = balanceErr
+	} else {
+		publish(topicBalance, wsex.Message{Type: wsex.MsgBalance, Data: wsex.BalanceUpdate{Balances: balances}})
+	}
+	positions, positionErr := e.FetchAllPositions()
+	if positionErr != nil {
+		err = positionErr
+	} else {
+		publish(topicPositions, wsex.Message{Type: wsex.MsgPositions, Data: wsex.FuturePositonsUpdate{Positons: positions}})
+	}
+	// the account update is the full state, it's published only if both are fetched
+	if balanceErr == nil && positionErr == nil {
+		publish(topicAccount, wsex.Message{Type: wsex.MsgAccount, Data: wsex.AccountUpdate{Balances: balances, Positions: positions}})
+	}
+	fetcher := orderFetcher{open: e.BinanceFutureRest.FetchOpenOrders, since: e.BinanceFutureRest.fetchOrdersSince, order: e.BinanceFutureRest.FetchOrder}
+	if orderErr := backfillOrders(gap, fetcher, publish); orderErr != nil {
+		err = orderErr
+	}
+	return
+}
@@ -28,6 +28,9 @@ type BinanceFutureRest struct {
 
 func (e *BinanceFutureRest) Init(option wsex.Options) {
 	e.Option = option
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = string(wsex.Binance)
+	}
 	e.errors = make(map[int]RawError)
 
 	if e.Option.RestHost == "" {
@@ -254,14 +257,59 @@ func (e *BinanceFutureRest) FetchMarkets() (map[string]wsex.Market, error) {
 }
 
 func (e *BinanceFutureRest) CreateOrder(symbol string, price, amount float64, side wsex.Side, tradeType wsex.TradeType, orderType wsex.OrderType, useClientID bool) (order wsex.Order, err error) {
-	market, err := e.GetMarket(symbol)
+	return e.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Price: price, Amount: amount, Side: side, Type: tradeType, OrderType: orderType, UseClientID: useClientID})
+}
+
+func (e *BinanceFutureRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
+	market, err := e.GetMarket(req.Symbol)
 	if err != nil {
 		return
 	}
-	params := url.Values{}
+	params, err := e.orderParams(market, req)
+	if err != nil {
+		return
+	}
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/order", params, http.Header{})
+	if err != nil {
+		return
+	}
+	type response struct {
+		ID  int64  `json:"orderId"`
+		CID string `json:"clientOrderId"`
+	}
+	var data response
+	err = json.Unmarshal(res, &data)
+	if err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	order.ID = strconv.FormatInt(data.ID, 10)
+	order.ClientID = data.CID
+	return
+}
+
+func (e *BinanceFutureRest) orderParams(market wsex.Market, req wsex.OrderRequest) (params url.Values, err error) {
+	req = req.Normalize()
+	if err = exchanges.CheckConditionalRequest("binance future", req, wsex.TriggerLast, wsex.TriggerMark); err != nil {
+		return
+	}
+	if req.QuoteAmount > 0 {
+		err = exchanges.ErrNotSupport("binance future", "quote amount")
+		return
+	}
+	if req.ClosePosition && req.Type != wsex.STOP_MARKET && req.Type != wsex.TAKE_PROFIT_MARKET {
+		err = exchanges.ErrNotSupport("binance future", fmt.Sprintf("close position %s order", req.Type))
+		return
+	}
+	params = url.Values{}
 	params.Set("symbol", market.SymbolID)
-	params.Set("quantity", utils.Round(amount, market.AmountPrecision, false))
-	switch side {
+	// closePosition=true closes the whole position when triggered, quantity and reduceOnly are not allowed
+	if req.ClosePosition {
+		params.Set("closePosition", "true")
+	} else {
+		params.Set("quantity", utils.Round(req.Amount, market.AmountPrecision, false))
+	}
+	switch req.Side {
 	case wsex.OpenLong:
 		params.Set("side", "BUY")
 		params.Set("positionSide", "LONG")
@@ -274,37 +322,130 @@ func (e *BinanceFutureRest) CreateOrder(symbol string, price, amount float64, si
 	case wsex.CloseShort:
 		params.Set("side", "BUY")
 		params.Set("positionSide", "SHORT")
+	case wsex.Buy, wsex.Sell:
+		params.Set("side", string(req.Side))
+		switch req.PositionSide {
+		case wsex.PositionLong:
+			params.Set("positionSide", "LONG")
+		case wsex.PositionShort:
+			params.Set("positionSide", "SHORT")
+		}
+	default:
+		err = exchanges.ErrNotSupport("binance future", fmt.Sprintf("side %s", req.Side))
+		return
+	}
+	params.Set("type", futureOrderType(req.Type))
+	if req.Type.IsLimit() {
+		params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+		switch req.OrderType {
+		case wsex.PostOnly:
+			params.Set("timeInForce", "GTX")
+		case wsex.IOC:
+			params.Set("timeInForce", "IOC")
+		case wsex.FOK:
+			params.Set("timeInForce", "FOK")
+		default:
+			params.Set("timeInForce", "GTC")
+		}
+	}
+	switch req.Type {
+	case wsex.TRAILING_STOP:
+		// callbackRate in percent
+		params.Set("callbackRate", strconv.FormatFloat(req.CallbackRate*100, 'f', 1, 64))
+		if req.ActivationPrice > 0 {
+			params.Set("activationPrice", utils.Round(req.ActivationPrice, market.PricePrecision, false))
+		}
+	case wsex.STOP_MARKET, wsex.STOP_LIMIT, wsex.TAKE_PROFIT_MARKET, wsex.TAKE_PROFIT_LIMIT:
+		params.Set("stopPrice", utils.Round(req.StopPrice, market.PricePrecision, false))
 	}
-	switch tradeType {
-	case wsex.LIMIT:
-		params.Set("type", "LIMIT")
-		params.Set("price", utils.Round(price, market.PricePrecision, false))
-		params.Set("timeInForce", "GTC")
-	case wsex.MARKET:
-		params.Set("type", "MARKET")
+	switch req.TriggerType {
+	case wsex.TriggerMark:
+		params.Set("workingType", "MARK_PRICE")
+	case wsex.TriggerLast:
+		params.Set("workingType", "CONTRACT_PRICE")
 	}
-	if useClientID {
-		params.Set("newClientOrderId", utils.GenerateOrderClientId(e.Option.ClientOrderIDPrefix, 32))
+	if req.ReduceOnly && !req.ClosePosition {
+		params.Set("reduceOnly", "true")
+	}
+	if clientID := req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32); clientID != "" {
+		params.Set("newClientOrderId", clientID)
 	}
 	params.Set("newOrderRespType", "ACK")
-	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/order", params, http.Header{})
+	exchanges.MergeParams(params, req.Params)
+	return
+}
+
+// CreateOrders place orders by /fapi/v1/batchOrders, 5 orders at most per request
+func (e *BinanceFutureRest) CreateOrders(requests []wsex.OrderRequest) (results []wsex.OrderResult, err error) {
+	const batchSize = 5
+	results = make([]wsex.OrderResult, len(requests))
+	batch := make([]map[string]interface{}, len(requests))
+	var indexes []int
+	for i, req := range requests {
+		market, err := e.GetMarket(req.Symbol)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		params, err := e.orderParams(market, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		batch[i] = utils.UrlValuesToMap(params)
+		indexes = append(indexes, i)
+	}
+	for start := 0; start < len(indexes); start += batchSize {
+		end := start + batchSize
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		e.createOrderBatch(requests, batch, indexes[start:end], results)
+	}
+	return results, nil
+}
+
+func (e *BinanceFutureRest) createOrderBatch(requests []wsex.OrderRequest, batch []map[string]interface{}, indexes []int, results []wsex.OrderResult) {
+	setErr := func(err error) {
+		for _, i := range indexes {
+			results[i].Err = err
+		}
+	}
+	orders := make([]map[string]interface{}, len(indexes))
+	for j, i := range indexes {
+		orders[j] = batch[i]
+	}
+	ordersJson, err := json.Marshal(orders)
 	if err != nil {
+		setErr(wsex.ExError{Code: wsex.ErrRequestParams, Message: err.Error()})
 		return
 	}
-	fmt.Println(string(res))
-	type response struct {
-		ID  int64  `json:"orderId"`
-		CID string `json:"clientOrderId"`
-	}
-	var data response
-	err = json.Unmarshal(res, &data)
+	params := url.Values{}
+	params.Set("batchOrders", string(ordersJson))
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/batchOrders", params, http.Header{})
 	if err != nil {
-		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		setErr(err)
 		return
 	}
-	order.ID = strconv.FormatInt(data.ID, 10)
-	order.ClientID = data.CID
-	return
+	var items []json.RawMessage
+	if err = json.Unmarshal(res, &items); err != nil || len(items) != len(indexes) {
+		setErr(wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("unexpected batch response: %s", res)})
+		return
+	}
+	futureJson := jsoniter.Config{TagKey: "future"}.Froze()
+	for j, item := range items {
+		i := indexes[j]
+		if err = e.HandleError(exchanges.Request{}, item); err != nil {
+			results[i].Err = err
+			continue
+		}
+		var data Order
+		if err = futureJson.Unmarshal(item, &data); err != nil {
+			results[i].Err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+			continue
+		}
+		results[i].Order = data.parseOrder(requests[i].Symbol)
+	}
 }
 
 func (e *BinanceFutureRest) CancelOrder(symbol, orderID string) (err error) {
@@ -323,6 +464,11 @@ func (e *BinanceFutureRest) CancelOrder(symbol, orderID string) (err error) {
 	return err
 }
 
+// AmendOrder no amend endpoint, the order is cancelled and created again
+func (e *BinanceFutureRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (wsex.AmendResult, error) {
+	return exchanges.AmendOrderByCancelCreate(e, symbol, orderID, newPrice, newAmount)
+}
+
 func (e *BinanceFutureRest) CancelAllOrders(symbol string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -335,6 +481,79 @@ func (e *BinanceFutureRest) CancelAllOrders(symbol string) (err error) {
 	return err
 }
 
+// CancelOrders cancel orders by /fapi/v1/batchOrders, 10 orders at most per request
+func (e *BinanceFutureRest) CancelOrders(symbol string, orderIDs []string) (results []wsex.CancelResult, err error) {
+	const batchSize = 10
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	results = make([]wsex.CancelResult, len(orderIDs))
+	// order ids and client order ids can not be mixed in one request
+	var ids, clientIDs []int
+	for i, orderID := range orderIDs {
+		results[i].OrderID = orderID
+		if utils.IsClientOrderID(orderID, e.Option.ClientOrderIDPrefix) {
+			clientIDs = append(clientIDs, i)
+		} else {
+			ids = append(ids, i)
+		}
+	}
+	for _, group := range [][]int{ids, clientIDs} {
+		for start := 0; start < len(group); start += batchSize {
+			end := start + batchSize
+			if end > len(group) {
+				end = len(group)
+			}
+			e.cancelOrderBatch(market, group[start:end], results)
+		}
+	}
+	return results, nil
+}
+
+func (e *BinanceFutureRest) cancelOrderBatch(market wsex.Market, indexes []int, results []wsex.CancelResult) {
+	setErr := func(err error) {
+		for _, i := range indexes {
+			results[i].Err = err
+		}
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	if utils.IsClientOrderID(results[indexes[0]].OrderID, e.Option.ClientOrderIDPrefix) {
+		clientIDs := make([]string, len(indexes))
+		for j, i := range indexes {
+			clientIDs[j] = results[i].OrderID
+		}
+		list, _ := json.Marshal(clientIDs)
+		params.Set("origClientOrderIdList", string(list))
+	} else {
+		ids := make([]int64, len(indexes))
+		for j, i := range indexes {
+			id, err := strconv.ParseInt(results[i].OrderID, 10, 64)
+			if err != nil {
+				setErr(wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("invalid order id %s", results[i].OrderID)})
+				return
+			}
+			ids[j] = id
+		}
+		list, _ := json.Marshal(ids)
+		params.Set("orderIdList", string(list))
+	}
+	res, err := e.Fetch(e, exchanges.Private, exchanges.DELETE, "/fapi/v1/batchOrders", params, http.Header{})
+	if err != nil {
+		setErr(err)
+		return
+	}
+	var items []json.RawMessage
+	if err = json.Unmarshal(res, &items); err != nil || len(items) != len(indexes) {
+		setErr(wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("unexpected batch response: %s", res)})
+		return
+	}
+	for j, item := range items {
+		results[indexes[j]].Err = e.HandleError(exchanges.Request{}, item)
+	}
+}
+
 func (e *BinanceFutureRest) FetchOrder(symbol, orderID string) (order wsex.Order, err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -386,6 +605,32 @@ func (e *BinanceFutureRest) FetchOpenOrders(symbol string, pageIndex, pageSize i
 	return
 }
 
+// fetchOrdersSince the orders of the symbol created since the time in ms, at most 1000
+func (e *BinanceFutureRest) fetchOrdersSince(symbol string, since time.Duration) (orders []wsex.Order, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("startTime", strconv.FormatInt(int64(since), 10))
+	params.Set("limit", "1000")
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/allOrders", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data []Order
+	restJson := jsoniter.Config{TagKey: "future"}.Froze()
+	if err = restJson.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	for _, o := range data {
+		orders = append(orders, o.parseOrder(symbol))
+	}
+	return
+}
+
 func (e *BinanceFutureRest) FetchBalance() (balances map[string]wsex.Balance, err error) {
 	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v2/balance", url.Values{}, http.Header{})
 	if err != nil {
@@ -419,7 +664,7 @@ func (e *BinanceFutureRest) FetchAccountInfo() (accountInfo wsex.FutureAccountIn
 	}
 
 	accountInfo = data.parseAccountInfo()
-	accountInfo.Positions = make(map[string]map[wsex.PositionType]wsex.FuturePositons)
+	accountInfo.Positions = make(map[string]map[wsex.PositionType]wsex.Position)
 	for _, position := range data.Positions {
 		if math.Abs(utils.SafeParseFloat(position.Amount)) < utils.ZERO {
 			continue
@@ -431,7 +676,7 @@ func (e *BinanceFutureRest) FetchAccountInfo() (accountInfo wsex.FutureAccountIn
 		po := position.ParserFuturePosition(market.BaseID, market.Symbol)
 		pos, ok := accountInfo.Positions[po.Coin]
 		if !ok {
-			pos = make(map[wsex.PositionType]wsex.FuturePositons)
+			pos = make(map[wsex.PositionType]wsex.Position)
 		}
 		pos[po.PositionType] = po
 		accountInfo.Positions[po.Coin] = pos
@@ -439,62 +684,162 @@ func (e *BinanceFutureRest) FetchAccountInfo() (accountInfo wsex.FutureAccountIn
 	return
 }
 
-func (e *BinanceFutureRest) FetchPositions(symbol string) (positions []wsex.FuturePositons, err error) {
-	params := url.Values{}
-	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v2/account", params, http.Header{})
+// FetchPositions the positions of symbol including the empty ones, all symbols if symbol is empty
+func (e *BinanceFutureRest) FetchPositions(symbol string) (positions []wsex.Position, err error) {
+	return e.fetchPositions(symbol, false)
+}
+
+// fetchPositions the positions of /fapi/v2/positionRisk with the ADL quantiles, the empty ones are skipped if nonEmpty
+func (e *BinanceFutureRest) fetchPositions(symbol string, nonEmpty bool) (positions []wsex.Position, err error) {
+	risks, err := e.positionRisks(symbol)
 	if err != nil {
 		return
 	}
-	type datas struct {
-		Positions []FuturePosition `json:"positions"`
-	}
-	var data datas
-	if err = json.Unmarshal(res, &data); err != nil {
-		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+	quantiles, err := e.adlQuantiles(symbol)
+	if err != nil {
 		return
 	}
-	positions = make([]wsex.FuturePositons, 0)
-	for _, position := range data.Positions {
-		market, err := e.GetMarketByID(position.Symbol)
-		if err != nil {
+	positions = make([]wsex.Position, 0)
+	for _, risk := range risks {
+		if nonEmpty && utils.SafeParseFloat(risk.PositionAmt) == 0 {
 			continue
 		}
-		if symbol == "" || symbol == market.Symbol {
-			positions = append(positions, position.ParserFuturePosition(market.BaseID, market.Symbol))
+		market, err := e.GetMarketByID(risk.Symbol)
+		if err != nil {
+			continue
 		}
+		position := risk.parsePosition(market.BaseID, market.Symbol)
+		position.AdlQuantile = quantiles[risk.Symbol][risk.PositionSide]
+		positions = append(positions, position)
+	}
+	return
+}
 
+// adlQuantiles key: symbol id, position side
+func (e *BinanceFutureRest) adlQuantiles(symbol string) (quantiles map[string]map[string]int, err error) {
+	params := url.Values{}
+	if symbol != "" {
+		market, err1 := e.GetMarket(symbol)
+		if err1 != nil {
+			return nil, err1
+		}
+		params.Set("symbol", market.SymbolID)
+	}
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/adlQuantile", params, http.Header{})
+	if err != nil {
+		return
+	}
+	// a single object is returned if symbol is set
+	var data []AdlQuantile
+	if symbol != "" {
+		data = make([]AdlQuantile, 1)
+		err = json.Unmarshal(res, &data[0])
+	} else {
+		err = json.Unmarshal(res, &data)
+	}
+	if err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	quantiles = make(map[string]map[string]int, len(data))
+	for _, item := range data {
+		quantiles[item.Symbol] = item.AdlQuantile
 	}
 	return
 }
 
-func (e *BinanceFutureRest) FetchAllPositions() (positions []wsex.FuturePositons, err error) {
+// ClosePosition 市价平掉整个仓位, 触发后平仓的条件单使用OrderRequest.ClosePosition
+func (e *BinanceFutureRest) ClosePosition(symbol string, positionType wsex.PositionType) (order wsex.Order, err error) {
+	positions, err := e.FetchPositions(symbol)
+	if err != nil {
+		return
+	}
+	req, err := exchanges.ClosePositionRequest(positions, symbol, positionType)
+	if err != nil {
+		return
+	}
+	return e.PlaceOrder(req)
+}
+
+// AdjustPositionMargin the positionSide is BOTH in one-way mode, otherwise LONG/SHORT
+func (e *BinanceFutureRest) AdjustPositionMargin(symbol string, positionType wsex.PositionType, amount float64) (position wsex.Position, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	if amount == 0 {
+		err = wsex.ExError{Code: wsex.ErrRequestParams, Message: "margin amount is required"}
+		return
+	}
+	positionMode, err := e.GetPositionMode(symbol)
+	if err != nil {
+		return
+	}
 	params := url.Values{}
-	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v2/account", params, http.Header{})
+	params.Set("symbol", market.SymbolID)
+	switch {
+	case positionMode == wsex.OneWay:
+		params.Set("positionSide", "BOTH")
+	case positionType == wsex.PositionLong:
+		params.Set("positionSide", "LONG")
+	case positionType == wsex.PositionShort:
+		params.Set("positionSide", "SHORT")
+	default:
+		err = wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("invalid position type %s", positionType)}
+		return
+	}
+	// type 1:add 2:reduce
+	params.Set("type", "1")
+	if amount < 0 {
+		params.Set("type", "2")
+	}
+	params.Set("amount", strconv.FormatFloat(math.Abs(amount), 'f', -1, 64))
+	if _, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/positionMargin", params, http.Header{}); err != nil {
+		return
+	}
+	risks, err := e.positionRisks(symbol)
 	if err != nil {
 		return
 	}
-	type datas struct {
-		Positions []FuturePosition `json:"positions"`
+	positions := make([]wsex.Position, 0, len(risks))
+	for _, risk := range risks {
+		positions = append(positions, risk.parsePosition(market.BaseID, market.Symbol))
 	}
-	var data datas
+	position, ok := exchanges.FindPosition(positions, symbol, positionType)
+	if !ok {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("no %s position of %s", positionType, symbol)}
+	}
+	return
+}
+
+func (e *BinanceFutureRest) FetchMarginHistory(symbol string) (changes []wsex.MarginChange, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/positionMargin/history", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data []MarginHistory
 	if err = json.Unmarshal(res, &data); err != nil {
 		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
 		return
 	}
-	positions = make([]wsex.FuturePositons, 0)
-	for _, position := range data.Positions {
-		if position.AvgPrice == "0.0" {
-			continue
-		}
-		market, err := e.GetMarketByID(position.Symbol)
-		if err != nil {
-			continue
-		}
-		positions = append(positions, position.ParserFuturePosition(market.BaseID, market.Symbol))
+	changes = make([]wsex.MarginChange, 0, len(data))
+	for _, item := range data {
+		changes = append(changes, item.parseMarginChange(market.Symbol))
 	}
 	return
 }
 
+// FetchAllPositions the non-empty positions of all symbols
+func (e *BinanceFutureRest) FetchAllPositions() (positions []wsex.Position, err error) {
+	return e.fetchPositions("", true)
+}
+
 func (e *BinanceFutureRest) FetchMarkPrice(symbol string) (markPrice wsex.MarkPrice, err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -515,6 +860,98 @@ func (e *BinanceFutureRest) FetchMarkPrice(symbol string) (markPrice wsex.MarkPr
 	return
 }
 
+func (e *BinanceFutureRest) FetchIndexPrice(symbol string) (indexPrice wsex.IndexPrice, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/fapi/v1/premiumIndex", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data MarkFundingRate
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	return data.parseIndexPrice(market.Symbol), nil
+}
+
+func (e *BinanceFutureRest) FetchOpenInterest(symbol string) (openInterest wsex.OpenInterest, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/fapi/v1/openInterest", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data OpenInterest
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	return data.parseOpenInterest(market.Symbol), nil
+}
+
+// FetchOpenInterestHistory period 5m,15m,30m,1h,2h,4h,6h,12h,1d, only the latest 30 days are kept
+func (e *BinanceFutureRest) FetchOpenInterestHistory(symbol string, period wsex.KLineType, limit int) (openInterests []wsex.OpenInterest, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/futures/data/openInterestHist", statisticsParams(market, period, limit), http.Header{})
+	if err != nil {
+		return
+	}
+	var data []OpenInterestHistory
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	openInterests = make([]wsex.OpenInterest, 0, len(data))
+	for _, item := range data {
+		openInterests = append(openInterests, item.parseOpenInterest(market.Symbol))
+	}
+	return
+}
+
+// FetchLongShortRatio the long/short ratio of the top traders' positions, period is same as FetchOpenInterestHistory
+func (e *BinanceFutureRest) FetchLongShortRatio(symbol string, period wsex.KLineType, limit int) (ratios []wsex.LongShortRatio, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/futures/data/topLongShortPositionRatio", statisticsParams(market, period, limit), http.Header{})
+	if err != nil {
+		return
+	}
+	var data []LongShortRatio
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	ratios = make([]wsex.LongShortRatio, 0, len(data))
+	for _, item := range data {
+		ratios = append(ratios, item.parseLongShortRatio(market.Symbol))
+	}
+	return
+}
+
+func statisticsParams(market wsex.Market, period wsex.KLineType, limit int) url.Values {
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("period", parseKLienType(period))
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	return params
+}
+
 func (e *BinanceFutureRest) FetchFundingRate(symbol string) (fundingrate wsex.FundingRate, err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -530,67 +967,304 @@ func (e *BinanceFutureRest) FetchFundingRate(symbol string) (fundingrate wsex.Fu
 	err = json.Unmarshal(b, &Mp)
 	if err != nil {
 		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
 	}
-	fundingrate.Rate = Mp.LastFundingRate
-	fundingrate.NextTimestamp = time.Duration(Mp.NextFundingTime)
+	interval, err := e.fundingInterval(market.SymbolID)
+	if err != nil {
+		return
+	}
+	fundingrate = Mp.parseFundingRate(market.Symbol, interval)
 	return
 }
 
-func (e *BinanceFutureRest) Setting(symbol string, leverage int, marginMode wsex.FutureMarginMode, positionMode wsex.FuturePositionsMode) (err error) {
+// fundingInterval in ms, the symbols funding every 4 or 1 hours are listed by /fapi/v1/fundingInfo, the others every 8 hours
+func (e *BinanceFutureRest) fundingInterval(symbolID string) (time.Duration, error) {
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/fapi/v1/fundingInfo", url.Values{}, http.Header{})
+	if err != nil {
+		return 0, err
+	}
+	var infos []FundingInfo
+	if err = json.Unmarshal(res, &infos); err != nil {
+		return 0, wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+	}
+	for _, info := range infos {
+		if info.Symbol == symbolID && info.FundingIntervalHours > 0 {
+			return time.Duration(info.FundingIntervalHours) * time.Hour / time.Millisecond, nil
+		}
+	}
+	return defaultFundingInterval / time.Millisecond, nil
+}
+
+func (e *BinanceFutureRest) FetchFundingRateHistory(symbol string, since time.Duration, limit int) (rates []wsex.FundingRate, err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
 		return
 	}
-	var Dual bool
-	switch positionMode {
-	case wsex.OneWay:
-		Dual = false
-	case wsex.TwoWay:
-		Dual = true
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	if since > 0 {
+		params.Set("startTime", strconv.FormatInt(int64(since), 10))
 	}
-	var dualSide DualSidePosition
-	b, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/positionSide/dual", url.Values{}, http.Header{})
-	if err != nil {
-		return err
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
 	}
-	err = json.Unmarshal(b, &dualSide)
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/fapi/v1/fundingRate", params, http.Header{})
 	if err != nil {
+		return
+	}
+	var data []FundingRateHistory
+	if err = json.Unmarshal(res, &data); err != nil {
 		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
 		return
 	}
-	if dualSide.DaulSide != Dual {
-		params := url.Values{}
-		params.Set("dualSidePosition", strconv.FormatBool(Dual))
-		_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/positionSide/dual", params, http.Header{})
-		if err != nil {
-			return err
+	// the interval is the gap to the previous record, the first one uses the gap to the next one
+	var interval time.Duration
+	if len(data) == 1 {
+		if interval, err = e.fundingInterval(market.SymbolID); err != nil {
+			return
+		}
+	}
+	rates = make([]wsex.FundingRate, 0, len(data))
+	for i, item := range data {
+		switch {
+		case i > 0:
+			interval = time.Duration(item.FundingTime - data[i-1].FundingTime)
+		case len(data) > 1:
+			interval = time.Duration(data[1].FundingTime - item.FundingTime)
 		}
+		rates = append(rates, item.parseFundingRate(market.Symbol, interval))
+	}
+	return
+}
+
+// FetchFundingPayments the FUNDING_FEE income, 1000 records at most
+func (e *BinanceFutureRest) FetchFundingPayments(symbol string, since time.Duration) (payments []wsex.FundingPayment, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("incomeType", "FUNDING_FEE")
+	if since > 0 {
+		params.Set("startTime", strconv.FormatInt(int64(since), 10))
 	}
-	symbolps, err := e.FetchPositions(symbol)
+	params.Set("limit", "1000")
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/income", params, http.Header{})
 	if err != nil {
 		return
 	}
-	if len(symbolps) > 0 && symbolps[0].MarginMode != marginMode {
-		params := url.Values{}
-		params.Set("symbol", market.SymbolID)
-		if marginMode == wsex.FixedMargin {
-			params.Set("marginType", "ISOLATED")
-		} else {
-			params.Set("marginType", "CROSSED")
+	var data []Income
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	payments = make([]wsex.FundingPayment, 0, len(data))
+	for _, item := range data {
+		payments = append(payments, item.parseFundingPayment(market.Symbol))
+	}
+	return
+}
+
+//FetchConditionalOrders : untriggered stop orders are open orders of binance
+func (e *BinanceFutureRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	openOrders, err := e.FetchOpenOrders(symbol, 0, 0)
+	if err != nil {
+		return
+	}
+	for _, order := range openOrders {
+		if order.Type.IsConditional() {
+			orders = append(orders, order)
 		}
-		_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/marginType", params, http.Header{})
-		if err != nil {
-			return err
+	}
+	return
+}
+
+func (e *BinanceFutureRest) CancelConditionalOrder(symbol, orderID string) error {
+	return e.CancelOrder(symbol, orderID)
+}
+
+func (e *BinanceFutureRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	b, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/commissionRate", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data TradeFee
+	restJson := jsoniter.Config{TagKey: "future"}.Froze()
+	if err = restJson.Unmarshal(b, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	fee = data.parseTradingFee(market.Symbol)
+	return
+}
+
+// Setting 依次设置持仓模式、保证金模式和杠杆
+func (e *BinanceFutureRest) Setting(symbol string, leverage int, marginMode wsex.FutureMarginMode, positionMode wsex.FuturePositionsMode) error {
+	if _, err := e.GetMarket(symbol); err != nil {
+		return err
+	}
+	return exchanges.ApplySetting(e, symbol, leverage, marginMode, positionMode)
+}
+
+// positionRisk leverage and margin type of symbol are returned even if there's no position
+func (e *BinanceFutureRest) positionRisk(symbol string) (risk PositionRisk, err error) {
+	risks, err := e.positionRisks(symbol)
+	if err != nil {
+		return
+	}
+	if len(risks) == 0 {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("no position risk of %s", symbol)}
+		return
+	}
+	return risks[0], nil
+}
+
+// positionRisks both LONG and SHORT are returned in hedge mode, otherwise BOTH. all symbols if symbol is empty
+func (e *BinanceFutureRest) positionRisks(symbol string) (risks []PositionRisk, err error) {
+	params := url.Values{}
+	if symbol != "" {
+		market, err1 := e.GetMarket(symbol)
+		if err1 != nil {
+			return nil, err1
 		}
+		params.Set("symbol", market.SymbolID)
+	}
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v2/positionRisk", params, http.Header{})
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(res, &risks); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+	}
+	return
+}
+
+func (e *BinanceFutureRest) GetLeverage(symbol string) (leverage int, err error) {
+	risk, err := e.positionRisk(symbol)
+	if err != nil {
+		return
+	}
+	return strconv.Atoi(risk.Leverage)
+}
+
+func (e *BinanceFutureRest) SetLeverage(symbol string, leverage int) (err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
 	}
 	params := url.Values{}
 	params.Set("symbol", market.SymbolID)
 	params.Set("leverage", strconv.Itoa(leverage))
 	_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/leverage", params, http.Header{})
+	return
+}
+
+func (e *BinanceFutureRest) GetMarginMode(symbol string) (marginMode wsex.FutureMarginMode, err error) {
+	risk, err := e.positionRisk(symbol)
 	if err != nil {
-		return err
+		return
+	}
+	if strings.ToLower(risk.MarginType) == "isolated" {
+		return wsex.FixedMargin, nil
+	}
+	return wsex.CrossedMargin, nil
+}
+
+// SetMarginMode nothing is sent if the margin mode is not changed, which is rejected by binance
+func (e *BinanceFutureRest) SetMarginMode(symbol string, marginMode wsex.FutureMarginMode) (err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	current, err := e.GetMarginMode(symbol)
+	if err != nil || current == marginMode {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	if marginMode == wsex.FixedMargin {
+		params.Set("marginType", "ISOLATED")
+	} else {
+		params.Set("marginType", "CROSSED")
+	}
+	_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/marginType", params, http.Header{})
+	return
+}
+
+// GetPositionMode the position mode of binance is account wide, symbol is ignored
+func (e *BinanceFutureRest) GetPositionMode(symbol string) (positionMode wsex.FuturePositionsMode, err error) {
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/positionSide/dual", url.Values{}, http.Header{})
+	if err != nil {
+		return
+	}
+	var dualSide DualSidePosition
+	if err = json.Unmarshal(res, &dualSide); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	if dualSide.DaulSide {
+		return wsex.TwoWay, nil
+	}
+	return wsex.OneWay, nil
+}
+
+// SetPositionMode the position mode of binance is account wide, symbol is ignored.
+// nothing is sent if the position mode is not changed, which is rejected by binance
+func (e *BinanceFutureRest) SetPositionMode(symbol string, positionMode wsex.FuturePositionsMode) (err error) {
+	current, err := e.GetPositionMode(symbol)
+	if err != nil || current == positionMode {
+		return
+	}
+	params := url.Values{}
+	params.Set("dualSidePosition", strconv.FormatBool(positionMode == wsex.TwoWay))
+	_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/fapi/v1/positionSide/dual", params, http.Header{})
+	return
+}
+
+func (e *BinanceFutureRest) FetchLeverageBrackets(symbol string) (brackets []wsex.LeverageBracket, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/fapi/v1/leverageBracket", params, http.Header{})
+	if err != nil {
+		return
+	}
+	// the brackets of the symbol is a single object, the list is only returned without symbol
+	var data SymbolBrackets
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	if data.Symbol != market.SymbolID {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("unexpected leverage brackets of %s", data.Symbol)}
+		return
+	}
+	for _, bracket := range data.Brackets {
+		brackets = append(brackets, bracket.parseBracket())
+	}
+	return
+}
+
+func (e *BinanceFutureRest) FetchMaxLeverage(symbol string) (leverage int, err error) {
+	brackets, err := e.FetchLeverageBrackets(symbol)
+	if err != nil {
+		return
+	}
+	if len(brackets) == 0 {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("no leverage bracket of %s", symbol)}
+		return
 	}
-	return nil
+	return brackets[0].MaxLeverage, nil
 }
 
 func (e *BinanceFutureRest) Sign(access, method, function string, param url.Values, header http.Header) (request exchanges.Request) {
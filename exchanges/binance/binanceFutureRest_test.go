@@ -154,3 +154,114 @@ func TestBinanceFutureRest_Setting(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestBinanceFutureRest_GetSetting(t *testing.T) {
+	leverage, err := baFuture.GetLeverage(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	marginMode, err := baFuture.GetMarginMode(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	positionMode, err := baFuture.GetPositionMode(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(leverage, marginMode, positionMode)
+}
+
+func TestBinanceFutureRest_FetchLeverageBrackets(t *testing.T) {
+	brackets, err := baFuture.FetchLeverageBrackets(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(brackets)
+}
+
+func TestBinanceFutureRest_FetchTradingFees(t *testing.T) {
+	fee, err := baFuture.FetchTradingFees(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(fee)
+}
+
+func TestBinanceFutureRest_CreateOrders(t *testing.T) {
+	results, err := baFuture.CreateOrders([]wsex.OrderRequest{
+		{Symbol: symbol, Price: 30000, Amount: 0.001, Side: wsex.OpenLong, Type: wsex.LIMIT, OrderType: wsex.Normal},
+		{Symbol: symbol, Price: 29000, Amount: 0.001, Side: wsex.OpenLong, Type: wsex.LIMIT, OrderType: wsex.Normal},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	ids := make([]string, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			t.Error(result.Err)
+			continue
+		}
+		ids = append(ids, result.Order.ID)
+	}
+	t.Log(results)
+	cancelResults, err := baFuture.CancelOrders(symbol, ids)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(cancelResults)
+}
+
+func TestBinanceFutureRest_ConditionalOrders(t *testing.T) {
+	order, err := baFuture.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Amount: 0.001, Side: wsex.CloseLong, Type: wsex.TRAILING_STOP, CallbackRate: 0.01, TriggerType: wsex.TriggerMark})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders, err := baFuture.FetchConditionalOrders(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(orders)
+	if err = baFuture.CancelConditionalOrder(symbol, order.ID); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestBinanceFutureRest_AdjustPositionMargin(t *testing.T) {
+	position, err := baFuture.AdjustPositionMargin(symbol, wsex.PositionLong, 1)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(position)
+}
+
+func TestBinanceFutureRest_FetchFundingRateHistory(t *testing.T) {
+	rates, err := baFuture.FetchFundingRateHistory(symbol, 0, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(rates)
+}
+
+func TestBinanceFutureRest_FetchFundingPayments(t *testing.T) {
+	payments, err := baFuture.FetchFundingPayments(symbol, 0)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(payments)
+}
+
+func TestBinanceFutureRest_FetchOpenInterestHistory(t *testing.T) {
+	openInterests, err := baFuture.FetchOpenInterestHistory(symbol, wsex.KLine1Hour, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(openInterests)
+}
+
+func TestBinanceFutureRest_FetchLongShortRatio(t *testing.T) {
+	ratios, err := baFuture.FetchLongShortRatio(symbol, wsex.KLine1Hour, 10)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(ratios)
+}
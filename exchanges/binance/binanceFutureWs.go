@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
 	"time"
@@ -14,6 +13,7 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/book"
 	"github.com/shiguantian/wsex/exchanges"
 	"github.com/shiguantian/wsex/exchanges/websocket"
 	"github.com/shiguantian/wsex/utils"
@@ -29,7 +29,7 @@ func SubscribeFstream(event ...string) Stream {
 	return Stream{
 		Method: "SUBSCRIBE",
 		Params: append([]string{}, event...),
-		Id:     time.Now().Nanosecond(),
+		Id:     nextStreamID(),
 	}
 }
 
@@ -37,7 +37,7 @@ func UnSubscribeFstream(event ...string) Stream {
 	return Stream{
 		Method: "UNSUBSCRIBE",
 		Params: append([]string{}, event...),
-		Id:     time.Now().Nanosecond(),
+		Id:     nextStreamID(),
 	}
 }
 
@@ -57,18 +57,17 @@ type BinanceFutureWs struct {
 	futuresKind  wsex.FuturesKind
 
 	isIncrementalDepth bool
-	isSubUserData      bool
-	orderBooks         map[string]*SymbolOrderBook // orderbook's local cache of one symbol
-	partialOrderBook   OrderBook                   // Partial Book Depth
+	orderBooks         map[string]*book.Engine // orderbook's local cache of the connection, key: ws url
+	partialOrderBook   OrderBook               // Partial Book Depth
+	partialTopic       string                  // topic of the partial book depth
 	errors             map[int]wsex.ExError
-	listenKey          string // listenKey for User Data Streams, including account update,balance update,order update
-	listenKeyStop      chan struct{}
+	userData           *userDataSession // User Data Streams, including account update,balance update,order update
 }
 
 func (e *BinanceFutureWs) Init(option wsex.Options) {
 	e.BaseExchange.Init()
 	e.Option = option
-	e.orderBooks = make(map[string]*SymbolOrderBook)
+	e.orderBooks = make(map[string]*book.Engine)
 	e.errors = map[int]wsex.ExError{
 		30040: wsex.ExError{Code: wsex.ErrChannelNotExist},
 		30008: wsex.ExError{Code: wsex.ErrAuthFailed},
@@ -79,17 +78,34 @@ func (e *BinanceFutureWs) Init(option wsex.Options) {
 	if e.Option.WsHost == "" {
 		e.Option.WsHost = "wss://fstream.binance.com/ws"
 	}
+	if e.Option.MaxTopicsPerConn == 0 {
+		e.Option.MaxTopicsPerConn = 200 // a single connection can listen to a maximum of 200 streams
+	}
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = string(wsex.Binance)
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 	if e.Option.RestHost == "" {
 		e.Option.RestHost = "https://fapi.binance.com"
 	}
-	e.listenKeyStop = make(chan struct{})
-	e.isSubUserData = false
+	e.userData = &userDataSession{
+		mgr:       e.ConnectionMgr,
+		url:       e.userDataUrl,
+		connect:   e.Connect,
+		create:    e.createListenKey,
+		keepAlive: e.keepAliveListenKey,
+		remove:    e.deleteListenKey,
+		onError:   e.errorHandler,
+	}
 }
 
 func (e *BinanceFutureWs) SubscribeOrderBook(symbol string, level, speed int, isIncremental bool, sub wsex.MessageChan) (string, error) {
 	e.RwLock.Lock()
 	if !isIncremental {
 		if e.partialOrderBook.Symbol != "" {
+			e.RwLock.Unlock()
 			return "", errors.New("binance instance can only obtain one symbol partial order book at the same time")
 		}
 		e.partialOrderBook.Symbol = symbol
@@ -110,7 +126,17 @@ func (e *BinanceFutureWs) SubscribeOrderBook(symbol string, level, speed int, is
 	if topic == "" {
 		return topic, err
 	}
-	return e.subscribe(e.Option.WsHost, topic, sub)
+	if !isIncremental {
+		e.partialTopic = topic
+	}
+	topic, err = e.subscribe(e.Option.WsHost, topic, sub)
+	if err != nil && !isIncremental {
+		e.RwLock.Lock()
+		e.partialTopic = ""
+		e.partialOrderBook = OrderBook{}
+		e.RwLock.Unlock()
+	}
+	return topic, err
 }
 
 func (e *BinanceFutureWs) SubscribeTrades(symbol string, sub wsex.MessageChan) (string, error) {
@@ -152,31 +178,118 @@ func (e *BinanceFutureWs) SubscribeMarkPrice(symbol string, sub wsex.MessageChan
 	return e.subscribe(e.Option.WsHost, topic, sub)
 }
 
+// SubscribeIndexPrice the index price is carried by the mark price stream, the topic is <symbol>@indexPrice
+func (e *BinanceFutureWs) SubscribeIndexPrice(symbol string, sub wsex.MessageChan) (string, error) {
+	topic, err := e.getTopicBySymbol(symbol, "indexPrice")
+	if topic == "" {
+		return topic, err
+	}
+	conn, err := e.ConnectionMgr.GetShard(e.Option.WsHost, topic, sub, e.Connect)
+	if err != nil {
+		return "", err
+	}
+	if err := subscribeWithAck(&e.BaseExchange, conn, topic, SubscribeFstream(indexPriceStream(topic)), sub); err != nil {
+		return "", err
+	}
+	return topic, nil
+}
+
+// indexPriceStream the mark price stream of the index price topic
+func indexPriceStream(topic string) string {
+	return strings.TrimSuffix(topic, "indexPrice") + "markPrice@1s"
+}
+
+// topicAllLiquidations the liquidation orders of all symbols
+const topicAllLiquidations = "!forceOrder@arr"
+
+// SubscribeLiquidations empty symbol means all symbols
+func (e *BinanceFutureWs) SubscribeLiquidations(symbol string, sub wsex.MessageChan) (string, error) {
+	if symbol == "" {
+		return e.subscribe(e.Option.WsHost, topicAllLiquidations, sub)
+	}
+	topic, err := e.getTopicBySymbol(symbol, "forceOrder")
+	if topic == "" {
+		return topic, err
+	}
+	return e.subscribe(e.Option.WsHost, topic, sub)
+}
+
 func (e *BinanceFutureWs) SubscribeBalance(symbol string, sub wsex.MessageChan) (string, error) {
-	return e.subscribeUserData(sub)
+	return e.userData.subscribe(topicBalance, "", sub)
 }
 
 func (e *BinanceFutureWs) SubscribePositions(symbol string, sub wsex.MessageChan) (string, error) {
-	return e.subscribeUserData(sub)
+	return e.userData.subscribe(topicPositions, "", sub)
 }
 
+// SubscribeOrder the orders of all symbols are pushed, the orders of symbol are backfilled after the listenKey is renewed
 func (e *BinanceFutureWs) SubscribeOrder(symbol string, sub wsex.MessageChan) (string, error) {
-	return e.subscribeUserData(sub)
+	return e.userData.subscribe(topicOrder, symbol, sub)
+}
+
+func (e *BinanceFutureWs) SubscribeAccount(sub wsex.MessageChan) (string, error) {
+	return e.userData.subscribe(topicAccount, "", sub)
 }
 
 func (e *BinanceFutureWs) UnSubscribe(event string, sub wsex.MessageChan) error {
-	conn, err := e.ConnectionMgr.GetConnection(e.Option.WsHost, nil)
+	if event == topicBalance || event == topicOrder || event == topicPositions || event == topicAccount {
+		// balance, positions, account and order share the user data stream, it is closed after the last subscriber unsubscribed
+		return e.userData.unSubscribe(event, sub)
+	}
+	conn, err := e.ConnectionMgr.FindShard(e.Option.WsHost, event)
 	if err != nil {
 		return err
 	}
-	if err := e.send(conn, UnSubscribeFstream(event)); err != nil {
+	if !conn.UnSubscribe(event, sub) {
+		return nil
+	}
+	if event == e.partialTopic {
+		e.RwLock.Lock()
+		e.partialTopic = ""
+		e.partialOrderBook = OrderBook{}
+		e.RwLock.Unlock()
+	}
+	stream := event
+	if strings.HasSuffix(event, "@indexPrice") {
+		stream = indexPriceStream(event)
+	}
+	if sharedStream(conn, stream) {
+		return nil
+	}
+	if err := e.send(conn, UnSubscribeFstream(stream)); err != nil {
 		return err
 	}
-
-	conn.UnSubscribe(sub)
+	// the unsubscription is done, the failed rebalancing is only reported
+	if err := e.ConnectionMgr.Rebalance(e.Option.WsHost, e.moveTopic); err != nil {
+		e.errorHandler(e.Option.WsHost, err)
+	}
 	return nil
 }
 
+// sharedStream whether the stream is used by the other topics of the connection,
+// the mark price stream is shared by the mark price and index price topics
+func sharedStream(conn *exchanges.Connection, stream string) bool {
+	for _, topic := range conn.Topics() {
+		if topic == stream || (strings.HasSuffix(topic, "@indexPrice") && indexPriceStream(topic) == stream) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveTopic the MoveFunc of rebalancing the shards
+func (e *BinanceFutureWs) moveTopic(topic string, from, to *exchanges.Connection) error {
+	stream := topic
+	if strings.HasSuffix(topic, "@indexPrice") {
+		stream = indexPriceStream(topic)
+	}
+	unsubscribe := UnSubscribeFstream(stream)
+	if sharedStream(from, stream) {
+		unsubscribe.Params = nil
+	}
+	return moveStream(&e.BaseExchange, from, to, SubscribeFstream(stream), unsubscribe)
+}
+
 func (e *BinanceFutureWs) getTopicBySymbol(symbol, suffix string) (string, error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -212,56 +325,19 @@ func (e *BinanceFutureWs) Connect(url string) (*exchanges.Connection, error) {
 }
 
 func (e *BinanceFutureWs) subscribe(url, topic string, sub wsex.MessageChan) (string, error) {
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	conn, err := e.ConnectionMgr.GetShard(url, topic, sub, e.Connect)
 	if err != nil {
 		return "", err
 	}
-	if err := e.send(conn, SubscribeFstream(topic)); err != nil {
+	if err := subscribeWithAck(&e.BaseExchange, conn, topic, SubscribeFstream(topic), sub); err != nil {
 		return "", err
 	}
-	conn.Subscribe(sub)
 	return topic, nil
 }
 
-func (e *BinanceFutureWs) subscribeUserData(sub wsex.MessageChan) (string, error) {
-	e.RwLock.Lock()
-	e.isSubUserData = true
-	defer e.RwLock.Unlock()
-	if e.listenKey != "" {
-		// Because balance and order share the same stream, so just subscribe once.
-		url := fmt.Sprintf("%s/%s", e.Option.WsHost, e.listenKey)
-		conn, err := e.ConnectionMgr.GetConnection(url, nil)
-		if err == nil {
-			conn.Subscribe(sub)
-		}
-		return e.listenKey, err
-	}
-	var err error
-	e.listenKey, err = e.createListenKey()
-	if err != nil {
-		return e.listenKey, err
-	}
-	e.listenKeyStop = make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(time.Minute * 30)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				e.keepAliveListenKey(e.listenKey)
-			case <-e.listenKeyStop:
-				return
-			}
-		}
-	}()
-
-	url := fmt.Sprintf("%s/%s", e.Option.WsHost, e.listenKey)
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
-	if err != nil {
-		return e.listenKey, err
-	}
-	conn.Subscribe(sub)
-	return e.listenKey, nil
+// userDataUrl the user data stream is a raw stream named listenKey
+func (e *BinanceFutureWs) userDataUrl(listenKey string) string {
+	return fmt.Sprintf("%s/%s", e.Option.WsHost, listenKey)
 }
 
 func (e *BinanceFutureWs) send(conn *exchanges.Connection, data Stream) (err error) {
@@ -299,15 +375,32 @@ func (e *BinanceFutureWs) messageHandler(url string, message []byte) {
 
 	case "ACCOUNT_UPDATE":
 		e.handleBalance(url, message)
+	case "MARGIN_CALL":
+		e.handleMarginCall(url, message)
+	case "listenKeyExpired":
+		go e.userData.expired(url)
 	case "markPriceUpdate":
 		e.handleMarkPrice(url, message)
+	case "forceOrder":
+		e.handleLiquidation(url, message)
+	default:
+		handleSubscribeAck(e.SubscribeAck, message)
 	}
 
 }
 
 func (e *BinanceFutureWs) reConnectedHandler(url string) {
 	e.BaseExchange.ReConnectedHandler(url, nil)
-	e.isSubUserData = false
+	// the routes of the reconnected shard are cleared, compact the shards after the subscribers resubscribed
+	if url != e.Option.WsHost && !strings.HasPrefix(url, e.Option.WsHost+"#") {
+		return
+	}
+	go func() {
+		time.Sleep(rebalanceDelay)
+		if err := e.ConnectionMgr.Rebalance(e.Option.WsHost, e.moveTopic); err != nil {
+			e.errorHandler(url, err)
+		}
+	}()
 }
 
 func (e *BinanceFutureWs) disConnectedHandler(url string, err error) {
@@ -322,12 +415,8 @@ func (e *BinanceFutureWs) closeHandler(url string) {
 	// clear cache data and the connection
 	e.BaseExchange.CloseHandler(url, func() {
 		delete(e.orderBooks, url)
-		if strings.Contains(url, e.listenKey) {
-			e.listenKey = ""
-			close(e.listenKeyStop)
-		}
 	})
-	e.isSubUserData = false
+	e.userData.closed(url)
 }
 
 func (e *BinanceFutureWs) errorHandler(url string, err error) {
@@ -355,7 +444,7 @@ func (e *BinanceFutureWs) handleDepth(url string, message []byte) {
 	e.partialOrderBook.Bids = wsex.Depth{}
 	e.partialOrderBook.Asks = wsex.Depth{}
 	e.partialOrderBook.update(data)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: e.partialOrderBook.OrderBook})
+	e.ConnectionMgr.Publish(url, e.partialTopic, wsex.Message{Type: wsex.MsgOrderBook, Data: e.partialOrderBook.OrderBook})
 }
 
 func (e *BinanceFutureWs) handleIncrementalDepth(url string, message []byte) {
@@ -370,31 +459,36 @@ func (e *BinanceFutureWs) handleIncrementalDepth(url string, message []byte) {
 		return
 	}
 
-	symbolOrderBook, ok := e.orderBooks[url]
-	if !ok || symbolOrderBook == nil {
-		_ = e.getSnapshotOrderBook(url, market, &SymbolOrderBook{})
-		return
+	delta := book.Delta{Bids: rawOB.Bids, Asks: rawOB.Asks, First: rawOB.FirstUpdateID, Last: rawOB.LastUpdateID, Prev: rawOB.PreUpdateID,
+		Time: rawOB.EventTime, RecvTime: e.ConnectionMgr.RecvTime(url)}
+	for _, msg := range e.orderBook(url).Update(market.Symbol, delta) {
+		publishStream(e.ConnectionMgr, url, rawOB.Symbol, "depth", msg)
 	}
-	fullOrderBook, ok := (*symbolOrderBook)[market.Symbol]
+}
+
+// orderBook the local order books of the connection
+func (e *BinanceFutureWs) orderBook(url string) *book.Engine {
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	engine, ok := e.orderBooks[url]
 	if !ok {
-		_ = e.getSnapshotOrderBook(url, market, symbolOrderBook)
-		return
-	}
-	if fullOrderBook != nil {
-		first := rawOB.FirstUpdateID <= fullOrderBook.LastUpdateID+1 && rawOB.LastUpdateID >= fullOrderBook.LastUpdateID
-		if first || rawOB.PreUpdateID == fullOrderBook.LastUpdateID {
-			fullOrderBook.update(rawOB)
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
-		} else if rawOB.LastUpdateID < fullOrderBook.LastUpdateID {
-			log.Printf("[BinanceWs] handleIncrementalDepth - recv old update data\n")
-		} else {
-			delete(*symbolOrderBook, market.Symbol)
-			err := wsex.ExError{Code: wsex.ErrInvalidDepth,
-				Message: fmt.Sprintf("[BinanceWs] handleIncrementalDepth - recv dirty data, new.FirstUpdateID: %v != old.LastUpdateID: %v ", rawOB.FirstUpdateID, fullOrderBook.LastUpdateID+1),
-				Data:    map[string]interface{}{"symbol": fullOrderBook.Symbol}}
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: err})
-		}
+		engine = book.NewEngine(e.getSnapshotOrderBook)
+		engine.SetAsync(func(symbol string, msgs []wsex.Message) {
+			market, err := e.GetMarket(symbol)
+			if err != nil {
+				e.errorHandler(url, err)
+				return
+			}
+			for _, msg := range msgs {
+				publishStream(e.ConnectionMgr, url, market.SymbolID, "depth", msg)
+			}
+		})
+		engine.SetDepth(e.Option.OrderBookDepth)
+		engine.SetMetrics(e.Option.ExchangeName, e.Option.Metrics)
+		engine.SetCompareInterval(e.Option.OrderBookCheckTime)
+		e.orderBooks[url] = engine
 	}
+	return engine
 }
 
 func (e *BinanceFutureWs) handleTicker(url string, message []byte) {
@@ -410,7 +504,7 @@ func (e *BinanceFutureWs) handleTicker(url string, message []byte) {
 	}
 	ticker := data.parseTicker(market.Symbol)
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
+	e.ConnectionMgr.Publish(url, strings.ToLower(data.Symbol)+"@ticker", wsex.Message{Type: wsex.MsgTicker, Data: ticker})
 }
 
 func (e *BinanceFutureWs) handleTrade(url string, message []byte) {
@@ -425,7 +519,7 @@ func (e *BinanceFutureWs) handleTrade(url string, message []byte) {
 		return
 	}
 	trade := data.parseTrade(market.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: trade})
+	e.ConnectionMgr.Publish(url, strings.ToLower(data.Symbol)+"@aggTrade", wsex.Message{Type: wsex.MsgTrade, Data: trade})
 }
 
 func (e *BinanceFutureWs) handleKLine(url string, message []byte) {
@@ -448,7 +542,7 @@ func (e *BinanceFutureWs) handleKLine(url string, message []byte) {
 		Low:       utils.SafeParseFloat(data.Line.Low),
 		Volume:    utils.SafeParseFloat(data.Line.Volume),
 	}
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgKLine, Data: kline})
+	e.ConnectionMgr.Publish(url, fmt.Sprintf("%s@kline_%s", strings.ToLower(data.Symbol), data.Line.Interval), wsex.Message{Type: wsex.MsgKLine, Data: kline})
 }
 
 func (e *BinanceFutureWs) handleMarkPrice(url string, message []byte) {
@@ -461,8 +555,25 @@ func (e *BinanceFutureWs) handleMarkPrice(url string, message []byte) {
 	}
 	market, _ := e.GetMarketByID(data.Symbol)
 	markPrice := data.parserMarkPrice(market.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgMarkPrice, Data: markPrice})
+	publishStream(e.ConnectionMgr, url, data.Symbol, "markPrice", wsex.Message{Type: wsex.MsgMarkPrice, Data: markPrice})
+	publishStream(e.ConnectionMgr, url, data.Symbol, "indexPrice", wsex.Message{Type: wsex.MsgIndexPrice, Data: data.parseIndexPrice(market.Symbol)})
+
+}
 
+func (e *BinanceFutureWs) handleLiquidation(url string, message []byte) {
+	data := ForceOrder{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		e.errorHandler(url, fmt.Errorf("[BinanceWs] handleLiquidation - message Unmarshal to forceOrder error:%v", err))
+		return
+	}
+	market, err := e.GetMarketByID(data.Order.Symbol)
+	if err != nil {
+		e.errorHandler(url, err)
+		return
+	}
+	msg := wsex.Message{Type: wsex.MsgLiquidation, Data: data.parseLiquidation(market.Symbol)}
+	e.ConnectionMgr.Publish(url, strings.ToLower(data.Order.Symbol)+"@forceOrder", msg)
+	e.ConnectionMgr.Publish(url, topicAllLiquidations, msg)
 }
 
 func (e *BinanceFutureWs) handleBalance(url string, message []byte) {
@@ -473,22 +584,56 @@ func (e *BinanceFutureWs) handleBalance(url string, message []byte) {
 	}
 	balances := wsex.BalanceUpdate{Balances: make(map[string]wsex.Balance)}
 	balances.UpdateTime = time.Duration(data.Timestamp)
-	Positions := make([]wsex.FuturePositons, 0)
+	Positions := make([]wsex.Position, 0)
 	for _, b := range data.Event.WsB {
 		balances.Balances[b.Currency] = b.parserWsBalance()
 	}
-	market, _ := e.GetMarketByID(data.Event.WsP[0].Symbol)
+	var futurePosition = wsex.FuturePositonsUpdate{
+		Positons: make([]wsex.Position, 0),
+	}
 	for _, p := range data.Event.WsP {
+		market, err := e.GetMarketByID(p.Symbol)
+		if err != nil {
+			continue
+		}
 		ps := p.parserWsPosition(market.Symbol)
+		ps.UpdateTime = balances.UpdateTime
 		Positions = append(Positions, ps)
+		futurePosition.Symbol = market.Symbol
 	}
-	var futurePosition = wsex.FuturePositonsUpdate{
-		Positons: make([]wsex.FuturePositons, 0),
-	}
-	futurePosition.Symbol = market.Symbol
 	futurePosition.Positons = Positions
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgPositions, Data: futurePosition})
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	account := wsex.AccountUpdate{
+		Reason:     data.Event.Reason,
+		Balances:   balances.Balances,
+		Positions:  Positions,
+		UpdateTime: time.Duration(data.EventTime),
+	}
+	if len(Positions) > 0 {
+		e.ConnectionMgr.Publish(url, topicPositions, wsex.Message{Type: wsex.MsgPositions, Data: futurePosition})
+	}
+	e.ConnectionMgr.Publish(url, topicBalance, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	e.ConnectionMgr.Publish(url, topicAccount, wsex.Message{Type: wsex.MsgAccount, Data: account})
+}
+
+func (e *BinanceFutureWs) handleMarginCall(url string, message []byte) {
+	data := WsMarginCall{}
+	if err := json.Unmarshal(message, &data); err != nil {
+		e.errorHandler(url, fmt.Errorf("[BinanceWs] handleMarginCall - message Unmarshal to margin call error:%v", err))
+		return
+	}
+	marginCall := wsex.MarginCall{
+		CrossWalletBalance: utils.SafeParseFloat(data.CrossWalletBalance),
+		Positions:          make([]wsex.Position, 0, len(data.Positions)),
+		Timestamp:          time.Duration(data.EventTime),
+	}
+	for _, p := range data.Positions {
+		market, err := e.GetMarketByID(p.Symbol)
+		if err != nil {
+			continue
+		}
+		marginCall.Positions = append(marginCall.Positions, p.parsePosition(market.Symbol))
+	}
+	e.ConnectionMgr.Publish(url, topicAccount, wsex.Message{Type: wsex.MsgMarginCall, Data: marginCall})
 }
 
 func (e *BinanceFutureWs) handleOrder(url string, message []byte) {
@@ -509,45 +654,41 @@ func (e *BinanceFutureWs) handleOrder(url string, message []byte) {
 	order := data.FutureWsOrder.parseOrder(market.Symbol)
 	order.Cost = fmt.Sprintf("%f", utils.SafeParseFloat(data.FutureWsOrder.AvePrice)*utils.SafeParseFloat(data.FutureWsOrder.Filled))
 	order.CreateTime = time.Duration(data.Timestramp)
+	e.userData.track(order)
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrder, Data: order})
+	e.ConnectionMgr.Publish(url, topicOrder, wsex.Message{Type: wsex.MsgOrder, Data: order})
 }
 
-func (e *BinanceFutureWs) getSnapshotOrderBook(u string, market wsex.Market, symbolOrderBook *SymbolOrderBook) (err error) {
-	time.Sleep(time.Second)
-
+func (e *BinanceFutureWs) getSnapshotOrderBook(symbol string) (*book.Snapshot, error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return nil, err
+	}
 	var response struct {
 		LastUpdateID int64         `json:"lastUpdateId"` // Last update ID
 		Bids         wsex.RawDepth `json:"bids"`
 		Asks         wsex.RawDepth `json:"asks"`
 	}
-	client := &http.Client{}
+	client := &http.Client{Timeout: exchanges.SnapshotTimeout}
 	reqUrl := fmt.Sprintf("%s/fapi/v1/depth?symbol=%s&limit=1000", e.Option.RestHost, market.SymbolID)
 	req, err := http.NewRequest("GET", reqUrl, nil)
 	if err != nil {
-		return fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request %s  error:%v", reqUrl, err)
+		return nil, fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request %s  error:%v", reqUrl, err)
 	}
 	res, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request %s  error:%v", reqUrl, err)
+		return nil, fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request %s  error:%v", reqUrl, err)
 	}
 	defer res.Body.Close()
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return fmt.Errorf("[BinanceWs] getSnapshotOrderBook - response body  error:%v", err)
+		return nil, fmt.Errorf("[BinanceWs] getSnapshotOrderBook - response body  error:%v", err)
 	}
 	json.Unmarshal(body, &response)
 	if response.LastUpdateID == 0 {
-		return fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request url %s no data", reqUrl)
-	}
-	var orderBook OrderBook
-	orderBook.Bids = orderBook.Bids.Update(response.Bids, true)
-	orderBook.Asks = orderBook.Asks.Update(response.Asks, false)
-	orderBook.LastUpdateID = response.LastUpdateID
-	orderBook.Symbol = market.Symbol
-	(*symbolOrderBook)[market.Symbol] = &orderBook
-	e.orderBooks[u] = symbolOrderBook
-	return
+		return nil, fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request url %s no data", reqUrl)
+	}
+	return &book.Snapshot{Bids: response.Bids, Asks: response.Asks, Sequence: response.LastUpdateID}, nil
 }
 
 func (e *BinanceFutureWs) createListenKey() (string, error) {
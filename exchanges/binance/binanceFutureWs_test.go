@@ -58,6 +58,18 @@ func handleFutureMsg(msgChan <-chan wsex.Message) {
 					fmt.Printf("markprice data error %v", msg)
 				}
 				fmt.Printf("markPrice:%+v\n", markPrice)
+			case wsex.MsgIndexPrice:
+				indexPrice, ok := msg.Data.(wsex.IndexPrice)
+				if !ok {
+					fmt.Printf("indexprice data error %v", msg)
+				}
+				fmt.Printf("indexPrice:%+v\n", indexPrice)
+			case wsex.MsgLiquidation:
+				liquidation, ok := msg.Data.(wsex.Liquidation)
+				if !ok {
+					fmt.Printf("liquidation data error %v", msg)
+				}
+				fmt.Printf("liquidation:%+v\n", liquidation)
 			case wsex.MsgKLine:
 				klines, ok := msg.Data.(wsex.KLine)
 				if !ok {
@@ -82,6 +94,18 @@ func handleFutureMsg(msgChan <-chan wsex.Message) {
 					fmt.Printf("order data error %v", msg)
 				}
 				fmt.Printf("position:%+v\n", position)
+			case wsex.MsgAccount:
+				account, ok := msg.Data.(wsex.AccountUpdate)
+				if !ok {
+					fmt.Printf("account data error %v", msg)
+				}
+				fmt.Printf("account:%+v\n", account)
+			case wsex.MsgMarginCall:
+				marginCall, ok := msg.Data.(wsex.MarginCall)
+				if !ok {
+					fmt.Printf("margin call data error %v", msg)
+				}
+				fmt.Printf("margin call:%+v\n", marginCall)
 			}
 		}
 	}
@@ -129,8 +153,26 @@ func TestBinanceFutureWs_SubscribePositions(t *testing.T) {
 	}
 }
 
+func TestBinanceFutureWs_SubscribeAccount(t *testing.T) {
+	if _, err := BaFuture.SubscribeAccount(fmsgChan); err == nil {
+		handleFutureMsg(fmsgChan)
+	}
+}
+
 func TestBinanceFutureWs_SubscribeMarkPrice(t *testing.T) {
 	if _, err := BaFuture.SubscribeMarkPrice("EOS/USDT", fmsgChan); err == nil {
 		handleFutureMsg(fmsgChan)
 	}
 }
+
+func TestBinanceFutureWs_SubscribeIndexPrice(t *testing.T) {
+	if _, err := BaFuture.SubscribeIndexPrice("EOS/USDT", fmsgChan); err == nil {
+		handleFutureMsg(fmsgChan)
+	}
+}
+
+func TestBinanceFutureWs_SubscribeLiquidations(t *testing.T) {
+	if _, err := BaFuture.SubscribeLiquidations("", fmsgChan); err == nil {
+		handleFutureMsg(fmsgChan)
+	}
+}
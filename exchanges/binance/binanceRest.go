@@ -9,6 +9,7 @@ package binance
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -34,6 +35,9 @@ type BinanceRest struct {
 
 func (e *BinanceRest) Init(option wsex.Options) {
 	e.Option = option
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = string(wsex.Binance)
+	}
 	e.errors = map[int]RawError{
 		-2010: RawError{Code: wsex.ErrInsufficientFunds, Message: ""},
 		20006: RawError{Code: wsex.ErrInsufficientFunds, Message: ""},
@@ -267,30 +271,18 @@ func (e *BinanceRest) FetchBalance() (balances map[string]wsex.Balance, err erro
 }
 
 func (e *BinanceRest) CreateOrder(symbol string, price, amount float64, side wsex.Side, tradeType wsex.TradeType, orderType wsex.OrderType, useClientID bool) (order wsex.Order, err error) {
-	market, err := e.GetMarket(symbol)
+	return e.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Price: price, Amount: amount, Side: side, Type: tradeType, OrderType: orderType, UseClientID: useClientID})
+}
+
+func (e *BinanceRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
+	market, err := e.GetMarket(req.Symbol)
 	if err != nil {
 		return
 	}
-	params := url.Values{}
-	params.Set("symbol", market.SymbolID)
-	params.Set("quantity", utils.Round(amount, market.AmountPrecision, false))
-	if side == wsex.Sell {
-		params.Set("side", "SELL")
-	} else if side == wsex.Buy {
-		params.Set("side", "BUY")
-	}
-	switch tradeType {
-	case wsex.MARKET:
-		params.Set("type", "MARKET")
-	default:
-		params.Set("price", utils.Round(price, market.PricePrecision, false))
-		params.Set("type", "LIMIT")
-		params.Set("timeInForce", "GTC")
-	}
-	if useClientID {
-		params.Set("newClientOrderId", GenerateOrderClientId(e.Option.ClientOrderIDPrefix, 32))
+	params, err := e.orderParams(market, req)
+	if err != nil {
+		return
 	}
-	params.Set("newOrderRespType", "ACK")
 	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/api/v3/order", params, http.Header{})
 	if err != nil {
 		return
@@ -310,6 +302,62 @@ func (e *BinanceRest) CreateOrder(symbol string, price, amount float64, side wse
 	return
 }
 
+func (e *BinanceRest) orderParams(market wsex.Market, req wsex.OrderRequest) (params url.Values, err error) {
+	req = req.Normalize()
+	if err = exchanges.CheckSpotRequest("binance spot", req); err != nil {
+		return
+	}
+	if err = exchanges.CheckConditionalRequest("binance spot", req, wsex.TriggerLast); err != nil {
+		return
+	}
+	params = url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("side", string(req.Side))
+	params.Set("type", spotOrderType(req.Type))
+	if req.QuoteAmount > 0 {
+		if req.Type != wsex.MARKET {
+			err = exchanges.ErrNotSupport("binance spot", fmt.Sprintf("quote amount of %s order", req.Type))
+			return
+		}
+		params.Set("quoteOrderQty", utils.Round(req.QuoteAmount, market.PricePrecision, false))
+	} else {
+		params.Set("quantity", utils.Round(req.Amount, market.AmountPrecision, false))
+	}
+	if req.Type.IsLimit() {
+		params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+		switch req.OrderType {
+		case wsex.PostOnly:
+			if req.Type != wsex.LIMIT {
+				err = exchanges.ErrNotSupport("binance spot", "post only conditional order")
+				return
+			}
+			params.Set("type", "LIMIT_MAKER")
+		case wsex.IOC:
+			params.Set("timeInForce", "IOC")
+		case wsex.FOK:
+			params.Set("timeInForce", "FOK")
+		default:
+			params.Set("timeInForce", "GTC")
+		}
+	}
+	switch req.Type {
+	case wsex.TRAILING_STOP:
+		// trailingDelta in BIPS, the order is activated at once without stopPrice
+		params.Set("trailingDelta", strconv.FormatInt(int64(math.Round(req.CallbackRate*10000)), 10))
+		if req.ActivationPrice > 0 {
+			params.Set("stopPrice", utils.Round(req.ActivationPrice, market.PricePrecision, false))
+		}
+	case wsex.STOP_MARKET, wsex.STOP_LIMIT, wsex.TAKE_PROFIT_MARKET, wsex.TAKE_PROFIT_LIMIT:
+		params.Set("stopPrice", utils.Round(req.StopPrice, market.PricePrecision, false))
+	}
+	if clientID := req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32); clientID != "" {
+		params.Set("newClientOrderId", clientID)
+	}
+	params.Set("newOrderRespType", "ACK")
+	exchanges.MergeParams(params, req.Params)
+	return
+}
+
 func (e *BinanceRest) CancelOrder(symbol, orderID string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -327,6 +375,94 @@ func (e *BinanceRest) CancelOrder(symbol, orderID string) (err error) {
 	return err
 }
 
+// CreateOrders binance spot has no batch endpoint, orders are placed concurrently
+func (e *BinanceRest) CreateOrders(requests []wsex.OrderRequest) ([]wsex.OrderResult, error) {
+	return exchanges.CreateOrdersConcurrently(requests, e.PlaceOrder), nil
+}
+
+// CancelOrders binance spot has no batch endpoint, orders are cancelled concurrently
+func (e *BinanceRest) CancelOrders(symbol string, orderIDs []string) ([]wsex.CancelResult, error) {
+	if _, err := e.GetMarket(symbol); err != nil {
+		return nil, err
+	}
+	return exchanges.CancelOrdersConcurrently(orderIDs, func(orderID string) error {
+		return e.CancelOrder(symbol, orderID)
+	}), nil
+}
+
+// AmendOrder by /api/v3/order/cancelReplace, the new order is not placed if cancel fails,
+// it keeps the type and time in force of the original order, the trailing stop order is not amended
+func (e *BinanceRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (result wsex.AmendResult, err error) {
+	result.Method = wsex.AmendCancelReplace
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	order, err := e.FetchOrder(symbol, orderID)
+	if err != nil {
+		return
+	}
+	price, remaining, err := exchanges.ResolveAmend(order, newPrice, newAmount)
+	if err != nil {
+		return
+	}
+	// the new order keeps the type and time in force of the original one
+	tradeType := order.Type
+	if tradeType == wsex.TRAILING_STOP || tradeType == "" || tradeType == wsex.TradeTypeUnKnown {
+		err = wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("%s order %s can not be amended", tradeType, orderID)}
+		return
+	}
+	req := wsex.OrderRequest{
+		Symbol:      symbol,
+		Price:       price,
+		Amount:      remaining,
+		Side:        order.Side,
+		Type:        tradeType,
+		OrderType:   order.OrderType,
+		UseClientID: order.ClientID != "",
+	}
+	if tradeType.IsConditional() {
+		req.StopPrice = utils.SafeParseFloat(order.StopPrice)
+	}
+	params, err := e.orderParams(market, req)
+	if err != nil {
+		return
+	}
+	params.Set("cancelReplaceMode", "STOP_ON_FAILURE")
+	if IsClientOrderID(orderID, e.Option.ClientOrderIDPrefix) {
+		params.Set("cancelOrigClientOrderId", orderID)
+	} else {
+		params.Set("cancelOrderId", orderID)
+	}
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/api/v3/order/cancelReplace", params, http.Header{})
+	if err != nil {
+		return
+	}
+	type response struct {
+		NewOrderResponse struct {
+			ID  int64  `json:"orderId"`
+			CID string `json:"clientOrderId"`
+		} `json:"newOrderResponse"`
+	}
+	data := response{}
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	result.Order = wsex.Order{
+		ID:        strconv.FormatInt(data.NewOrderResponse.ID, 10),
+		ClientID:  data.NewOrderResponse.CID,
+		Symbol:    market.Symbol,
+		Price:     params.Get("price"),
+		Amount:    params.Get("quantity"),
+		Status:    wsex.Open,
+		Side:      order.Side,
+		Type:      order.Type,
+		OrderType: order.OrderType,
+	}
+	return
+}
+
 func (e *BinanceRest) CancelAllOrders(symbol string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -393,6 +529,75 @@ func (e *BinanceRest) FetchOpenOrders(symbol string, pageIndex, pageSize int) (o
 	return
 }
 
+// fetchOrdersSince the orders of the symbol created since the time in ms, at most 1000
+func (e *BinanceRest) fetchOrdersSince(symbol string, since time.Duration) (orders []wsex.Order, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("startTime", strconv.FormatInt(int64(since), 10))
+	params.Set("limit", "1000")
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/api/v3/allOrders", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data = make([]Order, 0)
+	restJson := jsoniter.Config{TagKey: "rest"}.Froze()
+	if err = restJson.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	for _, order := range data {
+		orders = append(orders, order.parseOrder(market.Symbol))
+	}
+	return
+}
+
+//FetchConditionalOrders : untriggered stop orders are open orders of binance
+func (e *BinanceRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	openOrders, err := e.FetchOpenOrders(symbol, 0, 0)
+	if err != nil {
+		return
+	}
+	for _, order := range openOrders {
+		if order.Type.IsConditional() {
+			orders = append(orders, order)
+		}
+	}
+	return
+}
+
+func (e *BinanceRest) CancelConditionalOrder(symbol, orderID string) error {
+	return e.CancelOrder(symbol, orderID)
+}
+
+//FetchTradingFees : maker/taker commission rates of the account
+func (e *BinanceRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/sapi/v1/asset/tradeFee", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data = make([]TradeFee, 0)
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	if len(data) == 0 {
+		err = wsex.ExError{Code: wsex.ErrNotFoundMarket, Message: fmt.Sprintf("trade fee of %s not found", symbol)}
+		return
+	}
+	fee = data[0].parseTradingFee(market.Symbol)
+	return
+}
+
 func (e *BinanceRest) Sign(access, method, function string, param url.Values, header http.Header) (request exchanges.Request) {
 	request.Method = method
 	request.Headers = header
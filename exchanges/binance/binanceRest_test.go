@@ -102,3 +102,11 @@ func TestBinanceRest_CancelOrder(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestBinanceRest_FetchTradingFees(t *testing.T) {
+	fee, err := rest.FetchTradingFees(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(fee)
+}
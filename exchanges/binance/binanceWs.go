@@ -10,13 +10,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/book"
 	"github.com/shiguantian/wsex/exchanges"
 	"github.com/shiguantian/wsex/exchanges/websocket"
 	. "github.com/shiguantian/wsex/utils"
@@ -30,11 +32,87 @@ type Stream struct {
 	Id     int      `json:"id"`
 }
 
+var streamID int32
+
+// rebalanceDelay wait for the subscribers resubscribing after reconnected before rebalancing the shards
+const rebalanceDelay = time.Second * 5
+
+// nextStreamID the id of the request, used to match the response
+func nextStreamID() int {
+	return int(atomic.AddInt32(&streamID, 1))
+}
+
+// SubscribeResponse the response of the SUBSCRIBE request,
+// success: {"result":null,"id":1}, failed: {"error":{"code":2,"msg":"Invalid request"},"id":1} or {"code":2,"msg":"Invalid request","id":1}
+type SubscribeResponse struct {
+	Id    *int                 `json:"id"`
+	Code  int                  `json:"code"`
+	Msg   string               `json:"msg"`
+	Error *UserDataStreamError `json:"error"`
+}
+
+func (r SubscribeResponse) err() error {
+	if r.Error != nil {
+		return wsex.ExError{Code: wsex.ErrChannelNotExist, Message: r.Error.Msg, Data: map[string]interface{}{"code": r.Error.Code}}
+	}
+	if r.Msg != "" {
+		return wsex.ExError{Code: wsex.ErrChannelNotExist, Message: r.Msg, Data: map[string]interface{}{"code": r.Code}}
+	}
+	return nil
+}
+
+// handleSubscribeAck deliver the response of the SUBSCRIBE request to the waiting subscriber, returns false if it is not a response
+func handleSubscribeAck(ack *exchanges.SubscribeAck, message []byte) bool {
+	if !bytes.Contains(message, []byte(`"id"`)) {
+		return false
+	}
+	var res SubscribeResponse
+	if err := json.Unmarshal(message, &res); err != nil || res.Id == nil {
+		return false
+	}
+	ack.Done(strconv.Itoa(*res.Id), res.err())
+	return true
+}
+
+// subscribeWithAck send the SUBSCRIBE request and wait for the response, the route is removed if failed
+func subscribeWithAck(b *exchanges.BaseExchange, conn *exchanges.Connection, topic string, stream Stream, sub wsex.MessageChan) error {
+	id := strconv.Itoa(stream.Id)
+	ack := b.SubscribeAck.Register(id)
+	conn.Subscribe(topic, sub)
+	err := conn.SendJsonMessage(stream)
+	if err != nil {
+		b.SubscribeAck.Done(id, err)
+	} else {
+		err = b.WaitSubscribeAck(id, ack)
+	}
+	if err != nil {
+		conn.UnSubscribe(topic, sub)
+	}
+	return err
+}
+
+// moveStream subscribe the stream on the connection to, then unsubscribe it on the connection from if unsubscribe
+func moveStream(b *exchanges.BaseExchange, from, to *exchanges.Connection, stream Stream, unsubscribe Stream) error {
+	id := strconv.Itoa(stream.Id)
+	ack := b.SubscribeAck.Register(id)
+	if err := to.SendJsonMessage(stream); err != nil {
+		b.SubscribeAck.Done(id, err)
+		return err
+	}
+	if err := b.WaitSubscribeAck(id, ack); err != nil {
+		return err
+	}
+	if len(unsubscribe.Params) == 0 {
+		return nil
+	}
+	return from.SendJsonMessage(unsubscribe)
+}
+
 func SubscribeStream(event ...string) Stream {
 	return Stream{
 		Method: "SUBSCRIBE",
 		Params: append([]string{}, event...),
-		Id:     time.Now().Nanosecond(),
+		Id:     nextStreamID(),
 	}
 }
 
@@ -42,10 +120,18 @@ func UnSubscribeStream(event ...string) Stream {
 	return Stream{
 		Method: "UNSUBSCRIBE",
 		Params: append([]string{}, event...),
-		Id:     time.Now().Nanosecond(),
+		Id:     nextStreamID(),
 	}
 }
 
+// topics of the user data stream, balance and order share one stream
+const (
+	topicBalance   = "balance"
+	topicOrder     = "order"
+	topicPositions = "positions"
+	topicAccount   = "account"
+)
+
 type UserDataStreamError struct {
 	Code int    `json:"code"`
 	Msg  string `json:"msg"`
@@ -55,23 +141,37 @@ func (u UserDataStreamError) Error() string {
 	return fmt.Sprintf("UserDataStreamError error, code:%v msg:%v", u.Code, u.Msg)
 }
 
-//The websocket function of binance is not well designed.
-//first, there's no topic field passed by subscribe action in the async return data. so, it is impossible to directly distinguish whose data is
-//Second, some return data don't even have event field, only determine what kind of data it is by parsing the string
+// CombinedStream the envelope of the combined streams, {"stream":"<streamName>","data":<rawPayload>}
+type CombinedStream struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// combinedStreamHost the host of the combined streams, the raw stream host(/ws) is converted
+func combinedStreamHost(host string) string {
+	host = strings.TrimSuffix(strings.TrimSuffix(host, "/"), "/ws")
+	if !strings.HasSuffix(host, "/stream") {
+		host += "/stream"
+	}
+	return host
+}
+
+//The raw streams of binance carry no stream name, and the partial book depth even has no symbol,
+//so the combined streams are used, the stream name in the envelope is the topic of the data.
 
 type BinanceWs struct {
 	exchanges.BaseExchange
-	orderBooks       map[string]*SymbolOrderBook // orderbook's local cache of one symbol
-	partialOrderBook OrderBook                   // Partial Book Depth
-	errors           map[int]wsex.ExError
-	listenKey        string // listenKey for User Data Streams, including account update,balance update,order update
-	listenKeyStop    chan struct{}
+	orderBooks        map[string]*book.Engine // orderbook's local cache of the connection, key: ws url
+	partialOrderBooks map[string]*OrderBook   // Partial Book Depth, key: stream name
+	errors            map[int]wsex.ExError
+	userData          *userDataSession // User Data Streams, including account update,balance update,order update
 }
 
 func (e *BinanceWs) Init(option wsex.Options) {
 	e.BaseExchange.Init()
 	e.Option = option
-	e.orderBooks = make(map[string]*SymbolOrderBook)
+	e.orderBooks = make(map[string]*book.Engine)
+	e.partialOrderBooks = make(map[string]*OrderBook)
 	e.errors = map[int]wsex.ExError{
 		30040: wsex.ExError{Code: wsex.ErrChannelNotExist},
 		30008: wsex.ExError{Code: wsex.ErrAuthFailed},
@@ -80,25 +180,33 @@ func (e *BinanceWs) Init(option wsex.Options) {
 		30041: wsex.ExError{Code: wsex.ErrAuthFailed},
 	}
 	if e.Option.WsHost == "" {
-		e.Option.WsHost = "wss://stream.binance.com:9443/ws"
+		e.Option.WsHost = "wss://stream.binance.com:9443/stream"
+	}
+	e.Option.WsHost = combinedStreamHost(e.Option.WsHost)
+	if e.Option.MaxTopicsPerConn == 0 {
+		e.Option.MaxTopicsPerConn = 1024 // a single connection can listen to a maximum of 1024 streams
 	}
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = string(wsex.Binance)
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 	if e.Option.RestHost == "" {
 		e.Option.RestHost = "https://api.binance.com/api/v3"
 	}
-	e.listenKeyStop = make(chan struct{})
+	e.userData = &userDataSession{
+		mgr:       e.ConnectionMgr,
+		url:       e.userDataUrl,
+		connect:   e.Connect,
+		create:    e.createListenKey,
+		keepAlive: e.keepAliveListenKey,
+		remove:    e.deleteListenKey,
+		onError:   e.errorHandler,
+	}
 }
 
 func (e *BinanceWs) SubscribeOrderBook(symbol string, level, speed int, isIncremental bool, sub wsex.MessageChan) (string, error) {
-	e.RwLock.Lock()
-	if !isIncremental {
-		if e.partialOrderBook.Symbol != "" {
-			//It's a poor design of binance, because there's no event field for this kind of return, it is impossible to distinguish whose data it is.
-			//so only support one symbol data subscribe
-			return "", errors.New("binance instance can only obtain one symbol partial order book at the same time")
-		}
-		e.partialOrderBook.Symbol = symbol
-	}
-	e.RwLock.Unlock()
 	var suffix = "depth"
 	if level > 20 {
 		level = 20
@@ -148,26 +256,44 @@ func (e *BinanceWs) SubscribeKLine(symbol string, t wsex.KLineType, sub wsex.Mes
 }
 
 func (e *BinanceWs) SubscribeBalance(symbol string, sub wsex.MessageChan) (string, error) {
-	return e.subscribeUserData(sub)
+	return e.userData.subscribe(topicBalance, "", sub)
 }
 
+// SubscribeOrder the orders of all symbols are pushed, the orders of symbol are backfilled after the listenKey is renewed
 func (e *BinanceWs) SubscribeOrder(symbol string, sub wsex.MessageChan) (string, error) {
-	return e.subscribeUserData(sub)
+	return e.userData.subscribe(topicOrder, symbol, sub)
 }
 
 func (e *BinanceWs) UnSubscribe(event string, sub wsex.MessageChan) error {
-	conn, err := e.ConnectionMgr.GetConnection(e.Option.WsHost, nil)
+	if event == topicBalance || event == topicOrder {
+		// balance and order share the user data stream, it is closed after the last subscriber unsubscribed
+		return e.userData.unSubscribe(event, sub)
+	}
+	conn, err := e.ConnectionMgr.FindShard(e.Option.WsHost, event)
 	if err != nil {
 		return err
 	}
+	if !conn.UnSubscribe(event, sub) {
+		return nil
+	}
+	e.RwLock.Lock()
+	delete(e.partialOrderBooks, event)
+	e.RwLock.Unlock()
 	if err := e.send(conn, UnSubscribeStream(event)); err != nil {
 		return err
 	}
-
-	conn.UnSubscribe(sub)
+	// the unsubscription is done, the failed rebalancing is only reported
+	if err := e.ConnectionMgr.Rebalance(e.Option.WsHost, e.moveTopic); err != nil {
+		e.errorHandler(e.Option.WsHost, err)
+	}
 	return nil
 }
 
+// moveTopic the MoveFunc of rebalancing the shards
+func (e *BinanceWs) moveTopic(topic string, from, to *exchanges.Connection) error {
+	return moveStream(&e.BaseExchange, from, to, SubscribeStream(topic), UnSubscribeStream(topic))
+}
+
 func (e *BinanceWs) Connect(url string) (*exchanges.Connection, error) {
 	conn := exchanges.NewConnection()
 	err := conn.Connect(
@@ -188,56 +314,20 @@ func (e *BinanceWs) Connect(url string) (*exchanges.Connection, error) {
 }
 
 func (e *BinanceWs) subscribe(url, topic string, sub wsex.MessageChan) (string, error) {
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	conn, err := e.ConnectionMgr.GetShard(url, topic, sub, e.Connect)
 	if err != nil {
 		return "", err
 	}
 
-	if err := e.send(conn, SubscribeStream(topic)); err != nil {
+	if err := subscribeWithAck(&e.BaseExchange, conn, topic, SubscribeStream(topic), sub); err != nil {
 		return "", err
 	}
-	conn.Subscribe(sub)
 	return topic, nil
 }
 
-func (e *BinanceWs) subscribeUserData(sub wsex.MessageChan) (string, error) {
-	e.RwLock.Lock()
-	defer e.RwLock.Unlock()
-	if e.listenKey != "" {
-		// Because balance and order share the same stream, so just subscribe once.
-		url := fmt.Sprintf("%s/%s", e.Option.WsHost, e.listenKey)
-		conn, err := e.ConnectionMgr.GetConnection(url, nil)
-		if err == nil {
-			conn.Subscribe(sub)
-		}
-		return e.listenKey, err
-	}
-	var err error
-	e.listenKey, err = e.createListenKey()
-	if err != nil {
-		return e.listenKey, err
-	}
-	e.listenKeyStop = make(chan struct{})
-	go func() {
-		ticker := time.NewTicker(time.Minute * 30)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				e.keepAliveListenKey(e.listenKey)
-			case <-e.listenKeyStop:
-				return
-			}
-		}
-	}()
-
-	url := fmt.Sprintf("%s/%s", e.Option.WsHost, e.listenKey)
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
-	if err != nil {
-		return e.listenKey, err
-	}
-	conn.Subscribe(sub)
-	return e.listenKey, nil
+// userDataUrl the user data stream is a combined stream named listenKey
+func (e *BinanceWs) userDataUrl(listenKey string) string {
+	return fmt.Sprintf("%s?streams=%s", e.Option.WsHost, listenKey)
 }
 
 func (e *BinanceWs) getTopicBySymbol(symbol, suffix string) (string, error) {
@@ -249,6 +339,20 @@ func (e *BinanceWs) getTopicBySymbol(symbol, suffix string) (string, error) {
 	return strings.ToLower(topic), nil
 }
 
+// publishStream the stream may have options, eg: btcusdt@depth@100ms, btcusdt@markPrice@1s
+func publishStream(mgr *exchanges.ConnectionManager, url, symbolID, stream string, msg wsex.Message) {
+	conn, err := mgr.GetConnection(url, nil)
+	if err != nil {
+		return
+	}
+	prefix := fmt.Sprintf("%s@%s", strings.ToLower(symbolID), stream)
+	for _, topic := range conn.Topics() {
+		if topic == prefix || strings.HasPrefix(topic, prefix+"@") {
+			conn.Publish(topic, msg)
+		}
+	}
+}
+
 func (e *BinanceWs) send(conn *exchanges.Connection, data Stream) (err error) {
 	if err = conn.SendJsonMessage(data); err != nil {
 		return err
@@ -258,6 +362,17 @@ func (e *BinanceWs) send(conn *exchanges.Connection, data Stream) (err error) {
 }
 
 func (e *BinanceWs) messageHandler(url string, message []byte) {
+	combined := CombinedStream{}
+	if err := json.Unmarshal(message, &combined); err != nil {
+		e.errorHandler(url, fmt.Errorf("[BinanceWs] messageHandler unmarshal error:%v", err))
+		return
+	}
+	if combined.Stream == "" {
+		// the response of the SUBSCRIBE request has no envelope
+		handleSubscribeAck(e.SubscribeAck, message)
+		return
+	}
+	stream, message := combined.Stream, combined.Data
 	res := ResponseEvent{}
 	if err := json.Unmarshal(message, &res); err != nil {
 		e.errorHandler(url, fmt.Errorf("[BinanceWs] messageHandler unmarshal error:%v", err))
@@ -267,16 +382,16 @@ func (e *BinanceWs) messageHandler(url string, message []byte) {
 	switch res.Event {
 	case "depthUpdate":
 		//Diff. Depth Stream(Order book price and quantity depth updates used to locally manage an order book.)
-		e.handleIncrementalDepth(url, message)
+		e.handleIncrementalDepth(url, stream, message)
 	case "24hrTicker":
 		//Individual Symbol Ticker Streams(24hr rolling window ticker statistics for a single symbol)
-		e.handleTicker(url, message)
+		e.handleTicker(url, stream, message)
 	case "trade":
 		//Trade Streams(The Trade Streams push raw trade information; each trade has a unique buyer and seller.)
-		e.handleTrade(url, message)
+		e.handleTrade(url, stream, message)
 	case "kline":
 		//Kline/Candlestick Streams(The Kline/Candlestick Stream push updates to the current klines/candlestick every second.)
-		e.handleKLine(url, message)
+		e.handleKLine(url, stream, message)
 	case "executionReport":
 		//Orders are updated with the executionReport event.
 		//NEW - The order has been accepted into the engine.
@@ -294,25 +409,36 @@ func (e *BinanceWs) messageHandler(url string, message []byte) {
 		//Deposits or withdrawals from the account
 		//Transfer of funds between accounts (e.g. Spot to Margin)
 		e.handleBalance(url, true, message)
+	case "listenKeyExpired":
+		go e.userData.expired(url)
 	default:
-		//It's a poor design of binance, because there's no event field for this kind of return, it is impossible to distinguish whose data it is.
-		//So the data will be published to all subscribers who have the same event type, and the user need distinguish the right market data by himself
-		if bytes.Contains(message, []byte("lastUpdateId")) && bytes.Contains(message, []byte("bids")) {
-			//Partial Book Depth Streams(Top bids and asks of specified level)
-			e.handleDepth(url, message)
+		//Partial Book Depth Streams(Top bids and asks of specified level) has no event field, distinguish it by the stream name
+		if strings.Contains(stream, "@depth") {
+			e.handleDepth(url, stream, message)
 		}
 	}
 }
 
 func (e *BinanceWs) reConnectedHandler(url string) {
 	e.BaseExchange.ReConnectedHandler(url, nil)
+	// the routes of the reconnected shard are cleared, compact the shards after the subscribers resubscribed.
+	// it's not called in the read loop, the moves wait for the acks of the connections
+	if url != e.Option.WsHost && !strings.HasPrefix(url, e.Option.WsHost+"#") {
+		return
+	}
+	go func() {
+		time.Sleep(rebalanceDelay)
+		if err := e.ConnectionMgr.Rebalance(e.Option.WsHost, e.moveTopic); err != nil {
+			e.errorHandler(url, err)
+		}
+	}()
 }
 
 func (e *BinanceWs) disConnectedHandler(url string, err error) {
 	// clear cache data, Prevent getting dirty data
 	e.BaseExchange.DisConnectedHandler(url, err, func() {
 		delete(e.orderBooks, url)
-		e.partialOrderBook = OrderBook{}
+		e.partialOrderBooks = make(map[string]*OrderBook)
 	})
 }
 
@@ -320,11 +446,8 @@ func (e *BinanceWs) closeHandler(url string) {
 	// clear cache data and the connection
 	e.BaseExchange.CloseHandler(url, func() {
 		delete(e.orderBooks, url)
-		if strings.Contains(url, e.listenKey) {
-			e.listenKey = ""
-			close(e.listenKeyStop)
-		}
 	})
+	e.userData.closed(url)
 }
 
 func (e *BinanceWs) errorHandler(url string, err error) {
@@ -339,24 +462,39 @@ func (e *BinanceWs) heartbeatHandler(url string) {
 	conn.SendPongMessage([]byte("pong"))
 }
 
-func (e *BinanceWs) handleDepth(url string, message []byte) {
+func (e *BinanceWs) handleDepth(url, stream string, message []byte) {
 	data := RawOrderBook{}
 	restJson := jsoniter.Config{TagKey: "rest"}.Froze()
 	if err := restJson.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[BinanceWs] handleDepth - message Unmarshal to RawOrderBook error:%v", err))
 		return
 	}
-	if data.LastUpdateID < e.partialOrderBook.LastUpdateID {
+	market, err := e.GetMarketByID(strings.Split(stream, "@")[0])
+	if err != nil {
+		e.errorHandler(url, err)
 		return
 	}
 
-	e.partialOrderBook.Bids = wsex.Depth{}
-	e.partialOrderBook.Asks = wsex.Depth{}
-	e.partialOrderBook.update(data)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: e.partialOrderBook.OrderBook})
+	e.RwLock.Lock()
+	partialOrderBook, ok := e.partialOrderBooks[stream]
+	if !ok {
+		partialOrderBook = &OrderBook{}
+		e.partialOrderBooks[stream] = partialOrderBook
+	}
+	if data.LastUpdateID < partialOrderBook.LastUpdateID {
+		e.RwLock.Unlock()
+		return
+	}
+	partialOrderBook.Bids = wsex.Depth{}
+	partialOrderBook.Asks = wsex.Depth{}
+	partialOrderBook.update(data)
+	partialOrderBook.Symbol = market.Symbol
+	orderBook := partialOrderBook.OrderBook
+	e.RwLock.Unlock()
+	e.ConnectionMgr.Publish(url, stream, wsex.Message{Type: wsex.MsgOrderBook, Data: orderBook})
 }
 
-func (e *BinanceWs) handleIncrementalDepth(url string, message []byte) {
+func (e *BinanceWs) handleIncrementalDepth(url, stream string, message []byte) {
 	rawOB := RawOrderBook{}
 	if err := json.Unmarshal(message, &rawOB); err != nil {
 		e.errorHandler(url, fmt.Errorf("[BinanceWs] handleIncrementalDepth - message Unmarshal to RawOrderBook error:%v", err))
@@ -380,35 +518,39 @@ func (e *BinanceWs) handleIncrementalDepth(url string, message []byte) {
 		e.errorHandler(url, err)
 		return
 	}
-
-	symbolOrderBook, ok := e.orderBooks[url]
-	if !ok || symbolOrderBook == nil {
-		_ = e.getSnapshotOrderBook(url, market, &SymbolOrderBook{})
-		return
+	delta := book.Delta{Bids: rawOB.Bids, Asks: rawOB.Asks, First: rawOB.FirstUpdateID, Last: rawOB.LastUpdateID, Time: rawOB.EventTime,
+		RecvTime: e.ConnectionMgr.RecvTime(url)}
+	for _, msg := range e.orderBook(url).Update(market.Symbol, delta) {
+		e.ConnectionMgr.Publish(url, stream, msg)
 	}
-	fullOrderBook, ok := (*symbolOrderBook)[market.Symbol]
+}
+
+// orderBook the local order books of the connection
+func (e *BinanceWs) orderBook(url string) *book.Engine {
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	engine, ok := e.orderBooks[url]
 	if !ok {
-		_ = e.getSnapshotOrderBook(url, market, symbolOrderBook)
-		return
-	}
-	if fullOrderBook != nil {
-		first := rawOB.FirstUpdateID <= fullOrderBook.LastUpdateID+1 && rawOB.LastUpdateID >= fullOrderBook.LastUpdateID
-		if first || rawOB.FirstUpdateID == fullOrderBook.LastUpdateID+1 {
-			fullOrderBook.update(rawOB)
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
-		} else if rawOB.LastUpdateID < fullOrderBook.LastUpdateID {
-			log.Printf("[BinanceWs] handleIncrementalDepth - recv old update data\n")
-		} else {
-			delete(*symbolOrderBook, market.Symbol)
-			err := wsex.ExError{Code: wsex.ErrInvalidDepth,
-				Message: fmt.Sprintf("[BinanceWs] handleIncrementalDepth - recv dirty data, new.FirstUpdateID: %v != old.LastUpdateID: %v ", rawOB.FirstUpdateID, fullOrderBook.LastUpdateID+1),
-				Data:    map[string]interface{}{"symbol": fullOrderBook.Symbol}}
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: err})
-		}
+		engine = book.NewEngine(e.getSnapshotOrderBook)
+		engine.SetAsync(func(symbol string, msgs []wsex.Message) {
+			market, err := e.GetMarket(symbol)
+			if err != nil {
+				e.errorHandler(url, err)
+				return
+			}
+			for _, msg := range msgs {
+				publishStream(e.ConnectionMgr, url, market.SymbolID, "depth", msg)
+			}
+		})
+		engine.SetDepth(e.Option.OrderBookDepth)
+		engine.SetMetrics(e.Option.ExchangeName, e.Option.Metrics)
+		engine.SetCompareInterval(e.Option.OrderBookCheckTime)
+		e.orderBooks[url] = engine
 	}
+	return engine
 }
 
-func (e *BinanceWs) handleTicker(url string, message []byte) {
+func (e *BinanceWs) handleTicker(url, stream string, message []byte) {
 	data := Ticker{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[BinanceWs] handleTicker - message Unmarshal to ticker error:%v", err))
@@ -421,10 +563,10 @@ func (e *BinanceWs) handleTicker(url string, message []byte) {
 	}
 	ticker := data.parseTicker(market.Symbol)
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
+	e.ConnectionMgr.Publish(url, stream, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
 }
 
-func (e *BinanceWs) handleTrade(url string, message []byte) {
+func (e *BinanceWs) handleTrade(url, stream string, message []byte) {
 	data := Trade{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[BinanceWs] handleTrade - message Unmarshal to trade error:%v", err))
@@ -436,10 +578,10 @@ func (e *BinanceWs) handleTrade(url string, message []byte) {
 		return
 	}
 	trade := data.parseTrade(market.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: trade})
+	e.ConnectionMgr.Publish(url, stream, wsex.Message{Type: wsex.MsgTrade, Data: trade})
 }
 
-func (e *BinanceWs) handleKLine(url string, message []byte) {
+func (e *BinanceWs) handleKLine(url, stream string, message []byte) {
 	data := KLine{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[BinanceWs] handleKLine - message Unmarshal to kline error:%v", err))
@@ -460,7 +602,7 @@ func (e *BinanceWs) handleKLine(url string, message []byte) {
 		Volume:    SafeParseFloat(data.Line.Volume),
 	}
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgKLine, Data: kline})
+	e.ConnectionMgr.Publish(url, stream, wsex.Message{Type: wsex.MsgKLine, Data: kline})
 }
 
 func (e *BinanceWs) handleBalance(url string, balanceUpdate bool, message []byte) {
@@ -479,7 +621,7 @@ func (e *BinanceWs) handleBalance(url string, balanceUpdate bool, message []byte
 			balances.Balances[b.Currency] = b.parseBalance()
 		}
 	}
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	e.ConnectionMgr.Publish(url, topicBalance, wsex.Message{Type: wsex.MsgBalance, Data: balances})
 }
 
 func (e *BinanceWs) handleOrder(url string, message []byte) {
@@ -490,35 +632,31 @@ func (e *BinanceWs) handleOrder(url string, message []byte) {
 	}
 	market, _ := e.GetMarketByID(data.Symbol)
 	order := data.parseOrder(market.Symbol)
+	e.userData.track(order)
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrder, Data: order})
+	e.ConnectionMgr.Publish(url, topicOrder, wsex.Message{Type: wsex.MsgOrder, Data: order})
 }
 
-func (e *BinanceWs) getSnapshotOrderBook(url string, market wsex.Market, symbolOrderBook *SymbolOrderBook) (err error) {
-	time.Sleep(time.Second)
-
+func (e *BinanceWs) getSnapshotOrderBook(symbol string) (*book.Snapshot, error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return nil, err
+	}
 	var response struct {
 		LastUpdateID int64         `json:"lastUpdateId"` // Last update ID
 		Bids         wsex.RawDepth `json:"bids"`
 		Asks         wsex.RawDepth `json:"asks"`
 	}
-	client := resty.New()
+	client := resty.New().SetTimeout(exchanges.SnapshotTimeout)
 	reqUrl := fmt.Sprintf("%s/depth?symbol=%s&limit=1000", e.Option.RestHost, market.SymbolID)
 	_, err = client.R().SetResult(&response).Get(reqUrl)
 	if err != nil {
-		return fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request url %s error:%v", reqUrl, err)
+		return nil, fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request url %s error:%v", reqUrl, err)
 	}
 	if response.LastUpdateID == 0 {
-		return fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request url %s no data", reqUrl)
-	}
-	var orderBook OrderBook
-	orderBook.Bids = orderBook.Bids.Update(response.Bids, true)
-	orderBook.Asks = orderBook.Asks.Update(response.Asks, false)
-	orderBook.LastUpdateID = response.LastUpdateID
-	orderBook.Symbol = market.Symbol
-	(*symbolOrderBook)[market.Symbol] = &orderBook
-	e.orderBooks[url] = symbolOrderBook
-	return
+		return nil, fmt.Errorf("[BinanceWs] getSnapshotOrderBook - request url %s no data", reqUrl)
+	}
+	return &book.Snapshot{Bids: response.Bids, Asks: response.Asks, Sequence: response.LastUpdateID}, nil
 }
 
 func (e *BinanceWs) createListenKey() (string, error) {
@@ -46,6 +46,10 @@ func handleMsg(msgChan <-chan wsex.Message) {
 						//depth data invalid, Do some cleanup work, wait for the latest data or resubscribe
 					}
 				}
+			case wsex.MsgBookInvalid:
+				fmt.Printf("order book invalid: %+v\n", msg.Data)
+			case wsex.MsgBookResynced:
+				fmt.Printf("order book resynced: %+v\n", msg.Data)
 			case wsex.MsgOrderBook:
 				orderbook, ok := msg.Data.(wsex.OrderBook)
 				if !ok {
@@ -96,6 +100,16 @@ func TestBinance_SubscribeOrderBook(t *testing.T) {
 	}
 }
 
+func TestBinance_SubscribeMultiPartialOrderBook(t *testing.T) {
+	if _, err := e.SubscribeOrderBook(symbol, 20, 100, false, msgChan); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.SubscribeOrderBook(symbol1, 10, 100, false, msgChan); err != nil {
+		t.Fatal(err)
+	}
+	handleMsg(msgChan)
+}
+
 func TestBinance_SubscribeTicker(t *testing.T) {
 	if _, err := e.SubscribeTicker(symbol, msgChan); err == nil {
 		handleMsg(msgChan)
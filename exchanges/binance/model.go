@@ -64,11 +64,9 @@ func (o *OrderBook) update(bookData RawOrderBook) {
 	o.Bids = o.Bids.Update(bookData.Bids, true)
 	o.Asks = o.Asks.Update(bookData.Asks, false)
 	o.LastUpdateID = bookData.LastUpdateID
+	o.Timestamp = bookData.EventTime
 }
 
-// OrderBook of one symbol
-type SymbolOrderBook map[string]*OrderBook
-
 type Ticker struct {
 	Timestamp   float64 `json:"E" rest:"openTime"`
 	Symbol      string  `json:"s" rest:"symbol"`
@@ -136,6 +134,7 @@ type KLine struct {
 		High       string  `json:"h"`
 		Low        string  `json:"l"`
 		Volume     string  `json:"v"`
+		Interval   string  `json:"i"`
 		LIgnore    float64 `json:"L"`
 	} `json:"k"`
 }
@@ -194,10 +193,18 @@ type Order struct {
 	TransactionTime time.Duration `json:"T" fj:"T"  rest:"updateTime"    future:"updateTime"`     //Transaction time
 	Status          string        `json:"X" fj:"X"  rest:"status"        future:"status"`         //(NEW,CANCELED,TRADE,EXPIRED,REJECTED)
 	Positionside    string        `         fj:"ps"                      future:"positionSide"`
+	Fee             string        `json:"n" fj:"n"` //Commission amount of the last trade
+	FeeCurrency     string        `json:"N" fj:"N"` //Commission asset
+	IsMaker         bool          `json:"m" fj:"m"` //Is this trade the maker side?
+	MIgnore         bool          `json:"M"`
 	CIgnore         string        `json:"C" fj:"C"`
 	XIgnore         string        `json:"x" fj:"x"`
 	IIgnore         int           `json:"I" fj:"I"`
-	PIgnore         string        `json:"P" fj:"P"`
+	StopPrice       string        `json:"P" fj:"sp" rest:"stopPrice"     future:"stopPrice"`
+	WorkingType     string        `         fj:"wt"                      future:"workingType"` //(MARK_PRICE, CONTRACT_PRICE)
+	TimeInForce     string        `json:"f" fj:"f"  rest:"timeInForce"   future:"timeInForce"` //(GTC, IOC, FOK, GTX)
+	FIgnore         string        `json:"F"`
+	ReduceOnly      bool          `         fj:"R"                       future:"reduceOnly"`
 	QIgnore         string        `json:"Q" fj:"Q"`
 	TIgnore         int           `json:"t" fj:"t"`
 }
@@ -211,6 +218,8 @@ func (o Order) parseOrder(symbol string) wsex.Order {
 		Amount:          o.Amount,
 		Filled:          o.Filled,
 		Cost:            o.Cost,
+		Fee:             o.Fee,
+		FeeCurrency:     o.FeeCurrency,
 		Type:            "",
 		OrderType:       0,
 		CreateTime:      o.CreateTime,
@@ -234,11 +243,28 @@ func (o Order) parseOrder(symbol string) wsex.Order {
 			order.Side = wsex.Sell
 		}
 	}
-	switch o.Type {
-	case "LIMIT":
-		order.Type = wsex.LIMIT
-	case "MARKET":
-		order.Type = wsex.MARKET
+	if o.XIgnore == "TRADE" {
+		order.Liquidity = wsex.Taker
+		if o.IsMaker {
+			order.Liquidity = wsex.Maker
+		}
+	}
+	order.Type = parseOrderType(o.Type, o.Positionside != "")
+	switch {
+	case order.Type == wsex.LIMIT && o.Type == "LIMIT_MAKER", o.TimeInForce == "GTX":
+		order.OrderType = wsex.PostOnly
+	case o.TimeInForce == "IOC":
+		order.OrderType = wsex.IOC
+	case o.TimeInForce == "FOK":
+		order.OrderType = wsex.FOK
+	}
+	order.ReduceOnly = o.ReduceOnly
+	if order.Type.IsConditional() {
+		order.StopPrice = o.StopPrice
+		order.TriggerType = wsex.TriggerLast
+		if o.WorkingType == "MARK_PRICE" {
+			order.TriggerType = wsex.TriggerMark
+		}
 	}
 	switch o.Status {
 	case "NEW":
@@ -259,6 +285,66 @@ func (o Order) parseOrder(symbol string) wsex.Order {
 	return order
 }
 
+// parseOrderType TAKE_PROFIT is a market order of spot but a limit order of futures
+func parseOrderType(t string, isFuture bool) wsex.TradeType {
+	switch t {
+	case "LIMIT", "LIMIT_MAKER":
+		return wsex.LIMIT
+	case "MARKET":
+		return wsex.MARKET
+	case "STOP_LOSS", "STOP_MARKET":
+		return wsex.STOP_MARKET
+	case "STOP_LOSS_LIMIT", "STOP":
+		return wsex.STOP_LIMIT
+	case "TAKE_PROFIT":
+		if isFuture {
+			return wsex.TAKE_PROFIT_LIMIT
+		}
+		return wsex.TAKE_PROFIT_MARKET
+	case "TAKE_PROFIT_MARKET":
+		return wsex.TAKE_PROFIT_MARKET
+	case "TAKE_PROFIT_LIMIT":
+		return wsex.TAKE_PROFIT_LIMIT
+	case "TRAILING_STOP_MARKET":
+		return wsex.TRAILING_STOP
+	}
+	return wsex.TradeTypeUnKnown
+}
+
+func spotOrderType(t wsex.TradeType) string {
+	switch t {
+	case wsex.MARKET:
+		return "MARKET"
+	case wsex.STOP_MARKET, wsex.TRAILING_STOP:
+		return "STOP_LOSS"
+	case wsex.STOP_LIMIT:
+		return "STOP_LOSS_LIMIT"
+	case wsex.TAKE_PROFIT_MARKET:
+		return "TAKE_PROFIT"
+	case wsex.TAKE_PROFIT_LIMIT:
+		return "TAKE_PROFIT_LIMIT"
+	}
+	return "LIMIT"
+}
+
+func futureOrderType(t wsex.TradeType) string {
+	switch t {
+	case wsex.MARKET:
+		return "MARKET"
+	case wsex.STOP_MARKET:
+		return "STOP_MARKET"
+	case wsex.STOP_LIMIT:
+		return "STOP"
+	case wsex.TAKE_PROFIT_MARKET:
+		return "TAKE_PROFIT_MARKET"
+	case wsex.TAKE_PROFIT_LIMIT:
+		return "TAKE_PROFIT"
+	case wsex.TRAILING_STOP:
+		return "TRAILING_STOP_MARKET"
+	}
+	return "LIMIT"
+}
+
 type Balance struct {
 	Currency  string `json:"a" rest:"asset" future:"asset"`
 	Available string `json:"f" rest:"free" future:"availableBalance"`
@@ -286,32 +372,45 @@ type FuturePosition struct {
 	PositionInitialMargin  string `json:"positionInitialMargin"`  //持仓所需起始保证金
 	OpenOrderInitialMargin string `json:"openOrderInitialMargin"` //当前挂单所需起始保证金
 	Margin                 string `json:"maintMargin"`            //维持保证金
+	UnrealizedProfit       string `json:"unrealizedProfit"`       //未实现盈亏
+	Notional               string `json:"notional"`               //名义价值
 	Isolated               bool   `json:"isolated"`               //逐仓，全仓
 	Amount                 string `json:"positionAmt"`            //仓位数量
 	Side                   string `json:"positionSide"`           //开多，开空
 	Leverage               string `json:"leverage"`
+	UpdateTime             int64  `json:"updateTime"`
 }
 
-func (f *FuturePosition) ParserFuturePosition(coin, symbol string) (positions wsex.FuturePositons) {
+func (f *FuturePosition) ParserFuturePosition(coin, symbol string) (positions wsex.Position) {
 	positions.Coin = coin
 	positions.Symbol = symbol
-	positions.AvgPrice = f.AvgPrice
-	positions.Margin = f.Margin
+	positions.AvgPrice = SafeParseFloat(f.AvgPrice)
+	positions.Margin = SafeParseFloat(f.PositionInitialMargin)
+	positions.MaintainMargin = SafeParseFloat(f.Margin)
+	positions.UnrealizedPnl = SafeParseFloat(f.UnrealizedProfit)
+	positions.Notional = SafeParseFloat(f.Notional)
 	positions.Amount = SafeParseFloat(f.Amount)
 	positions.Leverage, _ = strconv.Atoi(f.Leverage)
+	positions.UpdateTime = time.Duration(f.UpdateTime)
 	if f.Isolated {
 		positions.MarginMode = wsex.FixedMargin
 	} else {
 		positions.MarginMode = wsex.CrossedMargin
 
 	}
-	switch f.Side {
+	positions.PositionType = parsePositionSide(f.Side)
+	return
+}
+
+// parsePositionSide BOTH of the one-way mode is PositionTypeUnKonwn
+func parsePositionSide(side string) wsex.PositionType {
+	switch side {
 	case "LONG":
-		positions.PositionType = wsex.PositionLong
+		return wsex.PositionLong
 	case "SHORT":
-		positions.PositionType = wsex.PositionShort
+		return wsex.PositionShort
 	}
-	return
+	return wsex.PositionTypeUnKonwn
 }
 
 //asset info
@@ -365,8 +464,9 @@ func (f FutureAccountInfo) parseAccountInfo() (accountInfo wsex.FutureAccountInf
 }
 
 type WsBP struct {
-	WsB []WsBalance  `json:"B"`
-	WsP []WsPosition `json:"P"`
+	Reason string       `json:"m"` //事件推出原因
+	WsB    []WsBalance  `json:"B"`
+	WsP    []WsPosition `json:"P"`
 }
 type WsBalance struct {
 	Currency  string `json:"a"`
@@ -384,61 +484,356 @@ func (w *WsBalance) parserWsBalance() wsex.Balance {
 }
 
 type WsPosition struct {
-	Symbol     string `json:"s"`
-	Amount     string `json:"pa"` //仓位数量
-	AvgPrice   string `json:"ep"` //开仓均价
-	Margin     string `json:"iw"` //保证金
-	MarginMode string `json:"mt"` //逐仓，全仓
-	Side       string `json:"ps"` //开多，开空
-}
-
-func (w *WsPosition) parserWsPosition(symbol string) wsex.FuturePositons {
-	future := wsex.FuturePositons{
-		Symbol:   symbol,
-		AvgPrice: w.AvgPrice,
-		Margin:   w.Margin,
-		Amount:   SafeParseFloat(w.Amount),
-	}
-
-	switch w.MarginMode {
-	case "isolated":
-		future.MarginMode = wsex.FixedMargin
-	default:
-		future.MarginMode = wsex.CrossedMargin
-	}
-	switch w.Side {
-	case "LONG":
-		future.PositionType = wsex.PositionLong
-	case "SHORT":
-		future.PositionType = wsex.PositionShort
-	default:
-		future.PositionType = wsex.PositionTypeUnKonwn
+	Symbol      string `json:"s"`
+	Amount      string `json:"pa"` //仓位数量
+	AvgPrice    string `json:"ep"` //开仓均价
+	RealizedPnl string `json:"cr"` //累计实现盈亏
+	Unrealized  string `json:"up"` //未实现盈亏
+	Margin      string `json:"iw"` //保证金
+	MarginMode  string `json:"mt"` //逐仓，全仓
+	Side        string `json:"ps"` //开多，开空
+}
+
+func (w *WsPosition) parserWsPosition(symbol string) wsex.Position {
+	future := wsex.Position{
+		Symbol:        symbol,
+		AvgPrice:      SafeParseFloat(w.AvgPrice),
+		Margin:        SafeParseFloat(w.Margin),
+		Amount:        SafeParseFloat(w.Amount),
+		RealizedPnl:   SafeParseFloat(w.RealizedPnl),
+		UnrealizedPnl: SafeParseFloat(w.Unrealized),
+		PositionType:  parsePositionSide(w.Side),
 	}
 
+	future.MarginMode = parseWsMarginMode(w.MarginMode)
 	return future
 }
 
+func parseWsMarginMode(marginType string) wsex.FutureMarginMode {
+	if strings.ToLower(marginType) == "isolated" {
+		return wsex.FixedMargin
+	}
+	return wsex.CrossedMargin
+}
+
 type WsBalances struct {
+	EventTime int64   `json:"E"`
 	Timestamp float64 `json:"T"`
 	Event     WsBP    `json:"a"`
 }
 
+// WsMarginCall 追加保证金通知
+type WsMarginCall struct {
+	EventTime          int64                  `json:"E"`
+	CrossWalletBalance string                 `json:"cw"` //全仓钱包余额
+	Positions          []WsMarginCallPosition `json:"p"`
+}
+
+type WsMarginCallPosition struct {
+	Symbol         string `json:"s"`
+	Side           string `json:"ps"` //持仓方向
+	Amount         string `json:"pa"` //仓位数量
+	MarginMode     string `json:"mt"` //逐仓，全仓
+	IsolatedWallet string `json:"iw"` //逐仓保证金
+	MarkPrice      string `json:"mp"` //标记价格
+	Unrealized     string `json:"up"` //未实现盈亏
+	MaintainMargin string `json:"mm"` //需要的维持保证金
+}
+
+func (w *WsMarginCallPosition) parsePosition(symbol string) wsex.Position {
+	return wsex.Position{
+		Symbol:         symbol,
+		PositionType:   parsePositionSide(w.Side),
+		MarginMode:     parseWsMarginMode(w.MarginMode),
+		Amount:         SafeParseFloat(w.Amount),
+		Margin:         SafeParseFloat(w.IsolatedWallet),
+		MarkPrice:      SafeParseFloat(w.MarkPrice),
+		UnrealizedPnl:  SafeParseFloat(w.Unrealized),
+		MaintainMargin: SafeParseFloat(w.MaintainMargin),
+	}
+}
+
 type MarkFundingRate struct {
-	Symbol          string `json:"symbol" ws:"s"`
-	MarkPrice       string `json:"markPrice" ws:"p"`
-	IndexPrice      string `json:"indexPrice" ws:"i"`
-	LastFundingRate string `json:"lastFundingRate" ws:"r"`
-	NextFundingTime int    `json:"nextFundingTime"`
-	PIgnore         string `ws:"P"`
+	Symbol               string `json:"symbol" ws:"s"`
+	MarkPrice            string `json:"markPrice" ws:"p"`
+	IndexPrice           string `json:"indexPrice" ws:"i"`
+	LastFundingRate      string `json:"lastFundingRate" ws:"r"`
+	NextFundingTime      int    `json:"nextFundingTime"`
+	EstimatedSettlePrice string `json:"estimatedSettlePrice" ws:"P"`
+	Time                 int64  `json:"time" ws:"E"`
+}
+
+// defaultFundingInterval the symbols not listed by /fapi/v1/fundingInfo fund every 8 hours
+const defaultFundingInterval = 8 * time.Hour
+
+// FundingInfo record of /fapi/v1/fundingInfo, only the symbols with adjusted funding parameters are listed
+type FundingInfo struct {
+	Symbol               string `json:"symbol"`
+	FundingIntervalHours int    `json:"fundingIntervalHours"`
+}
+
+// parseFundingRate interval is the funding interval of the symbol in ms
+func (m *MarkFundingRate) parseFundingRate(symbol string, interval time.Duration) wsex.FundingRate {
+	return wsex.FundingRate{
+		Symbol:        symbol,
+		Rate:          m.LastFundingRate,
+		MarkPrice:     m.MarkPrice,
+		IndexPrice:    m.IndexPrice,
+		Interval:      interval,
+		NextTimestamp: time.Duration(m.NextFundingTime),
+	}
+}
+
+// FundingRateHistory record of /fapi/v1/fundingRate
+type FundingRateHistory struct {
+	Symbol      string `json:"symbol"`
+	FundingRate string `json:"fundingRate"`
+	FundingTime int64  `json:"fundingTime"`
+}
+
+// parseFundingRate interval in ms is derived from the funding times of the records
+func (h FundingRateHistory) parseFundingRate(symbol string, interval time.Duration) wsex.FundingRate {
+	return wsex.FundingRate{
+		Symbol:    symbol,
+		Rate:      h.FundingRate,
+		Interval:  interval,
+		Timestamp: time.Duration(h.FundingTime),
+	}
+}
+
+// Income record of /fapi/v1/income
+type Income struct {
+	Symbol     string `json:"symbol"`
+	IncomeType string `json:"incomeType"`
+	Income     string `json:"income"`
+	Asset      string `json:"asset"`
+	Time       int64  `json:"time"`
+	TranID     int64  `json:"tranId"`
+}
+
+func (i Income) parseFundingPayment(symbol string) wsex.FundingPayment {
+	return wsex.FundingPayment{
+		ID:     strconv.FormatInt(i.TranID, 10),
+		Symbol: symbol,
+		Asset:  i.Asset,
+		Amount: SafeParseFloat(i.Income),
+		Time:   time.Duration(i.Time),
+	}
 }
 
 func (m *MarkFundingRate) parserMarkPrice(symbol string) wsex.MarkPrice {
 	return wsex.MarkPrice{
-		Symbol: symbol,
-		Price:  m.MarkPrice,
+		Symbol:               symbol,
+		Price:                m.MarkPrice,
+		IndexPrice:           m.IndexPrice,
+		EstimatedSettlePrice: m.EstimatedSettlePrice,
+		Timestamp:            time.Duration(m.Time),
+	}
+}
+
+func (m *MarkFundingRate) parseIndexPrice(symbol string) wsex.IndexPrice {
+	return wsex.IndexPrice{
+		Symbol:    symbol,
+		Price:     m.IndexPrice,
+		Timestamp: time.Duration(m.Time),
 	}
 }
 
 type DualSidePosition struct {
 	DaulSide bool `json:"dualSidePosition"`
 }
+
+// PositionRisk positions of /fapi/v2/positionRisk with the liquidation price
+type PositionRisk struct {
+	Symbol           string `json:"symbol"`
+	Leverage         string `json:"leverage"`
+	MarginType       string `json:"marginType"` // isolated, cross
+	PositionSide     string `json:"positionSide"`
+	PositionAmt      string `json:"positionAmt"`
+	EntryPrice       string `json:"entryPrice"`
+	MarkPrice        string `json:"markPrice"`
+	LiquidationPrice string `json:"liquidationPrice"`
+	IsolatedMargin   string `json:"isolatedMargin"`
+	UnRealizedProfit string `json:"unRealizedProfit"`
+	Notional         string `json:"notional"`
+	UpdateTime       int64  `json:"updateTime"`
+}
+
+func (r PositionRisk) parsePosition(coin, symbol string) (position wsex.Position) {
+	position.Coin = coin
+	position.Symbol = symbol
+	position.AvgPrice = SafeParseFloat(r.EntryPrice)
+	position.MarkPrice = SafeParseFloat(r.MarkPrice)
+	position.LiquidatePrice = SafeParseFloat(r.LiquidationPrice)
+	position.Margin = SafeParseFloat(r.IsolatedMargin)
+	position.UnrealizedPnl = SafeParseFloat(r.UnRealizedProfit)
+	position.Notional = SafeParseFloat(r.Notional)
+	position.Amount = SafeParseFloat(r.PositionAmt)
+	position.Leverage, _ = strconv.Atoi(r.Leverage)
+	position.UpdateTime = time.Duration(r.UpdateTime)
+	position.MarginMode = wsex.CrossedMargin
+	if strings.ToLower(r.MarginType) == "isolated" {
+		position.MarginMode = wsex.FixedMargin
+	}
+	position.PositionType = parsePositionSide(r.PositionSide)
+	return
+}
+
+// AdlQuantile of /fapi/v1/adlQuantile, the key is LONG, SHORT, BOTH or HEDGE
+type AdlQuantile struct {
+	Symbol      string         `json:"symbol"`
+	AdlQuantile map[string]int `json:"adlQuantile"`
+}
+
+// MarginHistory record of /fapi/v1/positionMargin/history, type 1:add 2:reduce
+type MarginHistory struct {
+	Symbol       string `json:"symbol"`
+	Amount       string `json:"amount"`
+	Asset        string `json:"asset"`
+	Type         int    `json:"type"`
+	PositionSide string `json:"positionSide"`
+	Time         int64  `json:"time"`
+}
+
+func (h MarginHistory) parseMarginChange(symbol string) wsex.MarginChange {
+	change := wsex.MarginChange{
+		Symbol:       symbol,
+		PositionType: wsex.PositionTypeUnKonwn,
+		Asset:        h.Asset,
+		Amount:       SafeParseFloat(h.Amount),
+		Time:         time.Duration(h.Time),
+	}
+	if h.Type == 2 {
+		change.Amount = -change.Amount
+	}
+	switch h.PositionSide {
+	case "LONG":
+		change.PositionType = wsex.PositionLong
+	case "SHORT":
+		change.PositionType = wsex.PositionShort
+	}
+	return change
+}
+
+type SymbolBrackets struct {
+	Symbol   string            `json:"symbol"`
+	Brackets []LeverageBracket `json:"brackets"`
+}
+
+type LeverageBracket struct {
+	Bracket          int     `json:"bracket"`
+	InitialLeverage  int     `json:"initialLeverage"`
+	NotionalCap      float64 `json:"notionalCap"`
+	NotionalFloor    float64 `json:"notionalFloor"`
+	MaintMarginRatio float64 `json:"maintMarginRatio"`
+}
+
+func (b LeverageBracket) parseBracket() wsex.LeverageBracket {
+	return wsex.LeverageBracket{
+		Bracket:          b.Bracket,
+		MaxLeverage:      b.InitialLeverage,
+		NotionalFloor:    b.NotionalFloor,
+		NotionalCap:      b.NotionalCap,
+		MaintMarginRatio: b.MaintMarginRatio,
+	}
+}
+
+type TradeFee struct {
+	Symbol          string `json:"symbol"`
+	MakerCommission string `json:"makerCommission" future:"makerCommissionRate"`
+	TakerCommission string `json:"takerCommission" future:"takerCommissionRate"`
+}
+
+func (f TradeFee) parseTradingFee(symbol string) wsex.TradingFee {
+	return wsex.TradingFee{
+		Symbol: symbol,
+		Maker:  SafeParseFloat(f.MakerCommission),
+		Taker:  SafeParseFloat(f.TakerCommission),
+	}
+}
+
+// flexInt the number may be quoted by binance, eg: the timestamp of /futures/data
+type flexInt int64
+
+func (i *flexInt) UnmarshalJSON(b []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	*i = flexInt(n)
+	return err
+}
+
+// OpenInterest of /fapi/v1/openInterest
+type OpenInterest struct {
+	Symbol       string  `json:"symbol"`
+	OpenInterest string  `json:"openInterest"`
+	Time         flexInt `json:"time"`
+}
+
+func (o OpenInterest) parseOpenInterest(symbol string) wsex.OpenInterest {
+	return wsex.OpenInterest{
+		Symbol:    symbol,
+		Amount:    SafeParseFloat(o.OpenInterest),
+		Timestamp: time.Duration(o.Time),
+	}
+}
+
+// OpenInterestHistory of /futures/data/openInterestHist
+type OpenInterestHistory struct {
+	Symbol               string  `json:"symbol"`
+	SumOpenInterest      string  `json:"sumOpenInterest"`
+	SumOpenInterestValue string  `json:"sumOpenInterestValue"`
+	Timestamp            flexInt `json:"timestamp"`
+}
+
+func (o OpenInterestHistory) parseOpenInterest(symbol string) wsex.OpenInterest {
+	return wsex.OpenInterest{
+		Symbol:    symbol,
+		Amount:    SafeParseFloat(o.SumOpenInterest),
+		Value:     SafeParseFloat(o.SumOpenInterestValue),
+		Timestamp: time.Duration(o.Timestamp),
+	}
+}
+
+// LongShortRatio of /futures/data/topLongShortPositionRatio
+type LongShortRatio struct {
+	Symbol         string  `json:"symbol"`
+	LongShortRatio string  `json:"longShortRatio"`
+	LongAccount    string  `json:"longAccount"`
+	ShortAccount   string  `json:"shortAccount"`
+	Timestamp      flexInt `json:"timestamp"`
+}
+
+func (r LongShortRatio) parseLongShortRatio(symbol string) wsex.LongShortRatio {
+	return wsex.LongShortRatio{
+		Symbol:    symbol,
+		Ratio:     SafeParseFloat(r.LongShortRatio),
+		Long:      SafeParseFloat(r.LongAccount),
+		Short:     SafeParseFloat(r.ShortAccount),
+		Timestamp: time.Duration(r.Timestamp),
+	}
+}
+
+// ForceOrder the forceOrder event, the latest liquidation order of the symbol in 1000ms
+type ForceOrder struct {
+	EventTime int64 `json:"E"`
+	Order     struct {
+		Symbol   string `json:"s"`
+		Side     string `json:"S"`
+		Price    string `json:"p"`
+		AvgPrice string `json:"ap"`
+		Amount   string `json:"q"`
+		Filled   string `json:"z"`
+		Time     int64  `json:"T"`
+	} `json:"o"`
+}
+
+func (f ForceOrder) parseLiquidation(symbol string) wsex.Liquidation {
+	return wsex.Liquidation{
+		Symbol:    symbol,
+		Side:      wsex.Side(f.Order.Side),
+		Price:     SafeParseFloat(f.Order.Price),
+		AvgPrice:  SafeParseFloat(f.Order.AvgPrice),
+		Amount:    SafeParseFloat(f.Order.Amount),
+		Filled:    SafeParseFloat(f.Order.Filled),
+		Timestamp: time.Duration(f.Order.Time),
+	}
+}
@@ -0,0 +1,302 @@
+/*
+@Time : 2021/6/17 10:20 上午
+@Author : shiguantian
+@File : userDataSession
+@Software: GoLand
+*/
+package binance
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/exchanges"
+)
+
+// listenKeyKeepAlive binance closes the listenKey which isn't kept alive in 60 minutes
+const listenKeyKeepAlive = time.Minute * 30
+
+// userDataSession the user data stream of one listenKey, shared by the balance, order, positions and account topics.
+// The listenKey is kept alive periodically, if it's failed or the listenKeyExpired event is received,
+// a new listenKey is created and the subscribers are moved to its stream, then the missed state is backfilled via rest:
+// the open orders, the orders created during the gap, and the tracked open orders which are closed during the gap.
+// The listenKey is deleted after the last subscriber unsubscribed.
+type userDataSession struct {
+	mu        sync.Mutex
+	mgr       *exchanges.ConnectionManager
+	listenKey string
+	stop      chan struct{}
+	symbols   map[string]struct{} // symbols of the order subscriptions, their open orders are backfilled
+	open      map[string]string   // the open orders pushed by the stream, key: order id, value: symbol
+	alive     time.Time           // the listenKey is created or kept alive, it's valid since then at least
+
+	url       func(listenKey string) string
+	connect   exchanges.ConnectFunc
+	create    func() (string, error)
+	keepAlive func(listenKey string) error
+	remove    func(listenKey string) error
+	onError   func(url string, err error)
+	// backfill publish the current state after the listenKey is renewed, nil if the rest api isn't available
+	backfill func(gap userDataGap, publish func(topic string, msg wsex.Message)) error
+}
+
+// userDataGap what the backfill needs to recover the updates missed while the listenKey was invalid
+type userDataGap struct {
+	symbols []string          // symbols of the order subscriptions
+	open    map[string]string // the open orders before the gap, key: order id, value: symbol
+	since   time.Duration     // the time in ms since when the events may be missed
+}
+
+// track keep the open orders pushed by the stream, the ones closed during the gap are fetched by the backfill
+func (s *userDataSession) track(order wsex.Order) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if order.Status == wsex.Open || order.Status == wsex.Partial {
+		if s.open == nil {
+			s.open = make(map[string]string)
+		}
+		s.open[order.ID] = order.Symbol
+	} else {
+		delete(s.open, order.ID)
+	}
+}
+
+// subscribe route the topic of the user data stream to sub, the stream is connected at the first subscription
+func (s *userDataSession) subscribe(topic, symbol string, sub wsex.MessageChan) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listenKey == "" {
+		listenKey, err := s.create()
+		if err != nil {
+			return "", err
+		}
+		if _, err := s.mgr.GetConnection(s.url(listenKey), s.connect); err != nil {
+			s.remove(listenKey)
+			return "", err
+		}
+		s.start(listenKey)
+	}
+	conn, err := s.mgr.GetConnection(s.url(s.listenKey), nil)
+	if err != nil {
+		return "", err
+	}
+	conn.Subscribe(topic, sub)
+	if symbol != "" {
+		if s.symbols == nil {
+			s.symbols = make(map[string]struct{})
+		}
+		s.symbols[symbol] = struct{}{}
+	}
+	return topic, nil
+}
+
+// unSubscribe remove the route of sub, the stream is closed and the listenKey is deleted if no subscriber remains
+func (s *userDataSession) unSubscribe(topic string, sub wsex.MessageChan) error {
+	s.mu.Lock()
+	if s.listenKey == "" {
+		s.mu.Unlock()
+		return nil
+	}
+	listenKey, url := s.listenKey, s.url(s.listenKey)
+	conn, err := s.mgr.GetConnection(url, nil)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	conn.UnSubscribe(topic, sub)
+	if conn.TopicCount() > 0 {
+		s.mu.Unlock()
+		return nil
+	}
+	s.reset()
+	s.open = nil
+	s.mgr.RemoveConnection(url)
+	s.mu.Unlock()
+
+	// the close handler is called synchronously, so close it without the lock
+	conn.Close()
+	return s.remove(listenKey)
+}
+
+// expired the listenKeyExpired event is received from the stream of url
+func (s *userDataSession) expired(url string) {
+	s.mu.Lock()
+	listenKey := s.listenKey
+	s.mu.Unlock()
+	if listenKey != "" && s.url(listenKey) == url {
+		s.renew(listenKey)
+	}
+}
+
+// closed the stream of url is closed and won't reconnect, the next subscription creates a new listenKey
+func (s *userDataSession) closed(url string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listenKey != "" && s.url(s.listenKey) == url {
+		s.reset()
+	}
+}
+
+// renew replace the invalid listenKey with a new one, nothing to do if it has been renewed
+func (s *userDataSession) renew(old string) {
+	s.mu.Lock()
+	current := s.listenKey
+	s.mu.Unlock()
+	if current != old {
+		return
+	}
+	// the rest request and the dial are done without the lock, so track isn't blocked in the read loop
+	oldUrl := s.url(old)
+	listenKey, err := s.create()
+	if err != nil {
+		s.onError(oldUrl, err)
+		return
+	}
+	if listenKey == old {
+		// binance returns the listenKey which is still valid
+		return
+	}
+	conn, err := s.mgr.GetConnection(s.url(listenKey), s.connect)
+	if err != nil {
+		s.remove(listenKey)
+		s.onError(oldUrl, err)
+		return
+	}
+	s.mu.Lock()
+	if s.listenKey != old {
+		// renewed or closed by another call meanwhile, binance may return the same new listenKey to both
+		renewed := s.listenKey == listenKey
+		s.mu.Unlock()
+		if renewed {
+			return
+		}
+		s.mgr.RemoveConnection(s.url(listenKey))
+		conn.Close()
+		s.remove(listenKey)
+		return
+	}
+	// the listenKey was valid when it was kept alive last time, the events after it may be missed
+	since := s.alive
+	oldConn, _ := s.mgr.GetConnection(oldUrl, nil)
+	if oldConn != nil {
+		oldConn.MoveRoutes(conn)
+		s.mgr.RemoveConnection(oldUrl)
+	}
+	s.reset()
+	s.start(listenKey)
+	gap := userDataGap{
+		symbols: make([]string, 0, len(s.symbols)),
+		open:    make(map[string]string, len(s.open)),
+		since:   time.Duration(since.UnixNano() / 1e6),
+	}
+	for symbol := range s.symbols {
+		gap.symbols = append(gap.symbols, symbol)
+	}
+	for id, symbol := range s.open {
+		gap.open[id] = symbol
+	}
+	s.mu.Unlock()
+
+	if oldConn != nil {
+		oldConn.Close()
+	}
+	s.remove(old)
+	if s.backfill != nil {
+		publish := func(topic string, msg wsex.Message) {
+			if order, ok := msg.Order(); ok {
+				s.track(order)
+			}
+			conn.Publish(topic, msg)
+		}
+		if err := s.backfill(gap, publish); err != nil {
+			s.onError(s.url(listenKey), err)
+		}
+	}
+}
+
+// start keep the listenKey alive until reset, must be called with lock
+func (s *userDataSession) start(listenKey string) {
+	s.listenKey = listenKey
+	s.alive = time.Now()
+	s.stop = make(chan struct{})
+	go s.keepAliveLoop(listenKey, s.stop)
+}
+
+// reset stop keeping the listenKey alive, must be called with lock
+func (s *userDataSession) reset() {
+	if s.listenKey == "" {
+		return
+	}
+	s.listenKey = ""
+	close(s.stop)
+}
+
+func (s *userDataSession) keepAliveLoop(listenKey string, stop chan struct{}) {
+	ticker := time.NewTicker(listenKeyKeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.keepAlive(listenKey); err != nil {
+				s.onError(s.url(listenKey), err)
+				s.renew(listenKey)
+				continue
+			}
+			s.mu.Lock()
+			if s.listenKey == listenKey {
+				s.alive = time.Now()
+			}
+			s.mu.Unlock()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// orderFetcher the rest api of the orders used by backfillOrders
+type orderFetcher struct {
+	open  func(symbol string, pageIndex, pageSize int) ([]wsex.Order, error)
+	since func(symbol string, since time.Duration) ([]wsex.Order, error)
+	order func(symbol, orderID string) (wsex.Order, error)
+}
+
+// backfillOrders publish the orders of the symbols created during the gap and the open ones,
+// then the tracked open orders which are closed during the gap. The last error is returned, the others are still backfilled
+func backfillOrders(gap userDataGap, fetcher orderFetcher, publish func(topic string, msg wsex.Message)) (err error) {
+	published := make(map[string]bool)
+	send := func(order wsex.Order) {
+		published[order.ID] = true
+		publish(topicOrder, wsex.Message{Type: wsex.MsgOrder, Data: order})
+	}
+	for _, symbol := range gap.symbols {
+		created, fetchErr := fetcher.since(symbol, gap.since)
+		if fetchErr != nil {
+			err = fetchErr
+		}
+		for _, order := range created {
+			send(order)
+		}
+		open, fetchErr := fetcher.open(symbol, 0, 0)
+		if fetchErr != nil {
+			err = fetchErr
+		}
+		for _, order := range open {
+			if !published[order.ID] {
+				send(order)
+			}
+		}
+	}
+	for id, symbol := range gap.open {
+		if published[id] {
+			continue
+		}
+		order, fetchErr := fetcher.order(symbol, id)
+		if fetchErr != nil {
+			err = fetchErr
+			continue
+		}
+		send(order)
+	}
+	return
+}
@@ -26,6 +26,9 @@ var AccountId int = 0
 
 func (e *CoinBaseRest) Init(option wsex.Options) {
 	e.Option = option
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = "coinbase"
+	}
 
 	if e.Option.RestHost == "" {
 		e.Option.RestHost = "https://api.pro.coinbase.com"
@@ -245,11 +248,31 @@ func (e *CoinBaseRest) CreateOrder(symbol string, price, amount float64, side ws
 	return
 }
 
+func (e *CoinBaseRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
 func (e *CoinBaseRest) CancelOrder(symbol, orderID string) (err error) {
 	err = wsex.ExError{Code: wsex.NotImplement}
 	return
 }
 
+func (e *CoinBaseRest) CreateOrders(requests []wsex.OrderRequest) (results []wsex.OrderResult, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *CoinBaseRest) CancelOrders(symbol string, orderIDs []string) (results []wsex.CancelResult, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *CoinBaseRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (result wsex.AmendResult, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
 func (e *CoinBaseRest) CancelAllOrders(symbol string) (err error) {
 	err = wsex.ExError{Code: wsex.NotImplement}
 	return
@@ -265,6 +288,21 @@ func (e *CoinBaseRest) FetchOpenOrders(symbol string, pageIndex, pageSize int) (
 	return
 }
 
+func (e *CoinBaseRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *CoinBaseRest) CancelConditionalOrder(symbol, orderID string) (err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *CoinBaseRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
 func (e *CoinBaseRest) Sign(access, method, function string, param url.Values, header http.Header) (request exchanges.Request) {
 	request.Headers = header
 	request.Method = method
@@ -3,12 +3,12 @@ package coinbase
 import (
 	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/book"
 	"github.com/shiguantian/wsex/exchanges"
 	"github.com/shiguantian/wsex/exchanges/websocket"
 )
@@ -24,7 +24,7 @@ type SubTopic struct {
 
 type CoinBaseWs struct {
 	exchanges.BaseExchange
-	orderBooks   map[string]*CoinBaseOrderBook
+	orderBooks   map[string]*book.Engine // key: ws url
 	subTopicInfo map[string]SubTopic
 	errors       map[int]wsex.ExError
 	loginLock    sync.Mutex
@@ -35,12 +35,18 @@ type CoinBaseWs struct {
 func (e *CoinBaseWs) Init(option wsex.Options) {
 	e.BaseExchange.Init()
 	e.Option = option
-	e.orderBooks = make(map[string]*CoinBaseOrderBook)
+	e.orderBooks = make(map[string]*book.Engine)
 	e.subTopicInfo = make(map[string]SubTopic)
 	e.errors = map[int]wsex.ExError{}
 	e.loginLock = sync.Mutex{}
 	e.loginChan = make(chan struct{})
 	e.isLogin = false
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = "coinbase"
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 	if e.Option.WsHost == "" {
 		e.Option.WsHost = "wss://ws-feed.pro.coinbase.com"
 	}
@@ -79,24 +85,27 @@ func (e *CoinBaseWs) SubscribeKLine(symbol string, t wsex.KLineType, sub wsex.Me
 }
 
 func (e *CoinBaseWs) UnSubscribe(event string, sub wsex.MessageChan) error {
-	conn, err := e.ConnectionMgr.GetConnection(e.Option.WsHost, nil)
+	conn, err := e.ConnectionMgr.FindShard(e.Option.WsHost, event)
 	if err != nil {
 		return err
 	}
+	if !conn.UnSubscribe(event, sub) {
+		return nil
+	}
 	tuple := strings.Split(event, "#")
-	data := make(map[string]interface{}, 3)
-	if len(tuple) == 2 {
-		data = map[string]interface{}{
-			"product_ids": []string{tuple[0]},
-			"type":        "subscribe",
-			"channels":    []string{tuple[1]},
-		}
+	if len(tuple) != 2 {
+		return nil
+	}
+	data := map[string]interface{}{
+		"product_ids": []string{tuple[0]},
+		"type":        "unsubscribe",
+		"channels":    []string{tuple[1]},
 	}
 	if err := conn.SendJsonMessage(data); err != nil {
 		return err
 	}
-	conn.UnSubscribe(sub)
-	return nil
+	// close the empty shards, the topics are not moved
+	return e.ConnectionMgr.Rebalance(e.Option.WsHost, nil)
 }
 
 func (e *CoinBaseWs) SubscribeBalance(symbol string, sub wsex.MessageChan) (string, error) {
@@ -125,10 +134,6 @@ func (e *CoinBaseWs) Connect(url string) (*exchanges.Connection, error) {
 }
 
 func (e *CoinBaseWs) subscribe(url, topic, symbol string, t wsex.MessageType, needLogin bool, sub wsex.MessageChan) (string, error) {
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
-	if err != nil {
-		return "", err
-	}
 	var data map[string]interface{}
 	if needLogin {
 
@@ -150,11 +155,21 @@ func (e *CoinBaseWs) subscribe(url, topic, symbol string, t wsex.MessageType, ne
 		}
 	}
 
-	if err := conn.SendJsonMessage(data); err != nil {
+	conn, err := e.ConnectionMgr.GetShard(url, topic, sub, e.Connect)
+	if err != nil {
+		return "", err
+	}
+	ack := e.SubscribeAck.Register(topic)
+	err = conn.SendJsonMessage(data)
+	if err != nil {
+		e.SubscribeAck.Done(topic, err)
+	} else {
+		err = e.WaitSubscribeAck(topic, ack)
+	}
+	if err != nil {
+		conn.UnSubscribe(topic, sub)
 		return "", err
 	}
-	conn.Subscribe(sub)
-
 	return topic, nil
 }
 
@@ -165,6 +180,18 @@ func (e *CoinBaseWs) messageHandler(url string, message []byte) {
 		return
 	}
 	switch res.Type {
+	case "subscriptions":
+		for _, channel := range res.Channels {
+			for _, id := range channel.ProductIDs {
+				e.SubscribeAck.Done(id+"#"+channel.Name, nil)
+			}
+		}
+	case "error":
+		// the error has no channel field, eg: {"type":"error","message":"Failed to subscribe","reason":"BTC-USDX is not a valid product"}
+		err := wsex.ExError{Code: wsex.ErrChannelNotExist, Message: fmt.Sprintf("%s, %s", res.Message, res.Reason)}
+		if !e.SubscribeAck.DoneMatch(func(topic string) bool { return strings.Contains(res.Reason, strings.Split(topic, "#")[0]) }, err) {
+			e.errorHandler(url, err)
+		}
 	case "ticker":
 		e.handleTicker(url, message)
 	case "match":
@@ -209,7 +236,7 @@ func (e *CoinBaseWs) handleTicker(url string, message []byte) {
 		return
 	}
 	ticker := data.parseTicker(market)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
+	e.ConnectionMgr.Publish(url, market.SymbolID+"#ticker", wsex.Message{Type: wsex.MsgTicker, Data: ticker})
 }
 
 func (e *CoinBaseWs) handleDepth(url string, message []byte, depthType string) {
@@ -224,33 +251,9 @@ func (e *CoinBaseWs) handleDepth(url string, message []byte, depthType string) {
 			e.errorHandler(url, fmt.Errorf("[coinBaseWs] handleDepth - find market by id error:%v", err))
 			return
 		}
-		symbolOrderBook, ok := e.orderBooks[market.Symbol]
-		if !ok {
-			symbolOrderBook = &CoinBaseOrderBook{}
-			e.orderBooks[market.Symbol] = symbolOrderBook
-		}
-		var asks wsex.Depth = wsex.Depth{}
-		for _, ask := range data.Asks {
-			depthItem, err := ask.ParseRawDepthItem()
-			if err != nil {
-				e.errorHandler(url, fmt.Errorf("[coinBaseWs] handleDepth - parse depth item error:%v", err))
-			}
-			asks = append(asks, depthItem)
+		for _, msg := range e.orderBook(url).Snapshot(market.Symbol, book.Snapshot{Bids: data.Bids, Asks: data.Asks}) {
+			e.ConnectionMgr.Publish(url, market.SymbolID+"#level2", msg)
 		}
-		var bids wsex.Depth = wsex.Depth{}
-		for _, bid := range data.Bids {
-			depthItem, err := bid.ParseRawDepthItem()
-			if err != nil {
-				e.errorHandler(url, fmt.Errorf("[coinBaseWs] handleDepth - parse depth item error:%v", err))
-			}
-			bids = append(bids, depthItem)
-		}
-		sort.Sort(asks)
-		sort.Sort(sort.Reverse(bids))
-		symbolOrderBook.Asks = asks
-		symbolOrderBook.Bids = bids
-		symbolOrderBook.Symbol = market.Symbol
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: symbolOrderBook.OrderBook})
 	} else {
 		var data WsOrderBookUpdateRes
 		if err := json.Unmarshal(message, &data); err != nil {
@@ -262,11 +265,6 @@ func (e *CoinBaseWs) handleDepth(url string, message []byte, depthType string) {
 			e.errorHandler(url, fmt.Errorf("[coinBaseWs] handleDepth - find market by id error:%v", err))
 			return
 		}
-		symbolOrderBook, ok := e.orderBooks[market.Symbol]
-		if !ok {
-			e.errorHandler(url, fmt.Errorf("[coinBaseWs] handleDepth - find cache orderbook err:%v", err))
-			return
-		}
 		var changeDepth OrderBookRes = OrderBookRes{
 			Asks: wsex.RawDepth{},
 			Bids: wsex.RawDepth{},
@@ -282,10 +280,24 @@ func (e *CoinBaseWs) handleDepth(url string, message []byte, depthType string) {
 				})
 			}
 		}
-		symbolOrderBook.update(changeDepth)
+		for _, msg := range e.orderBook(url).Update(market.Symbol, book.Delta{Bids: changeDepth.Bids, Asks: changeDepth.Asks}) {
+			e.ConnectionMgr.Publish(url, market.SymbolID+"#level2", msg)
+		}
+	}
+}
 
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: symbolOrderBook.OrderBook})
+// orderBook the local order books of the connection, the snapshot is pushed by websocket
+func (e *CoinBaseWs) orderBook(url string) *book.Engine {
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	engine, ok := e.orderBooks[url]
+	if !ok {
+		engine = book.NewEngine(nil)
+		engine.SetDepth(e.Option.OrderBookDepth)
+		engine.SetMetrics(e.Option.ExchangeName, e.Option.Metrics)
+		e.orderBooks[url] = engine
 	}
+	return engine
 }
 
 func (e *CoinBaseWs) handleTrade(url string, message []byte) {
@@ -300,5 +312,5 @@ func (e *CoinBaseWs) handleTrade(url string, message []byte) {
 		return
 	}
 	trade := data.parseTrade(market)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: trade})
+	e.ConnectionMgr.Publish(url, market.SymbolID+"#matches", wsex.Message{Type: wsex.MsgTrade, Data: trade})
 }
@@ -11,6 +11,14 @@ import (
 
 type Response struct {
 	Type string `json:"type"`
+
+	// the current subscriptions, pushed after subscribe successfully
+	Channels []struct {
+		Name       string   `json:"name"`
+		ProductIDs []string `json:"product_ids"`
+	} `json:"channels"`
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
 }
 
 type OrderBookRes struct {
@@ -114,14 +122,6 @@ type Market struct {
 }
 type SymbolListRes []Market
 
-type OrderBook struct {
-	wsex.OrderBook
-	SeqNum     float64
-	PrevSeqNum float64
-}
-
-type SymbolOrderBook map[string]*OrderBook
-
 type WsTickerRes struct {
 	Symbol        string `json:"product_id"`
 	Price         string `json:"price"`
@@ -179,15 +179,6 @@ type WsOrderBookUpdateRes struct {
 	Changes [][]string `json:"changes"`
 }
 
-type CoinBaseOrderBook struct {
-	wsex.OrderBook
-}
-
-func (o *CoinBaseOrderBook) update(bookData OrderBookRes) {
-	o.Bids = o.Bids.Update(bookData.Bids, true)
-	o.Asks = o.Asks.Update(bookData.Asks, false)
-}
-
 func parseTime(t string) (duration time.Duration) {
 	timeTuple := strings.Split(t, ".")
 	if len(timeTuple) == 2 {
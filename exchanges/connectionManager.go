@@ -8,7 +8,10 @@ package exchanges
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/shiguantian/wsex"
 
@@ -19,52 +22,281 @@ import (
 type ConnectFunc func(url string) (*Connection, error)
 type Connection struct {
 	websocket.WsConn
-	MsgChannels set.Set
+	mu       sync.RWMutex
+	routes   map[string]set.Set // key: topic, value: subscribers of the topic
+	exchange string             // stamped on the messages, set by ConnectionManager
+	metrics  wsex.Metrics
+
+	sendMu   sync.Mutex
+	inflight map[wsex.MessageChan]int // the messages being sent to the subscriber, blocked if it's slow
 }
 
 func NewConnection() *Connection {
 	return &Connection{
-		MsgChannels: set.NewSet(),
+		routes: make(map[string]set.Set),
+	}
+}
+
+// Subscribe route the messages of the topic to msgChan
+func (c *Connection) Subscribe(topic string, msgChan wsex.MessageChan) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	subs, ok := c.routes[topic]
+	if !ok {
+		subs = set.NewSet()
+		c.routes[topic] = subs
+	}
+	subs.Add(msgChan)
+}
+
+// UnSubscribe remove the route of the topic to msgChan, returns true if the topic has no subscriber any more
+func (c *Connection) UnSubscribe(topic string, msgChan wsex.MessageChan) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	subs, ok := c.routes[topic]
+	if !ok {
+		return true
+	}
+	subs.Remove(msgChan)
+	if subs.Cardinality() == 0 {
+		delete(c.routes, topic)
+		return true
 	}
+	return false
 }
 
-func (c *Connection) Subscribe(msgChan wsex.MessageChan) {
-	c.MsgChannels.Add(msgChan)
+// HasTopic whether the topic is subscribed on the connection
+func (c *Connection) HasTopic(topic string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.routes[topic]
+	return ok
 }
 
-func (c *Connection) UnSubscribe(msgChan wsex.MessageChan) {
-	c.MsgChannels.Remove(msgChan)
+// TopicCount the count of the subscribed topics of the connection
+func (c *Connection) TopicCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.routes)
+}
+
+// Topics the subscribed topics of the connection
+func (c *Connection) Topics() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	topics := make([]string, 0, len(c.routes))
+	for topic := range c.routes {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// MoveRoutes move the routes of the connection to dst, eg: the stream is renewed with a new url
+func (c *Connection) MoveRoutes(dst *Connection) {
+	c.mu.Lock()
+	routes := c.routes
+	c.routes = make(map[string]set.Set)
+	c.mu.Unlock()
+
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	for topic, subs := range routes {
+		if old, ok := dst.routes[topic]; ok {
+			subs = old.Union(subs)
+		}
+		dst.routes[topic] = subs
+	}
+}
+
+// moveTopic move the route of the topic to dst
+func (c *Connection) moveTopic(topic string, dst *Connection) {
+	c.mu.Lock()
+	subs, ok := c.routes[topic]
+	delete(c.routes, topic)
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	if old, ok := dst.routes[topic]; ok {
+		subs = old.Union(subs)
+	}
+	dst.routes[topic] = subs
 }
 
 func (c *Connection) Close() {
 	c.WsConn.Close()
 }
 
-func (c *Connection) Publish(msg wsex.Message, clear bool) {
-	tmp := c.MsgChannels
+// RecvTime the receive time in ms of the frame being handled, it's only valid in the message handler
+func (c *Connection) RecvTime() time.Duration {
+	if recv := c.WsConn.RecvTime(); !recv.IsZero() {
+		return millis(recv)
+	}
+	return 0
+}
+
+// Publish send the message to the subscribers of the topic only
+func (c *Connection) Publish(topic string, msg wsex.Message) {
+	c.mu.RLock()
+	subs, ok := c.routes[topic]
+	c.mu.RUnlock()
+	if ok {
+		msg, metrics := c.stamp(msg, true)
+		depth := c.send(subs, msg)
+		if metrics != nil {
+			metrics.MessagePublished(msg.Exchange, msg.Type.String(), depth)
+		}
+	}
+}
+
+// Broadcast send the message to every subscriber of the connection once, eg: reconnected, closed
+func (c *Connection) Broadcast(msg wsex.Message, clear bool) {
+	c.mu.Lock()
+	subs := set.NewSet()
+	for _, s := range c.routes {
+		subs = subs.Union(s)
+	}
 	if clear {
-		c.MsgChannels = set.NewSet()
+		c.routes = make(map[string]set.Set)
+	}
+	c.mu.Unlock()
+	msg, _ = c.stamp(msg, false)
+	c.send(subs, msg)
+}
+
+// stamp fill the common fields which are not set by the exchange, and observe the latency of the message
+func (c *Connection) stamp(msg wsex.Message, observe bool) (wsex.Message, wsex.Metrics) {
+	c.mu.RLock()
+	exchange, metrics := c.exchange, c.metrics
+	c.mu.RUnlock()
+	if msg.Exchange == "" {
+		msg.Exchange = exchange
+	}
+	now, recv := time.Now(), c.WsConn.RecvTime()
+	if recv.IsZero() {
+		// not published by the message handler
+		recv = now
+	}
+	if msg.RecvTime == 0 {
+		msg.RecvTime = millis(recv)
 	}
-	tmp.Each(func(item interface{}) bool {
+	msg.PublishTime = millis(now)
+	msg.Complete()
+
+	if observe && metrics != nil {
+		channel := msg.Type.String()
+		// the time which isn't in ms can't be compared, eg: in seconds
+		if msg.Time > minMillis {
+			metrics.ObserveLatency(msg.Exchange, channel, wsex.LatencyFeed, (msg.RecvTime-msg.Time)*time.Millisecond)
+		}
+		metrics.ObserveLatency(msg.Exchange, channel, wsex.LatencyProcess, now.Sub(recv))
+	}
+	return msg, metrics
+}
+
+func (c *Connection) setStamp(exchange string, metrics wsex.Metrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.exchange, c.metrics = exchange, metrics
+}
+
+// minMillis 2001-09-09 in ms, the smaller timestamp of the exchange isn't in ms
+const minMillis = time.Duration(1e12)
+
+// millis the timestamp in ms, same as the timestamps of the exchanges
+func millis(t time.Time) time.Duration {
+	return time.Duration(t.UnixNano() / 1e6)
+}
+
+// send returns the max queued messages of the subscribers, the buffered ones and the ones being sent,
+// the slow subscriber makes it grow
+func (c *Connection) send(subs set.Set, msg wsex.Message) int {
+	var depth int
+	subs.Each(func(item interface{}) bool {
 		msgChan, ok := item.(wsex.MessageChan)
 		if ok && msgChan != nil {
+			if n := len(msgChan) + c.sending(msgChan, 1); n > depth {
+				depth = n
+			}
 			//must use go routine here, otherwise the "Each" method may be blocked, caused dead lock if someone call Subscribe/UnSubscribe at same time.
-			go func() { msgChan <- msg }()
+			go func() {
+				msgChan <- msg
+				c.sending(msgChan, -1)
+			}()
 		}
 		return false
 	})
+	return depth
+}
+
+// sending add delta to the messages being sent to the subscriber, returns the count after added
+func (c *Connection) sending(msgChan wsex.MessageChan, delta int) int {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.inflight == nil {
+		c.inflight = make(map[wsex.MessageChan]int)
+	}
+	n := c.inflight[msgChan] + delta
+	if n <= 0 {
+		delete(c.inflight, msgChan)
+		return 0
+	}
+	c.inflight[msgChan] = n
+	return n
 }
 
 type ConnectionManager struct {
 	sync.RWMutex
-	once  sync.Once
-	conns map[string]*Connection // key: ws url
+	once      sync.Once
+	conns     map[string]*Connection // key: ws url, the other shards of the url are keyed by url#n
+	maxTopics int                    // max topics of one connection, 0 means unlimited
+	exchange  string
+	metrics   wsex.Metrics
+	rebalance sync.Mutex // serialize the rebalancing, the moves are done without the lock of the manager
+}
+
+// ShardUrl the key of the nth connection of the url, the first one is the url itself.
+// the fragment is ignored when dialing, so all shards connect to the same endpoint
+func ShardUrl(url string, n int) string {
+	if n == 0 {
+		return url
+	}
+	return fmt.Sprintf("%s#%d", url, n)
 }
 
 func NewConnectionManager() *ConnectionManager {
 	return &ConnectionManager{conns: make(map[string]*Connection)}
 }
 
+// SetMaxTopics set the max topics of one connection, the topics over the limit are placed on another connection of the same url
+func (c *ConnectionManager) SetMaxTopics(n int) {
+	c.Lock()
+	defer c.Unlock()
+	c.maxTopics = n
+}
+
+// SetExchange set the exchange name stamped on the messages of the connections
+func (c *ConnectionManager) SetExchange(name string) {
+	c.Lock()
+	defer c.Unlock()
+	c.exchange = name
+	for _, conn := range c.conns {
+		conn.setStamp(c.exchange, c.metrics)
+	}
+}
+
+// SetMetrics set the collector of the latency of the messages
+func (c *ConnectionManager) SetMetrics(metrics wsex.Metrics) {
+	c.Lock()
+	defer c.Unlock()
+	c.metrics = metrics
+	for _, conn := range c.conns {
+		conn.setStamp(c.exchange, c.metrics)
+	}
+}
+
 func (c *ConnectionManager) SetConnection(url string, connection *Connection) {
 	c.Lock()
 	defer c.Unlock()
@@ -72,7 +304,16 @@ func (c *ConnectionManager) SetConnection(url string, connection *Connection) {
 	if ok {
 		conn.Close()
 	}
-	c.conns[url] = connection
+	c.add(url, connection)
+}
+
+// add must be called with lock
+func (c *ConnectionManager) add(key string, conn *Connection) {
+	conn.setStamp(c.exchange, c.metrics)
+	c.conns[key] = conn
+	if c.metrics != nil {
+		c.metrics.ConnectionEvent(c.exchange, key, wsex.ConnConnected, "")
+	}
 }
 
 func (c *ConnectionManager) RemoveConnection(url string) {
@@ -101,7 +342,7 @@ func (c *ConnectionManager) GetConnection(url string, connectFunc ConnectFunc) (
 			if err != nil {
 				return nil, err
 			}
-			c.conns[url] = conn
+			c.add(url, conn)
 			return conn, nil
 		}
 		return nil, fmt.Errorf("not found websocket session, url:%s", url)
@@ -109,17 +350,247 @@ func (c *ConnectionManager) GetConnection(url string, connectFunc ConnectFunc) (
 	return conn, nil
 }
 
-func (c *ConnectionManager) Publish(url string, message wsex.Message) {
+// shards the connections of the url, must be called with lock
+func (c *ConnectionManager) shards(url string) []*Connection {
+	var shards []*Connection
+	for key, conn := range c.conns {
+		if key == url || strings.HasPrefix(key, url+"#") {
+			shards = append(shards, conn)
+		}
+	}
+	return shards
+}
+
+// GetShard get the connection to subscribe the topic of url, and route the topic to sub on it.
+// the connection which has subscribed the topic is reused, otherwise the one with the fewest topics under the limit is chosen,
+// a new connection is created if all are full. The route is added here to reserve the place, so remove it if the subscription failed.
+func (c *ConnectionManager) GetShard(url, topic string, sub wsex.MessageChan, connectFunc ConnectFunc) (*Connection, error) {
+	c.Lock()
+	defer c.Unlock()
+	var (
+		shards = c.shards(url)
+		best   *Connection
+	)
+	for _, conn := range shards {
+		if conn.HasTopic(topic) {
+			best = conn
+			break
+		}
+		count := conn.TopicCount()
+		if c.maxTopics > 0 && count >= c.maxTopics {
+			continue
+		}
+		if best == nil || count < best.TopicCount() {
+			best = conn
+		}
+	}
+	if best == nil {
+		if connectFunc == nil {
+			return nil, fmt.Errorf("not found websocket session, url:%s", url)
+		}
+		key := url
+		for n := 1; c.conns[key] != nil; n++ {
+			key = ShardUrl(url, n)
+		}
+		conn, err := connectFunc(key)
+		if err != nil {
+			return nil, err
+		}
+		c.add(key, conn)
+		best = conn
+	}
+	best.Subscribe(topic, sub)
+	return best, nil
+}
+
+// MoveFunc subscribe the topic on the connection to and unsubscribe it on the connection from by the protocol of the exchange,
+// the route of the topic has been moved to the connection to when it's called, and is moved back if it fails
+type MoveFunc func(topic string, from, to *Connection) error
+
+type shardMove struct {
+	topic    string
+	from, to string // keys of the shards
+}
+
+// shardKeys the keys of the shards of url, the first one is url itself, must be called with lock
+func (c *ConnectionManager) shardKeys(url string) []string {
+	var keys []string
+	for key := range c.conns {
+		if key == url || strings.HasPrefix(key, url+"#") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i] == url || keys[j] == url {
+			return keys[i] == url
+		}
+		return len(keys[i]) < len(keys[j]) || len(keys[i]) == len(keys[j]) && keys[i] < keys[j]
+	})
+	return keys
+}
+
+// planRebalance the moves of the topics over the limit and of the extra shards whose topics fit into the others, must be called with lock
+func (c *ConnectionManager) planRebalance(keys []string) []shardMove {
+	topics := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		topics[key] = c.conns[key].Topics()
+		sort.Strings(topics[key])
+	}
+	free := func(key string) int {
+		if c.maxTopics <= 0 {
+			return int(^uint(0) >> 1)
+		}
+		return c.maxTopics - len(topics[key])
+	}
+	// target the shard with the most free place except the excluded ones
+	target := func(exclude map[string]bool) string {
+		var best string
+		for _, key := range keys {
+			if exclude[key] || free(key) <= 0 {
+				continue
+			}
+			if best == "" || free(key) > free(best) {
+				best = key
+			}
+		}
+		return best
+	}
+	var moves []shardMove
+	move := func(from, to string) {
+		n := len(topics[from]) - 1
+		moves = append(moves, shardMove{topic: topics[from][n], from: from, to: to})
+		topics[to] = append(topics[to], topics[from][n])
+		topics[from] = topics[from][:n]
+	}
+	// the topics over the limit, eg: the limit is lowered
+	for _, key := range keys {
+		for free(key) < 0 {
+			to := target(map[string]bool{key: true})
+			if to == "" {
+				break
+			}
+			move(key, to)
+		}
+	}
+	// drain the extra shards with the fewest topics first, the first shard is kept
+	extras := append([]string{}, keys[1:]...)
+	sort.SliceStable(extras, func(i, j int) bool { return len(topics[extras[i]]) < len(topics[extras[j]]) })
+	drained := make(map[string]bool)
+	for _, key := range extras {
+		if len(topics[key]) == 0 {
+			drained[key] = true
+			continue
+		}
+		drained[key] = true
+		if c.maxTopics > 0 {
+			space := 0
+			for _, other := range keys {
+				if !drained[other] {
+					space += free(other)
+				}
+			}
+			if space < len(topics[key]) {
+				delete(drained, key)
+				continue
+			}
+		}
+		for len(topics[key]) > 0 {
+			move(key, target(drained))
+		}
+	}
+	return moves
+}
+
+// Rebalance compact the shards of url after unsubscribed or reconnected:
+// the topics over the limit are moved to the shards with free place, the extra shards whose topics fit into the others are drained,
+// then the empty extra shards are closed. The topics are not moved if move is nil, only the empty extra shards are closed.
+// the first failed move stops the rebalancing and is returned
+func (c *ConnectionManager) Rebalance(url string, move MoveFunc) error {
+	c.rebalance.Lock()
+	defer c.rebalance.Unlock()
+	c.RLock()
+	keys := c.shardKeys(url)
+	var moves []shardMove
+	if move != nil && len(keys) > 1 {
+		moves = c.planRebalance(keys)
+	}
+	c.RUnlock()
+
+	// the moves are done without lock, the exchange may wait for the ack which is published by the manager
+	for _, m := range moves {
+		from, err := c.GetConnection(m.from, nil)
+		if err != nil {
+			return err
+		}
+		to, err := c.GetConnection(m.to, nil)
+		if err != nil {
+			return err
+		}
+		from.moveTopic(m.topic, to)
+		if err := move(m.topic, from, to); err != nil {
+			to.moveTopic(m.topic, from)
+			return err
+		}
+	}
+
+	var empty []*Connection
+	c.Lock()
+	for _, key := range c.shardKeys(url) {
+		if key != url && c.conns[key].TopicCount() == 0 {
+			empty = append(empty, c.conns[key])
+			delete(c.conns, key)
+		}
+	}
+	c.Unlock()
+	// the close handler is called synchronously, so close them without lock
+	for _, conn := range empty {
+		conn.Close()
+	}
+	return nil
+}
+
+// FindShard get the connection of url which has subscribed the topic
+func (c *ConnectionManager) FindShard(url, topic string) (*Connection, error) {
+	c.RLock()
+	defer c.RUnlock()
+	for _, conn := range c.shards(url) {
+		if conn.HasTopic(topic) {
+			return conn, nil
+		}
+	}
+	return nil, fmt.Errorf("not found websocket session of topic %s, url:%s", topic, url)
+}
+
+// Publish send the message to the subscribers of the topic on the connection of url
+func (c *ConnectionManager) Publish(url, topic string, message wsex.Message) {
+	conn, _ := c.GetConnection(url, nil)
+	if conn != nil {
+		conn.Publish(topic, message)
+	}
+}
+
+// RecvTime the receive time in ms of the frame being handled on the connection of url, 0 if it's unknown.
+// it's only valid in the message handler, eg: set to book.Delta.RecvTime
+func (c *ConnectionManager) RecvTime(url string) time.Duration {
+	conn, _ := c.GetConnection(url, nil)
+	if conn == nil {
+		return 0
+	}
+	return conn.RecvTime()
+}
+
+// Broadcast send the message to all subscribers of the connection of url
+func (c *ConnectionManager) Broadcast(url string, message wsex.Message) {
 	conn, _ := c.GetConnection(url, nil)
 	if conn != nil {
-		conn.Publish(message, false)
+		conn.Broadcast(message, false)
 	}
 }
 
-// PublishAfterClear clear the subscribers and notify them
-func (c *ConnectionManager) PublishAfterClear(url string, message wsex.Message) {
+// BroadcastAfterClear clear the subscribers and notify them
+func (c *ConnectionManager) BroadcastAfterClear(url string, message wsex.Message) {
 	conn, _ := c.GetConnection(url, nil)
 	if conn != nil {
-		conn.Publish(message, true)
+		conn.Broadcast(message, true)
 	}
 }
@@ -0,0 +1,316 @@
+/*
+@Time : 2021/6/8 10:12 上午
+@Author : shiguantian
+@File : connectionManager_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+func recv(ch wsex.MessageChan) (wsex.Message, bool) {
+	select {
+	case msg := <-ch:
+		return msg, true
+	case <-time.After(100 * time.Millisecond):
+		return wsex.Message{}, false
+	}
+}
+
+func TestConnection_Publish(t *testing.T) {
+	conn := NewConnection()
+	btc, eth := make(wsex.MessageChan, 1), make(wsex.MessageChan, 1)
+	conn.Subscribe("btcusdt@ticker", btc)
+	conn.Subscribe("ethusdt@ticker", eth)
+
+	conn.Publish("btcusdt@ticker", wsex.Message{Type: wsex.MsgTicker})
+	if _, ok := recv(btc); !ok {
+		t.Fatal("btc subscriber should receive the message")
+	}
+	if _, ok := recv(eth); ok {
+		t.Fatal("eth subscriber should not receive btc message")
+	}
+}
+
+func TestConnection_UnSubscribe(t *testing.T) {
+	conn := NewConnection()
+	a, b := make(wsex.MessageChan, 1), make(wsex.MessageChan, 1)
+	conn.Subscribe("btcusdt@trade", a)
+	conn.Subscribe("btcusdt@trade", b)
+	conn.Subscribe("btcusdt@ticker", a)
+
+	if conn.UnSubscribe("btcusdt@trade", a) {
+		t.Fatal("topic still has subscriber b")
+	}
+	conn.Publish("btcusdt@trade", wsex.Message{Type: wsex.MsgTrade})
+	if _, ok := recv(a); ok {
+		t.Fatal("a should not receive trade after unsubscribe")
+	}
+	if _, ok := recv(b); !ok {
+		t.Fatal("b should still receive trade")
+	}
+	if !conn.UnSubscribe("btcusdt@trade", b) {
+		t.Fatal("topic should have no subscriber")
+	}
+	if len(conn.Topics()) != 1 {
+		t.Fatalf("unexpected topics %v", conn.Topics())
+	}
+}
+
+func TestConnection_Broadcast(t *testing.T) {
+	conn := NewConnection()
+	a := make(wsex.MessageChan, 2)
+	conn.Subscribe("btcusdt@trade", a)
+	conn.Subscribe("btcusdt@ticker", a)
+
+	conn.Broadcast(wsex.ReConnectedMessage, true)
+	if _, ok := recv(a); !ok {
+		t.Fatal("subscriber should receive broadcast")
+	}
+	if _, ok := recv(a); ok {
+		t.Fatal("broadcast should be sent once per subscriber")
+	}
+	if len(conn.Topics()) != 0 {
+		t.Fatal("routes should be cleared")
+	}
+}
+
+func TestConnection_MoveRoutes(t *testing.T) {
+	src, dst := NewConnection(), NewConnection()
+	a, b := make(wsex.MessageChan, 1), make(wsex.MessageChan, 1)
+	src.Subscribe("order", a)
+	dst.Subscribe("order", b)
+	src.Subscribe("balance", a)
+
+	src.MoveRoutes(dst)
+	if len(src.Topics()) != 0 {
+		t.Fatal("routes of the source should be cleared")
+	}
+	dst.Publish("order", wsex.Message{Type: wsex.MsgOrder})
+	if _, ok := recv(a); !ok {
+		t.Fatal("moved subscriber should receive order")
+	}
+	if _, ok := recv(b); !ok {
+		t.Fatal("existing subscriber should still receive order")
+	}
+	if !dst.HasTopic("balance") {
+		t.Fatal("balance route should be moved")
+	}
+}
+
+func TestConnectionManager_GetShard(t *testing.T) {
+	mgr := NewConnectionManager()
+	mgr.SetMaxTopics(2)
+	var dialed []string
+	connect := func(url string) (*Connection, error) {
+		dialed = append(dialed, url)
+		return NewConnection(), nil
+	}
+	url := "wss://stream.binance.com:9443/stream"
+	sub := make(wsex.MessageChan, 1)
+	topics := []string{"btcusdt@ticker", "ethusdt@ticker", "ltcusdt@ticker"}
+	conns := make([]*Connection, len(topics))
+	for i, topic := range topics {
+		conn, err := mgr.GetShard(url, topic, sub, connect)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns[i] = conn
+	}
+	if conns[0] != conns[1] || conns[1] == conns[2] {
+		t.Fatal("the third topic should be placed on a new connection")
+	}
+	if len(dialed) != 2 || dialed[0] != url || dialed[1] != ShardUrl(url, 1) {
+		t.Fatalf("unexpected dialed urls %v", dialed)
+	}
+
+	// the topic already subscribed reuses its connection even if it is full
+	conn, err := mgr.GetShard(url, "ethusdt@ticker", make(wsex.MessageChan, 1), connect)
+	if err != nil || conn != conns[1] {
+		t.Fatal("the subscribed topic should reuse its connection")
+	}
+	if conn, err := mgr.FindShard(url, "ltcusdt@ticker"); err != nil || conn != conns[2] {
+		t.Fatal("ltcusdt@ticker should be found on the second connection")
+	}
+	if _, err := mgr.FindShard(url, "xrpusdt@ticker"); err == nil {
+		t.Fatal("the topic not subscribed should not be found")
+	}
+
+	if conn, _ := mgr.GetShard(url, "adausdt@ticker", sub, connect); conn != conns[2] {
+		t.Fatal("the topic should be placed on the connection with fewer topics")
+	}
+	// the freed place is reused before creating another connection
+	conns[0].UnSubscribe("btcusdt@ticker", sub)
+	if conn, _ := mgr.GetShard(url, "xrpusdt@ticker", sub, connect); conn != conns[0] || len(dialed) != 2 {
+		t.Fatal("the topic should be placed on the connection with free place")
+	}
+}
+
+func TestConnectionManager_Stamp(t *testing.T) {
+	mgr := NewConnectionManager()
+	mgr.SetExchange("binance")
+	conn, _ := mgr.GetConnection("wss://stream.binance.com:9443/stream", func(url string) (*Connection, error) {
+		return NewConnection(), nil
+	})
+	handler := wsex.NewHandler()
+	trades := make(chan wsex.TradeEvent, 2)
+	handler.OnTrade(func(event wsex.TradeEvent) { trades <- event })
+	ch := make(wsex.MessageChan, 1)
+	conn.Subscribe("btcusdt@trade", ch)
+
+	conn.Publish("btcusdt@trade", wsex.Message{Type: wsex.MsgTrade, Data: []wsex.Trade{
+		{Symbol: "BTC/USDT", Timestamp: 1000},
+		{Symbol: "BTC/USDT", Timestamp: 1001},
+	}})
+	msg, ok := recv(ch)
+	if !ok {
+		t.Fatal("the subscriber should receive the message")
+	}
+	if msg.Exchange != "binance" || msg.Symbol != "BTC/USDT" || msg.Time != 1001 || msg.RecvTime == 0 {
+		t.Fatalf("unexpected stamp %+v", msg)
+	}
+	handler.Handle(msg)
+	for _, ts := range []time.Duration{1000, 1001} {
+		if event := <-trades; event.Time != ts || event.Exchange != "binance" || event.Trade.Symbol != "BTC/USDT" {
+			t.Fatalf("unexpected event %+v", event)
+		}
+	}
+}
+
+type feedMetrics struct {
+	wsex.NopMetrics
+	feed []time.Duration
+}
+
+func (m *feedMetrics) ObserveLatency(exchange, channel string, stage wsex.LatencyStage, latency time.Duration) {
+	if stage == wsex.LatencyFeed {
+		m.feed = append(m.feed, latency)
+	}
+}
+
+func TestConnectionManager_FeedLatency(t *testing.T) {
+	metrics := &feedMetrics{}
+	mgr := NewConnectionManager()
+	mgr.SetMetrics(metrics)
+	conn, _ := mgr.GetConnection("wss://api.gateio.ws/ws/v4/", func(url string) (*Connection, error) {
+		return NewConnection(), nil
+	})
+	ch := make(wsex.MessageChan, 2)
+	conn.Subscribe("spot.tickers", ch)
+
+	now := time.Duration(time.Now().UnixNano() / 1e6)
+	// the time in seconds is skipped
+	conn.Publish("spot.tickers", wsex.Message{Type: wsex.MsgTicker, Data: wsex.Ticker{Symbol: "BTC/USDT", Timestamp: now / 1000}})
+	conn.Publish("spot.tickers", wsex.Message{Type: wsex.MsgTicker, Data: wsex.Ticker{Symbol: "BTC/USDT", Timestamp: now - 10}})
+	if len(metrics.feed) != 1 || metrics.feed[0] < 10*time.Millisecond || metrics.feed[0] > time.Second {
+		t.Fatalf("only the time in ms should be observed, %v", metrics.feed)
+	}
+}
+
+type depthMetrics struct {
+	wsex.NopMetrics
+	depth []int
+}
+
+func (m *depthMetrics) MessagePublished(exchange, channel string, queueDepth int) {
+	m.depth = append(m.depth, queueDepth)
+}
+
+func TestConnection_QueueDepth(t *testing.T) {
+	metrics := &depthMetrics{}
+	conn := NewConnection()
+	conn.setStamp("binance", metrics)
+	// the stuck subscriber doesn't receive anything
+	ch := make(wsex.MessageChan)
+	conn.Subscribe("btcusdt@trade", ch)
+	for i := 0; i < 3; i++ {
+		conn.Publish("btcusdt@trade", wsex.Message{Type: wsex.MsgTrade})
+	}
+	if len(metrics.depth) != 3 || metrics.depth[2] != 3 {
+		t.Fatalf("the messages being sent should be counted, %v", metrics.depth)
+	}
+	for i := 0; i < 3; i++ {
+		<-ch
+	}
+	deadline := time.Now().Add(time.Second)
+	for conn.sending(ch, 0) != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := conn.sending(ch, 0); n != 0 {
+		t.Fatalf("the sent messages should not be counted, %v", n)
+	}
+}
+
+func TestConnectionManager_Rebalance(t *testing.T) {
+	mgr := NewConnectionManager()
+	mgr.SetMaxTopics(2)
+	connect := func(url string) (*Connection, error) {
+		return NewConnection(), nil
+	}
+	url := "wss://stream.binance.com:9443/stream"
+	sub := make(wsex.MessageChan, 10)
+	topics := []string{"btcusdt@ticker", "ethusdt@ticker", "ltcusdt@ticker", "adausdt@ticker", "xrpusdt@ticker"}
+	for _, topic := range topics {
+		if _, err := mgr.GetShard(url, topic, sub, connect); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, _ := mgr.GetConnection(url, nil)
+	// the shards: url [btc eth], url#1 [ltc ada], url#2 [xrp]
+	first.UnSubscribe("btcusdt@ticker", sub)
+	first.UnSubscribe("ethusdt@ticker", sub)
+
+	var moved []string
+	move := func(topic string, from, to *Connection) error {
+		if from.HasTopic(topic) || !to.HasTopic(topic) {
+			t.Fatalf("the route of %s should be moved before the move func", topic)
+		}
+		moved = append(moved, topic)
+		return nil
+	}
+	if err := mgr.Rebalance(url, move); err != nil {
+		t.Fatal(err)
+	}
+	// url#2 is drained first, then url#1 doesn't fit into the free place left
+	if len(moved) != 1 || moved[0] != "xrpusdt@ticker" || !first.HasTopic("xrpusdt@ticker") {
+		t.Fatalf("unexpected moves %v", moved)
+	}
+	if _, err := mgr.GetConnection(ShardUrl(url, 2), nil); err == nil {
+		t.Fatal("the empty extra shard should be closed")
+	}
+	second, err := mgr.GetConnection(ShardUrl(url, 1), nil)
+	if err != nil || second.TopicCount() != 2 {
+		t.Fatal("the full extra shard should be kept")
+	}
+	first.Publish("xrpusdt@ticker", wsex.Message{Type: wsex.MsgTicker})
+	if _, ok := recv(sub); !ok {
+		t.Fatal("the subscriber of the moved topic should receive the message")
+	}
+
+	// the failed move is rolled back
+	second.UnSubscribe("ltcusdt@ticker", sub)
+	failed := wsex.ExError{Code: wsex.ErrExchangeSystem, Message: "move failed"}
+	if err := mgr.Rebalance(url, func(topic string, from, to *Connection) error { return failed }); err == nil {
+		t.Fatal("the failed move should be returned")
+	}
+	if !second.HasTopic("adausdt@ticker") || first.HasTopic("adausdt@ticker") {
+		t.Fatal("the route should be moved back after the failed move")
+	}
+
+	// release only
+	second.UnSubscribe("adausdt@ticker", sub)
+	if err := mgr.Rebalance(url, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mgr.GetConnection(ShardUrl(url, 1), nil); err == nil {
+		t.Fatal("the empty extra shard should be released")
+	}
+	if _, err := mgr.GetConnection(url, nil); err != nil {
+		t.Fatal("the first shard is always kept")
+	}
+}
@@ -21,6 +21,9 @@ type GateRest struct {
 
 func (e *GateRest) Init(options wsex.Options) {
 	e.Option = options
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.GateIo
+	}
 	if e.Option.RestHost == "" {
 		e.Option.RestHost = "https://api.gateio.ws"
 	}
@@ -227,51 +230,157 @@ func (e *GateRest) FetchBalance() (balances map[string]wsex.Balance, err error)
 }
 
 func (e *GateRest) CreateOrder(symbol string, price, amount float64, side wsex.Side, tradeType wsex.TradeType, orderType wsex.OrderType, useClientID bool) (order wsex.Order, err error) {
-	market, err := e.GetMarket(symbol)
+	return e.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Price: price, Amount: amount, Side: side, Type: tradeType, OrderType: orderType, UseClientID: useClientID})
+}
+
+func (e *GateRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
+	market, err := e.GetMarket(req.Symbol)
 	if err != nil {
 		return
 	}
-	params := url.Values{}
+	params, err := e.orderParams(market, req)
+	if err != nil {
+		return
+	}
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/spot/orders", params, http.Header{})
+	if err != nil {
+		return
+	}
+	type response struct {
+		ID  string `json:"id"`
+		CID string `json:"text"`
+	}
+	var data response
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	order.ID = data.ID
+	order.ClientID = data.CID
+	return
+}
+
+func (e *GateRest) orderParams(market wsex.Market, req wsex.OrderRequest) (params url.Values, err error) {
+	if err = exchanges.CheckSpotRequest("gateio", req); err != nil {
+		return
+	}
+	if req = req.Normalize(); req.Type.IsConditional() {
+		err = exchanges.ErrNotSupport("gateio", fmt.Sprintf("%s order", req.Type))
+		return
+	}
+	params = url.Values{}
 	params.Set("currency_pair", market.SymbolID)
-	params.Set("amount", utils.Round(amount, market.AmountPrecision, false))
-	if side == wsex.Sell {
+	params.Set("amount", utils.Round(req.Amount, market.AmountPrecision, false))
+	if req.Side == wsex.Sell {
 		params.Set("side", "sell")
 	} else {
 		params.Set("side", "buy")
 	}
-	switch tradeType {
+	switch req.Type {
 	case wsex.MARKET:
 		params.Set("type", "market")
+		// 市价买单的amount为quote金额,市价单只支持ioc和fok
+		if req.Side == wsex.Buy {
+			quoteAmount := req.QuoteAmount
+			if quoteAmount == 0 {
+				quoteAmount = req.Amount * req.Price
+			}
+			params.Set("amount", utils.Round(quoteAmount, market.PricePrecision, false))
+		}
+		params.Set("time_in_force", "ioc")
+		if req.OrderType == wsex.FOK {
+			params.Set("time_in_force", "fok")
+		}
 	default:
 		params.Set("type", "limit")
-		params.Set("price", utils.Round(price, market.PricePrecision, false))
+		params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+		switch req.OrderType {
+		case wsex.IOC:
+			params.Set("time_in_force", "ioc")
+		case wsex.FOK:
+			params.Set("time_in_force", "fok")
+		case wsex.PostOnly:
+			params.Set("time_in_force", "poc")
+		default:
+			params.Set("time_in_force", "gtc")
+		}
 	}
-	switch orderType {
-	case wsex.Normal:
-		params.Set("time_in_force", "gtc")
-	case wsex.IOC:
-		params.Set("time_in_force", "ioc")
-	case wsex.PostOnly:
-		params.Set("time_in_force", "poc")
+	if clientID := req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32); clientID != "" {
+		params.Set("text", clientID)
+	}
+	exchanges.MergeParams(params, req.Params)
+	return
+}
+
+// CreateOrders place orders by /spot/batch_orders, 10 orders at most per request
+func (e *GateRest) CreateOrders(requests []wsex.OrderRequest) (results []wsex.OrderResult, err error) {
+	const batchSize = 10
+	results = make([]wsex.OrderResult, len(requests))
+	batch := make([]map[string]interface{}, len(requests))
+	var indexes []int
+	for i, req := range requests {
+		market, err := e.GetMarket(req.Symbol)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		params, err := e.orderParams(market, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		batch[i] = utils.UrlValuesToMap(params)
+		indexes = append(indexes, i)
 	}
-	if useClientID {
-		params.Set("text", utils.GenerateOrderClientId(e.Option.ClientOrderIDPrefix, 32))
+	for start := 0; start < len(indexes); start += batchSize {
+		end := start + batchSize
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		e.createOrderBatch(requests, batch, indexes[start:end], results)
 	}
-	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/spot/orders", params, http.Header{})
+	return results, nil
+}
+
+func (e *GateRest) createOrderBatch(requests []wsex.OrderRequest, batch []map[string]interface{}, indexes []int, results []wsex.OrderResult) {
+	orders := make([]map[string]interface{}, len(indexes))
+	for j, i := range indexes {
+		orders[j] = batch[i]
+	}
+	items, err := e.fetchBatch("/spot/batch_orders", orders, len(indexes))
 	if err != nil {
+		for _, i := range indexes {
+			results[i].Err = err
+		}
 		return
 	}
-	type response struct {
-		ID  string `json:"id"`
-		CID string `json:"text"`
+	for j, item := range items {
+		i := indexes[j]
+		if results[i].Err = e.HandleError(exchanges.Request{}, item); results[i].Err != nil {
+			continue
+		}
+		var data Order
+		if err = json.Unmarshal(item, &data); err != nil {
+			results[i].Err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+			continue
+		}
+		results[i].Order = data.parserOrder(requests[i].Symbol)
 	}
-	var data response
-	if err = json.Unmarshal(res, &data); err != nil {
-		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+}
+
+func (e *GateRest) fetchBatch(function string, batch interface{}, size int) (items []json.RawMessage, err error) {
+	params, err := utils.RawJsonValues(batch)
+	if err != nil {
+		err = wsex.ExError{Code: wsex.ErrRequestParams, Message: err.Error()}
 		return
 	}
-	order.ID = data.ID
-	order.ClientID = data.CID
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, function, params, http.Header{})
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(res, &items); err != nil || len(items) != size {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("unexpected batch response: %s", res)}
+	}
 	return
 }
 
@@ -287,6 +396,68 @@ func (e *GateRest) CancelOrder(symbol, orderID string) (err error) {
 	return err
 }
 
+// AmendOrder by PATCH /spot/orders/{order_id}, the order keeps its id
+func (e *GateRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (result wsex.AmendResult, err error) {
+	result.Method = wsex.AmendInPlace
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	if newPrice != 0 {
+		params.Set("price", utils.Round(newPrice, market.PricePrecision, false))
+	}
+	if newAmount != 0 {
+		params.Set("amount", utils.Round(newAmount, market.AmountPrecision, false))
+	}
+	function := fmt.Sprintf("/spot/orders/%s?currency_pair=%s", orderID, url.QueryEscape(market.SymbolID))
+	res, err := e.Fetch(e, exchanges.Private, exchanges.PATCH, function, params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data Order
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	result.Order = data.parserOrder(market.Symbol)
+	return
+}
+
+// CancelOrders cancel orders by /spot/cancel_batch_orders, 20 orders at most per request
+func (e *GateRest) CancelOrders(symbol string, orderIDs []string) (results []wsex.CancelResult, err error) {
+	const batchSize = 20
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	results = make([]wsex.CancelResult, len(orderIDs))
+	for start := 0; start < len(orderIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(orderIDs) {
+			end = len(orderIDs)
+		}
+		e.cancelOrderBatch(market, orderIDs[start:end], results[start:end])
+	}
+	return results, nil
+}
+
+func (e *GateRest) cancelOrderBatch(market wsex.Market, orderIDs []string, results []wsex.CancelResult) {
+	batch := make([]map[string]string, len(orderIDs))
+	for i, orderID := range orderIDs {
+		results[i].OrderID = orderID
+		batch[i] = map[string]string{"currency_pair": market.SymbolID, "id": orderID}
+	}
+	items, err := e.fetchBatch("/spot/cancel_batch_orders", batch, len(results))
+	for i := range results {
+		if err != nil {
+			results[i].Err = err
+		} else {
+			results[i].Err = e.HandleError(exchanges.Request{}, items[i])
+		}
+	}
+}
+
 func (e *GateRest) CancelAllOrders(symbol string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -345,6 +516,37 @@ func (e *GateRest) FetchOpenOrders(symbol string, pageIndex, pageSize int) (orde
 	return
 }
 
+func (e *GateRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *GateRest) CancelConditionalOrder(symbol, orderID string) (err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *GateRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("currency_pair", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/spot/fee", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data TradeFee
+	err = json.Unmarshal(res, &data)
+	if err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	fee = data.parserTradingFee(market.Symbol)
+	return
+}
+
 func (e *GateRest) Sign(access, method, function string, param url.Values, header http.Header) (request exchanges.Request) {
 	request.Method = method
 	request.Headers = header
@@ -366,18 +568,18 @@ func (e *GateRest) Sign(access, method, function string, param url.Values, heade
 			}
 			plainText = fmt.Sprintf("%s\n/api/v4%s\n%s\n%s\n%d", method, function, param.Encode(), HexBody, t)
 		} else {
-			request.Url = e.Option.RestHost + "/api/v4" + function
-			data := make(map[string]string)
-			for key, v := range param {
-				data[key] = v[0]
+			// query string can be carried by function, eg: PATCH /spot/orders/{id}?currency_pair=
+			path, query := function, ""
+			if i := strings.Index(function, "?"); i >= 0 {
+				path, query = function[:i], function[i+1:]
 			}
-			dataStr, _ := json.Marshal(data)
-			request.Body = string(dataStr)
-			HexBody, err := utils.HashSign(utils.SHA512, string(dataStr), false)
+			request.Url = e.Option.RestHost + "/api/v4" + function
+			request.Body = utils.UrlValuesToJson(param)
+			HexBody, err := utils.HashSign(utils.SHA512, request.Body, false)
 			if err != nil {
 				return
 			}
-			plainText = fmt.Sprintf("%s\n/api/v4%s\n%s\n%s\n%d", method, function, "", HexBody, t)
+			plainText = fmt.Sprintf("%s\n/api/v4%s\n%s\n%s\n%d", method, path, query, HexBody, t)
 		}
 
 		SignStr, err := utils.HmacSign(utils.SHA512, plainText, e.Option.SecretKey, false)
@@ -4,19 +4,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/book"
 	"github.com/shiguantian/wsex/exchanges"
 	"github.com/shiguantian/wsex/exchanges/websocket"
 	"github.com/shiguantian/wsex/utils"
 )
 
+var requestID int64 // id of the subscribe request, used to match the ack
+
 type Stream struct {
 	Time    int64             `json:"time"`
 	ID      int64             `json:"id,omitempty"`
@@ -45,16 +49,36 @@ func UnSubscribeStream(channel string, payload []string) Stream {
 
 type GateWs struct {
 	exchanges.BaseExchange
-	orderBooks       map[string]*SymbolOrderBook
+	orderBooks       map[string]*book.Engine // key: ws url
 	partialOrderBook OrderBook
 	errors           map[int]wsex.ExError
+	payloads         map[string][]string
+}
+
+// topic 格式为 channel:payload，K线为 spot.candlesticks:1m_BTC_USDT，余额仅为 channel
+func gateTopic(channel string, payload []string) string {
+	switch {
+	case len(payload) == 0:
+		return channel
+	case channel == "spot.candlesticks":
+		return channel + ":" + strings.Join(payload, "_")
+	default:
+		return channel + ":" + payload[0]
+	}
 }
 
 func (e *GateWs) Init(options wsex.Options) {
 	e.BaseExchange.Init()
 	e.Option = options
-	e.orderBooks = make(map[string]*SymbolOrderBook)
+	e.orderBooks = make(map[string]*book.Engine)
 	e.errors = map[int]wsex.ExError{}
+	e.payloads = make(map[string][]string)
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.GateIo
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 	if e.Option.WsHost == "" {
 		e.Option.WsHost = "wss://api.gateio.ws/ws/v4/"
 	}
@@ -151,18 +175,23 @@ func (e *GateWs) SubscribeOrder(symbol string, sub wsex.MessageChan) (string, er
 	return e.subscribeUserData(e.Option.WsHost, "spot.orders", []string{market.SymbolID}, sub)
 }
 
-func (e *GateWs) UnSubscribe(topics string, sub wsex.MessageChan) error {
-	stream := UnSubscribeStream(topics, nil)
-	conn, err := e.ConnectionMgr.GetConnection(e.Option.WsHost, e.Connect)
+func (e *GateWs) UnSubscribe(topic string, sub wsex.MessageChan) error {
+	conn, err := e.ConnectionMgr.FindShard(e.Option.WsHost, topic)
 	if err != nil {
 		return err
 	}
-	err = e.send(conn, stream)
-	if err != nil {
+	if !conn.UnSubscribe(topic, sub) {
+		return nil
+	}
+	e.RwLock.Lock()
+	payload := e.payloads[topic]
+	delete(e.payloads, topic)
+	e.RwLock.Unlock()
+	if err := e.send(conn, UnSubscribeStream(strings.Split(topic, ":")[0], payload)); err != nil {
 		return err
 	}
-	conn.Subscribe(sub)
-	return nil
+	// close the empty shards, the topics are not moved
+	return e.ConnectionMgr.Rebalance(e.Option.WsHost, nil)
 }
 
 func (e *GateWs) Connect(url string) (*exchanges.Connection, error) {
@@ -185,16 +214,43 @@ func (e *GateWs) Connect(url string) (*exchanges.Connection, error) {
 }
 
 func (e *GateWs) Subscribe(url, channel string, payload []string, sub wsex.MessageChan) (string, error) {
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	conn, err := e.ConnectionMgr.GetShard(url, gateTopic(channel, payload), sub, e.Connect)
 	if err != nil {
 		return "", err
 	}
-	err = e.send(conn, SubscribeStream(channel, payload))
+	return e.subscribeWithAck(conn, channel, payload, SubscribeStream(channel, payload), sub)
+}
+
+// subscribeWithAck send the subscribe request and wait for the ack, the route is removed if failed
+func (e *GateWs) subscribeWithAck(conn *exchanges.Connection, channel string, payload []string, stream Stream, sub wsex.MessageChan) (string, error) {
+	stream.ID = atomic.AddInt64(&requestID, 1)
+	id := strconv.FormatInt(stream.ID, 10)
+	ack := e.SubscribeAck.Register(id)
+	topic := e.addRoute(conn, channel, payload, sub)
+	err := e.send(conn, stream)
 	if err != nil {
+		e.SubscribeAck.Done(id, err)
+	} else {
+		err = e.WaitSubscribeAck(id, ack)
+	}
+	if err != nil {
+		if conn.UnSubscribe(topic, sub) {
+			e.RwLock.Lock()
+			delete(e.payloads, topic)
+			e.RwLock.Unlock()
+		}
 		return "", err
 	}
-	conn.Subscribe(sub)
-	return channel, nil
+	return topic, nil
+}
+
+func (e *GateWs) addRoute(conn *exchanges.Connection, channel string, payload []string, sub wsex.MessageChan) string {
+	topic := gateTopic(channel, payload)
+	e.RwLock.Lock()
+	e.payloads[topic] = payload
+	e.RwLock.Unlock()
+	conn.Subscribe(topic, sub)
+	return topic
 }
 
 func (e *GateWs) subscribeUserData(url, channel string, payload []string, sub wsex.MessageChan) (string, error) {
@@ -209,16 +265,12 @@ func (e *GateWs) subscribeUserData(url, channel string, payload []string, sub ws
 	request["KEY"] = e.Option.AccessKey
 	request["SIGN"] = singStr
 	stream.Auth = request
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	// every request is signed, so the private channels can be placed on any connection
+	conn, err := e.ConnectionMgr.GetShard(url, gateTopic(channel, payload), sub, e.Connect)
 	if err != nil {
 		return "", err
 	}
-	err = e.send(conn, stream)
-	if err != nil {
-		return "", err
-	}
-	conn.Subscribe(sub)
-	return channel, nil
+	return e.subscribeWithAck(conn, channel, payload, stream, sub)
 }
 
 func (e *GateWs) send(conn *exchanges.Connection, stream Stream) (err error) {
@@ -236,8 +288,17 @@ func (e *GateWs) messageHandler(url string, message []byte) {
 		e.errorHandler(url, fmt.Errorf("[GateWs] messageHandler unmarshal error:%v", err))
 		return
 	}
+	if res.Event == "subscribe" && res.ID != 0 {
+		var err error
+		if res.Error != nil {
+			err = wsex.ExError{Code: wsex.ErrChannelNotExist, Message: res.Error.Message, Data: map[string]interface{}{"code": res.Error.Code}}
+		}
+		if e.SubscribeAck.Done(strconv.FormatInt(res.ID, 10), err) {
+			return
+		}
+	}
 	if res.Error != nil {
-		e.errorHandler(url, fmt.Errorf("[GateWs] messageHandler response error:%v", res.Error))
+		e.errorHandler(url, fmt.Errorf("[GateWs] messageHandler response error:%v", *res.Error))
 		return
 	}
 	switch res.Channel {
@@ -323,34 +384,44 @@ func (e *GateWs) handleIncrementalDepth(url string, message []byte) {
 		return
 	}
 
-	symbolOrderBook, ok := e.orderBooks[url]
-	if !ok || symbolOrderBook == nil {
-		_ = e.getSnapshotOrderBook(url, market, &SymbolOrderBook{})
-		return
+	delta := book.Delta{
+		Bids:     data.Result.Bids,
+		Asks:     data.Result.Asks,
+		First:    data.Result.FirstUpdateID,
+		Last:     data.Result.LastUpdateID,
+		Time:     time.Duration(data.Result.Time),
+		RecvTime: e.ConnectionMgr.RecvTime(url),
+	}
+	for _, msg := range e.orderBook(url).Update(market.Symbol, delta) {
+		e.ConnectionMgr.Publish(url, gateTopic(data.Channel, []string{data.Result.Symbol}), msg)
 	}
+}
 
-	fullOrders, ok := (*e.orderBooks[url])[market.Symbol]
+// orderBook the local order books of the connection
+func (e *GateWs) orderBook(url string) *book.Engine {
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	engine, ok := e.orderBooks[url]
 	if !ok {
-		_ = e.getSnapshotOrderBook(url, market, &SymbolOrderBook{})
-		return
-	}
-	if fullOrders != nil {
-		first := data.Result.FirstUpdateID <= fullOrders.LastUpdateID && data.Result.LastUpdateID >= fullOrders.LastUpdateID
-		if first || data.Result.FirstUpdateID == fullOrders.LastUpdateID+1 {
-			fullOrders.OrderBook = data.Result.parseOrderBook(market.Symbol)
-			((*e.orderBooks[url])[market.Symbol]).LastUpdateID = data.Result.LastUpdateID
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrders.OrderBook})
-		}
-	} else if data.Result.LastUpdateID < fullOrders.LastUpdateID {
-		log.Printf("[GateWs] handleIncrementalDepth - recv old update data\n")
-	} else {
-		delete(*symbolOrderBook, market.Symbol)
-		err := wsex.ExError{Code: wsex.ErrInvalidDepth,
-			Message: fmt.Sprintf("[GateWs] handleIncrementalDepth - recv dirty data, new.FirstUpdateID: %v != old.LastUpdateID: %v ", data.Result.FirstUpdateID, fullOrders.LastUpdateID+1),
-			Data:    map[string]interface{}{"symbol": fullOrders.Symbol}}
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: err})
-	}
+		engine = book.NewEngine(e.getSnapshotOrderBook)
+		engine.SetAsync(func(symbol string, msgs []wsex.Message) {
+			market, err := e.GetMarket(symbol)
+			if err != nil {
+				e.errorHandler(url, err)
+				return
+			}
+			for _, msg := range msgs {
+				e.ConnectionMgr.Publish(url, gateTopic("spot.order_book_update", []string{market.SymbolID}), msg)
+			}
+		})
+		engine.SetDepth(e.Option.OrderBookDepth)
+		engine.SetMetrics(e.Option.ExchangeName, e.Option.Metrics)
+		engine.SetCompareInterval(e.Option.OrderBookCheckTime)
+		e.orderBooks[url] = engine
+	}
+	return engine
 }
+
 func (e *GateWs) handleTicker(url string, message []byte) {
 	type tick struct {
 		ResponseEvent
@@ -368,8 +439,8 @@ func (e *GateWs) handleTicker(url string, message []byte) {
 		return
 	}
 	ticker := data.Result.parseTicker(market.Symbol)
-	ticker.Timestamp = time.Duration(data.ResponseEvent.Time)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
+	ticker.Timestamp = data.ResponseEvent.millis()
+	e.ConnectionMgr.Publish(url, gateTopic(data.Channel, []string{data.Result.Symbol}), wsex.Message{Type: wsex.MsgTicker, Data: ticker})
 }
 
 func (e *GateWs) handleTrade(url string, message []byte) {
@@ -389,7 +460,7 @@ func (e *GateWs) handleTrade(url string, message []byte) {
 		return
 	}
 	trade := data.Result.parseTrade(market.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: trade})
+	e.ConnectionMgr.Publish(url, gateTopic(data.Channel, []string{data.Result.Symbol}), wsex.Message{Type: wsex.MsgTrade, Data: trade})
 }
 func (e *GateWs) handleKLine(url string, message []byte) {
 	type wskline struct {
@@ -434,7 +505,7 @@ func (e *GateWs) handleKLine(url string, message []byte) {
 			Low:       utils.SafeParseFloat(data.Result.Low),
 			Volume:    utils.SafeParseFloat(data.Result.Volume),
 		}
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgKLine, Data: kline})
+		e.ConnectionMgr.Publish(url, gateTopic(data.Channel, []string{data.Result.IntSymbol}), wsex.Message{Type: wsex.MsgKLine, Data: kline})
 	}
 }
 func (e *GateWs) handleOrder(url string, message []byte) {
@@ -462,7 +533,7 @@ func (e *GateWs) handleOrder(url string, message []byte) {
 			return
 		}
 		order := each.parserOrder(market.Symbol)
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrder, Data: order})
+		e.ConnectionMgr.Publish(url, gateTopic(data.Channel, []string{each.Symbol}), wsex.Message{Type: wsex.MsgOrder, Data: order})
 	}
 }
 func (e *GateWs) handleBalance(url string, message []byte) {
@@ -480,14 +551,14 @@ func (e *GateWs) handleBalance(url string, message []byte) {
 		return
 	}
 	balances := wsex.BalanceUpdate{Balances: make(map[string]wsex.Balance)}
-	balances.UpdateTime = time.Duration(data.ResponseEvent.Time)
+	balances.UpdateTime = data.ResponseEvent.millis()
 	balance := wsex.Balance{
 		Asset:     strings.ToUpper(data.Result[0].Currency),
 		Available: utils.SafeParseFloat(data.Result[0].Available),
 		Frozen:    utils.SafeParseFloat(data.Result[0].Total) - utils.SafeParseFloat(data.Result[0].Available),
 	}
 	balances.Balances[balance.Asset] = balance
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	e.ConnectionMgr.Publish(url, data.Channel, wsex.Message{Type: wsex.MsgBalance, Data: balances})
 }
 func (e *GateWs) handleDepth(url string, message []byte) {
 	type WsDepth struct {
@@ -513,25 +584,23 @@ func (e *GateWs) handleDepth(url string, message []byte) {
 	e.partialOrderBook.Bids = wsex.Depth{}
 	e.partialOrderBook.Asks = wsex.Depth{}
 	e.partialOrderBook.OrderBook = data.Result.parseOrderBook(market.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: e.partialOrderBook.OrderBook})
+	e.ConnectionMgr.Publish(url, gateTopic(data.Channel, []string{data.Result.Symbol}), wsex.Message{Type: wsex.MsgOrderBook, Data: e.partialOrderBook.OrderBook})
 }
 
-func (e *GateWs) getSnapshotOrderBook(url string, market wsex.Market, symbolOrderBook *SymbolOrderBook) (err error) {
-	time.Sleep(time.Second)
+func (e *GateWs) getSnapshotOrderBook(symbol string) (*book.Snapshot, error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return nil, err
+	}
 	var response = RawOrderBook{}
-	client := resty.New()
+	client := resty.New().SetTimeout(exchanges.SnapshotTimeout)
 	reqUrl := fmt.Sprintf("%s/api/v4/spot/order_book?currency_pair=%s&limit=100&with_id=true", e.Option.RestHost, market.SymbolID)
 	_, err = client.R().SetHeader("Accept", "application/json").SetHeader("Content-Type", "application/json").SetResult(&response).Get(reqUrl)
 	if err != nil {
-		return fmt.Errorf("[GateWs] getSnapshotOrderBook - request url %s error:%v", reqUrl, err)
+		return nil, fmt.Errorf("[GateWs] getSnapshotOrderBook - request url %s error:%v", reqUrl, err)
 	}
 	if response.ID == 0 {
-		return fmt.Errorf("[GateWs] getSnapshotOrderBook - request url %s no data", reqUrl)
-	}
-	var orderBook OrderBook
-	orderBook.LastUpdateID = response.ID
-	orderBook.OrderBook = response.parseOrderBook(market.Symbol)
-	(*symbolOrderBook)[market.Symbol] = &orderBook
-	e.orderBooks[url] = symbolOrderBook
-	return
+		return nil, fmt.Errorf("[GateWs] getSnapshotOrderBook - request url %s no data", reqUrl)
+	}
+	return &book.Snapshot{Bids: response.Bids, Asks: response.Asks, Sequence: response.ID}, nil
 }
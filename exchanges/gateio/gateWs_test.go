@@ -123,15 +123,11 @@ func TestGateWs_SubscribeOrder(t *testing.T) {
 	}
 }
 
-func TestGateWs_getSnapshotOrderBook(t *testing.T){
-	var sym =make(SymbolOrderBook)
- 	err:=e.getSnapshotOrderBook("2",wsex.Market{
- 		SymbolID: "EOS_USDT",
- 		Symbol: symbol,
-	},&sym)
- 	if err!=nil{
- 		fmt.Println(err)
+func TestGateWs_getSnapshotOrderBook(t *testing.T) {
+	snapshot, err := e.getSnapshotOrderBook(symbol)
+	if err != nil {
+		fmt.Println(err)
+		return
 	}
-	fmt.Println((*e.orderBooks["2"])[symbol].OrderBook)
-
+	fmt.Println(snapshot.Sequence, snapshot.Bids, snapshot.Asks)
 }
@@ -26,6 +26,7 @@ type RawOrderBook struct {
 	Asks          wsex.RawDepth `json:"asks" rest:"a"`
 	Bids          wsex.RawDepth `json:"bids" rest:"b"`
 	Symbol        string        `json:"s" rest:"s"`
+	Time          int64         `json:"t" rest:"t"` // event time in ms
 }
 
 type OrderBook struct {
@@ -37,11 +38,10 @@ func (r *RawOrderBook) parseOrderBook(symbol string) (orderBook wsex.OrderBook)
 	orderBook.Bids = orderBook.Bids.Update(r.Bids, true)
 	orderBook.Asks = orderBook.Asks.Update(r.Asks, false)
 	orderBook.Symbol = symbol
+	orderBook.Timestamp = time.Duration(r.Time)
 	return
 }
 
-type SymbolOrderBook map[string]*OrderBook
-
 type Ticker struct {
 	Timestamp  int64  `json:"etf_pre_timestamp"`
 	Symbol     string `json:"currency_pair"`
@@ -159,6 +159,8 @@ type Order struct {
 	Type            string `json:"type" rest:"type"`
 	OrderType       string `json:"time_in_force" rest:"time_in_force"`
 	Cost            string `json:"filled_total" rest:"filled_total"`
+	Fee             string `json:"fee" rest:"fee"`
+	FeeCurrency     string `json:"fee_currency" rest:"fee_currency"`
 }
 
 func (o *Order) parserOrder(symbol string) wsex.Order {
@@ -170,6 +172,8 @@ func (o *Order) parserOrder(symbol string) wsex.Order {
 		Amount:          o.Amount,
 		Filled:          fmt.Sprintf("%f", utils.SafeParseFloat(o.Amount)-utils.SafeParseFloat(o.Left)),
 		Cost:            o.Cost,
+		Fee:             o.Fee,
+		FeeCurrency:     o.FeeCurrency,
 		CreateTime:      time.Duration(utils.SafeParseFloat(o.CreateTime)),
 		TransactionTime: time.Duration(utils.SafeParseFloat(o.TransactionTime)),
 	}
@@ -214,9 +218,38 @@ func (o *Order) parserOrder(symbol string) wsex.Order {
 	return order
 }
 
+type TradeFee struct {
+	Symbol   string `json:"currency_pair"`
+	MakerFee string `json:"maker_fee"`
+	TakerFee string `json:"taker_fee"`
+}
+
+func (f *TradeFee) parserTradingFee(symbol string) wsex.TradingFee {
+	return wsex.TradingFee{
+		Symbol: symbol,
+		Maker:  utils.SafeParseFloat(f.MakerFee),
+		Taker:  utils.SafeParseFloat(f.TakerFee),
+	}
+}
+
 type ResponseEvent struct {
-	Time    int64       `json:"time" rest:"time"`
-	Channel string      `json:"channel" rest:"channel"`
-	Event   string      `json:"event" rest:"event"`
-	Error   interface{} `json:"error" rest:"error"`
+	Time    int64          `json:"time" rest:"time"` // in seconds
+	TimeMs  int64          `json:"time_ms" rest:"time_ms"`
+	ID      int64          `json:"id" rest:"id"`
+	Channel string         `json:"channel" rest:"channel"`
+	Event   string         `json:"event" rest:"event"`
+	Error   *ResponseError `json:"error" rest:"error"`
+}
+
+// millis the event time in ms, time_ms is not pushed by every channel
+func (r ResponseEvent) millis() time.Duration {
+	if r.TimeMs > 0 {
+		return time.Duration(r.TimeMs)
+	}
+	return time.Duration(r.Time) * 1000
+}
+
+type ResponseError struct {
+	Code    int    `json:"code" rest:"code"`
+	Message string `json:"message" rest:"message"`
 }
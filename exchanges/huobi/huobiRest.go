@@ -28,6 +28,9 @@ var AccountId int = 0
 
 func (e *HuobiRest) Init(option wsex.Options) {
 	e.Option = option
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.Huobi
+	}
 
 	if e.Option.RestHost == "" {
 		e.Option.RestHost = "https://api.huobi.pro"
@@ -242,39 +245,21 @@ func (e *HuobiRest) FetchBalance() (balances map[string]wsex.Balance, err error)
 }
 
 func (e *HuobiRest) CreateOrder(symbol string, price, amount float64, side wsex.Side, tradeType wsex.TradeType, orderType wsex.OrderType, useClientID bool) (order wsex.Order, err error) {
+	return e.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Price: price, Amount: amount, Side: side, Type: tradeType, OrderType: orderType, UseClientID: useClientID})
+}
+
+func (e *HuobiRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
 	accountId, err := e.GetAccount()
 	if err != nil {
 		return
 	}
-	market, err := e.GetMarket(symbol)
+	market, err := e.GetMarket(req.Symbol)
 	if err != nil {
 		return
 	}
-	params := url.Values{}
-	params.Add("account-id", strconv.Itoa(int(accountId)))
-	params.Set("symbol", market.SymbolID)
-	params.Set("amount", utils.Round(amount, market.AmountPrecision, false))
-	if side == wsex.Sell {
-		switch tradeType {
-		case wsex.MARKET:
-			params.Set("type", "sell-market")
-			params.Set("amount", utils.Round(amount*price, market.AmountPrecision, false))
-		default:
-			params.Set("price", utils.Round(price, market.PricePrecision, false))
-			params.Set("type", "sell-limit")
-		}
-	} else if side == wsex.Buy {
-		switch tradeType {
-		case wsex.MARKET:
-			params.Set("type", "buy-market")
-			params.Set("amount", utils.Round(amount*price, market.AmountPrecision, false))
-		default:
-			params.Set("price", utils.Round(price, market.PricePrecision, false))
-			params.Set("type", "buy-limit")
-		}
-	}
-	if useClientID {
-		params.Set("client-order-id", GenerateOrderClientId(e.Option.ClientOrderIDPrefix, 32))
+	params, err := e.orderParams(accountId, market, req)
+	if err != nil {
+		return
 	}
 	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/v1/order/orders/place", params, http.Header{})
 	if err != nil {
@@ -293,6 +278,146 @@ func (e *HuobiRest) CreateOrder(symbol string, price, amount float64, side wsex.
 	return
 }
 
+func (e *HuobiRest) orderParams(accountId int, market wsex.Market, req wsex.OrderRequest) (params url.Values, err error) {
+	req = req.Normalize()
+	if err = exchanges.CheckSpotRequest("huobi", req); err != nil {
+		return
+	}
+	if err = exchanges.CheckConditionalRequest("huobi", req, wsex.TriggerLast); err != nil {
+		return
+	}
+	params = url.Values{}
+	params.Add("account-id", strconv.Itoa(int(accountId)))
+	params.Set("symbol", market.SymbolID)
+	params.Set("amount", utils.Round(req.Amount, market.AmountPrecision, false))
+	side := "buy"
+	if req.Side == wsex.Sell {
+		side = "sell"
+	}
+	switch req.Type {
+	case wsex.MARKET:
+		params.Set("type", side+"-market")
+		// 市价买单的amount为quote金额
+		if req.Side == wsex.Buy {
+			quoteAmount := req.QuoteAmount
+			if quoteAmount == 0 {
+				quoteAmount = req.Amount * req.Price
+			}
+			params.Set("amount", utils.Round(quoteAmount, market.PricePrecision, false))
+		}
+	case wsex.LIMIT:
+		params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+		switch req.OrderType {
+		case wsex.PostOnly:
+			params.Set("type", side+"-limit-maker")
+		case wsex.IOC:
+			params.Set("type", side+"-ioc")
+		case wsex.FOK:
+			params.Set("type", side+"-limit-fok")
+		default:
+			params.Set("type", side+"-limit")
+		}
+	case wsex.STOP_LIMIT, wsex.TAKE_PROFIT_LIMIT:
+		params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+		switch req.OrderType {
+		case wsex.FOK:
+			params.Set("type", side+"-stop-limit-fok")
+		case wsex.PostOnly, wsex.IOC:
+			err = exchanges.ErrNotSupport("huobi", "post only or ioc stop order")
+			return
+		default:
+			params.Set("type", side+"-stop-limit")
+		}
+		params.Set("stop-price", utils.Round(req.StopPrice, market.PricePrecision, false))
+		// 止损买单向上突破触发,止盈买单向下突破触发,卖单相反
+		if (req.Side == wsex.Buy) == (req.Type == wsex.STOP_LIMIT) {
+			params.Set("operator", "gte")
+		} else {
+			params.Set("operator", "lte")
+		}
+	default:
+		err = exchanges.ErrNotSupport("huobi", fmt.Sprintf("%s order", req.Type))
+		return
+	}
+	if clientID := req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32); clientID != "" {
+		params.Set("client-order-id", clientID)
+	}
+	exchanges.MergeParams(params, req.Params)
+	return
+}
+
+// CreateOrders place orders by /v1/order/batch-orders, 10 orders at most per request
+func (e *HuobiRest) CreateOrders(requests []wsex.OrderRequest) (results []wsex.OrderResult, err error) {
+	const batchSize = 10
+	accountId, err := e.GetAccount()
+	if err != nil {
+		return
+	}
+	results = make([]wsex.OrderResult, len(requests))
+	batch := make([]map[string]interface{}, len(requests))
+	var indexes []int
+	for i, req := range requests {
+		market, err := e.GetMarket(req.Symbol)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		params, err := e.orderParams(accountId, market, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		batch[i] = utils.UrlValuesToMap(params)
+		indexes = append(indexes, i)
+	}
+	for start := 0; start < len(indexes); start += batchSize {
+		end := start + batchSize
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		e.createOrderBatch(requests, batch, indexes[start:end], results)
+	}
+	return results, nil
+}
+
+func (e *HuobiRest) createOrderBatch(requests []wsex.OrderRequest, batch []map[string]interface{}, indexes []int, results []wsex.OrderResult) {
+	orders := make([]map[string]interface{}, len(indexes))
+	for j, i := range indexes {
+		orders[j] = batch[i]
+	}
+	params, err := utils.RawJsonValues(orders)
+	if err == nil {
+		var res []byte
+		if res, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/v1/order/batch-orders", params, http.Header{}); err == nil {
+			var data BatchOrderRes
+			if err = json.Unmarshal(res, &data); err == nil && len(data.Data) == len(indexes) {
+				for j, item := range data.Data {
+					i := indexes[j]
+					results[i].Err = e.batchError(item.ErrCode, item.ErrMsg)
+					results[i].Order.ID = item.OrderID.String()
+					results[i].Order.ClientID = item.ClientOrderID
+					results[i].Order.Symbol = requests[i].Symbol
+				}
+				return
+			}
+			err = wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("unexpected batch response: %s", res)}
+		}
+	}
+	for _, i := range indexes {
+		results[i].Err = err
+	}
+}
+
+func (e *HuobiRest) batchError(code, message string) error {
+	if code == "" {
+		return nil
+	}
+	if errCode, ok := e.errors[code]; ok {
+		return wsex.ExError{Code: errCode, Message: message}
+	}
+	return wsex.ExError{Code: wsex.UnHandleError, Message: fmt.Sprintf("code:%v msg:%v", code, message)}
+}
+
 func (e *HuobiRest) CancelOrder(symbol, orderID string) (err error) {
 	params := url.Values{}
 	if IsClientOrderID(orderID, e.Option.ClientOrderIDPrefix) {
@@ -306,6 +431,78 @@ func (e *HuobiRest) CancelOrder(symbol, orderID string) (err error) {
 	}
 }
 
+// CancelOrders cancel orders by /v1/order/orders/batchcancel, 50 orders at most per request
+func (e *HuobiRest) CancelOrders(symbol string, orderIDs []string) (results []wsex.CancelResult, err error) {
+	const batchSize = 50
+	if _, err = e.GetMarket(symbol); err != nil {
+		return
+	}
+	results = make([]wsex.CancelResult, len(orderIDs))
+	// order ids and client order ids can not be mixed in one request
+	var ids, clientIDs []int
+	for i, orderID := range orderIDs {
+		results[i].OrderID = orderID
+		if IsClientOrderID(orderID, e.Option.ClientOrderIDPrefix) {
+			clientIDs = append(clientIDs, i)
+		} else {
+			ids = append(ids, i)
+		}
+	}
+	for _, group := range [][]int{ids, clientIDs} {
+		for start := 0; start < len(group); start += batchSize {
+			end := start + batchSize
+			if end > len(group) {
+				end = len(group)
+			}
+			e.cancelOrderBatch(group[start:end], results)
+		}
+	}
+	return results, nil
+}
+
+func (e *HuobiRest) cancelOrderBatch(indexes []int, results []wsex.CancelResult) {
+	key := "order-ids"
+	if IsClientOrderID(results[indexes[0]].OrderID, e.Option.ClientOrderIDPrefix) {
+		key = "client-order-ids"
+	}
+	orderIDs := make([]string, len(indexes))
+	for j, i := range indexes {
+		orderIDs[j] = results[i].OrderID
+	}
+	params, err := utils.RawJsonValues(map[string][]string{key: orderIDs})
+	if err == nil {
+		var res []byte
+		if res, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/v1/order/orders/batchcancel", params, http.Header{}); err == nil {
+			var data BatchCancelRes
+			if err = json.Unmarshal(res, &data); err == nil {
+				failed := make(map[string]error)
+				for _, item := range data.Data.Failed {
+					err := e.batchError(item.ErrCode, item.ErrMsg)
+					if item.OrderID != "" {
+						failed[item.OrderID] = err
+					}
+					if item.ClientOrderID != "" {
+						failed[item.ClientOrderID] = err
+					}
+				}
+				for _, i := range indexes {
+					results[i].Err = failed[results[i].OrderID]
+				}
+				return
+			}
+			err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		}
+	}
+	for _, i := range indexes {
+		results[i].Err = err
+	}
+}
+
+// AmendOrder no amend endpoint, the order is cancelled and created again
+func (e *HuobiRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (wsex.AmendResult, error) {
+	return exchanges.AmendOrderByCancelCreate(e, symbol, orderID, newPrice, newAmount)
+}
+
 func (e *HuobiRest) CancelAllOrders(symbol string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -393,6 +590,66 @@ func (e *HuobiRest) FetchOpenOrders(symbol string, pageIndex, pageSize int) (ord
 	return
 }
 
+//FetchConditionalOrders : 未触发的止盈止损单状态为created
+func (e *HuobiRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("types", "buy-stop-limit,sell-stop-limit,buy-stop-limit-fok,sell-stop-limit-fok")
+	params.Set("states", "created")
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/v1/order/orders", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data struct {
+		Data []Order `json:"data"`
+	}
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	orders = make([]wsex.Order, len(data.Data))
+	for i, order := range data.Data {
+		orders[i] = (&OrderRes{Data: order}).parseOrder(market.Symbol, market)
+	}
+	return
+}
+
+func (e *HuobiRest) CancelConditionalOrder(symbol, orderID string) error {
+	return e.CancelOrder(symbol, orderID)
+}
+
+func (e *HuobiRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbols", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/v2/reference/transact-fee-rate", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data TradingFeeRes
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	if len(data.Data) == 0 {
+		err = wsex.ExError{Code: wsex.ErrNotFoundMarket, Message: fmt.Sprintf("trade fee of %s not found", symbol)}
+		return
+	}
+	fee = wsex.TradingFee{
+		Symbol: market.Symbol,
+		Maker:  SafeParseFloat(data.Data[0].ActualMakerRate),
+		Taker:  SafeParseFloat(data.Data[0].ActualTakerRate),
+	}
+	return
+}
+
 func (e *HuobiRest) Sign(access, method, function string, param url.Values, header http.Header) (request exchanges.Request) {
 	request.Headers = header
 	request.Method = method
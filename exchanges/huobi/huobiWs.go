@@ -8,13 +8,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
 
 	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/book"
 	"github.com/shiguantian/wsex/exchanges"
 	"github.com/shiguantian/wsex/exchanges/websocket"
 	. "github.com/shiguantian/wsex/utils"
@@ -22,6 +25,8 @@ import (
 
 type Stream map[string]string
 
+var requestID int64 // id of the sub request, used to match the ack
+
 type SubTopic struct {
 	Topic        string
 	Symbol       string
@@ -31,7 +36,7 @@ type SubTopic struct {
 
 type HuobiWs struct {
 	exchanges.BaseExchange
-	orderBooks   map[string]*SymbolOrderBook
+	orderBooks   map[string]*book.Engine // key: ws url
 	subTopicInfo map[string]SubTopic
 	errors       map[int]wsex.ExError
 	loginLock    sync.Mutex
@@ -42,12 +47,18 @@ type HuobiWs struct {
 func (e *HuobiWs) Init(option wsex.Options) {
 	e.BaseExchange.Init()
 	e.Option = option
-	e.orderBooks = make(map[string]*SymbolOrderBook)
+	e.orderBooks = make(map[string]*book.Engine)
 	e.subTopicInfo = make(map[string]SubTopic)
 	e.errors = map[int]wsex.ExError{}
 	e.loginLock = sync.Mutex{}
 	e.loginChan = make(chan struct{})
 	e.isLogin = false
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.Huobi
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 	if e.Option.WsHost == "" {
 		e.Option.WsHost = "wss://api.huobi.pro/ws"
 	}
@@ -131,19 +142,42 @@ func (e *HuobiWs) SubscribeKLine(symbol string, t wsex.KLineType, sub wsex.Messa
 }
 
 func (e *HuobiWs) UnSubscribe(event string, sub wsex.MessageChan) error {
-	delete(e.subTopicInfo, event)
-	conn, err := e.ConnectionMgr.GetConnection(e.Option.WsHost, nil)
+	url := e.Option.WsHost
+	data := map[string]string{
+		"unsub": event,
+	}
+	if strings.HasPrefix(event, "orders#") || strings.HasPrefix(event, "accounts.update") {
+		url = fmt.Sprintf("%s/v2", e.Option.WsHost)
+		data = map[string]string{
+			"action": "unsub",
+			"ch":     event,
+		}
+	} else if strings.Contains(event, ".mbp.") && !strings.Contains(event, "refresh") {
+		url = "wss://api.huobi.pro/feed"
+	}
+	var (
+		conn *exchanges.Connection
+		err  error
+	)
+	if strings.HasSuffix(url, "/v2") {
+		conn, err = e.ConnectionMgr.GetConnection(url, nil)
+	} else {
+		conn, err = e.ConnectionMgr.FindShard(url, event)
+	}
 	if err != nil {
 		return err
 	}
-	data := map[string]string{
-		"unsub": event,
+	if !conn.UnSubscribe(event, sub) {
+		return nil
 	}
+	e.RwLock.Lock()
+	delete(e.subTopicInfo, event)
+	e.RwLock.Unlock()
 	if err := conn.SendJsonMessage(data); err != nil {
 		return err
 	}
-	conn.UnSubscribe(sub)
-	return nil
+	// close the empty shards, the topics are not moved
+	return e.ConnectionMgr.Rebalance(url, nil)
 }
 
 func (e *HuobiWs) SubscribeBalance(symbol string, sub wsex.MessageChan) (string, error) {
@@ -186,16 +220,30 @@ func (e *HuobiWs) Connect(url string) (*exchanges.Connection, error) {
 }
 
 func (e *HuobiWs) subscribe(url, topic, symbol string, t wsex.MessageType, needLogin bool, sub wsex.MessageChan) (string, error) {
+	e.RwLock.Lock()
 	_, ok := e.subTopicInfo[topic] //ok是看当前key是否存在返回布尔，value返回对应key的值
 	if !ok {
 		e.subTopicInfo[topic] = SubTopic{Topic: topic, Symbol: symbol, MessageType: t}
 	}
+	e.RwLock.Unlock()
 
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	var (
+		conn *exchanges.Connection
+		err  error
+	)
+	if needLogin {
+		// the login state belongs to the connection, so private topics stay on one connection
+		conn, err = e.ConnectionMgr.GetConnection(url, e.Connect)
+	} else {
+		conn, err = e.ConnectionMgr.GetShard(url, topic, sub, e.Connect)
+	}
 	if err != nil {
 		return "", err
 	}
-	var data map[string]string
+	var (
+		data  map[string]string
+		ackID = topic // v2 sub ack has no id, match it by topic
+	)
 	if needLogin {
 		e.loginLock.Lock()
 		defer e.loginLock.Unlock()
@@ -215,16 +263,25 @@ func (e *HuobiWs) subscribe(url, topic, symbol string, t wsex.MessageType, needL
 			"ch":     topic,
 		}
 	} else {
+		ackID = strconv.FormatInt(atomic.AddInt64(&requestID, 1), 10)
 		data = map[string]string{
 			"sub": topic,
+			"id":  ackID,
 		}
 	}
 
-	if err := conn.SendJsonMessage(data); err != nil {
+	ack := e.SubscribeAck.Register(ackID)
+	conn.Subscribe(topic, sub)
+	err = conn.SendJsonMessage(data)
+	if err != nil {
+		e.SubscribeAck.Done(ackID, err)
+	} else {
+		err = e.WaitSubscribeAck(ackID, ack)
+	}
+	if err != nil {
+		conn.UnSubscribe(topic, sub)
 		return "", err
 	}
-	conn.Subscribe(sub)
-
 	return topic, nil
 }
 
@@ -267,9 +324,17 @@ func (e *HuobiWs) messageHandler(url string, message []byte) {
 			return
 		}
 	}
+	if res.ID != "" && res.Status != "" {
+		var err error
+		if res.Status != "ok" {
+			err = wsex.ExError{Code: wsex.ErrChannelNotExist, Message: res.ErrMsg, Data: map[string]interface{}{"code": res.ErrCode}}
+		}
+		e.SubscribeAck.Done(res.ID, err)
+		return
+	}
 
 	if res.Topic != "" && res.Code == 0 {
-		topicInfo := e.subTopicInfo[res.Topic]
+		topicInfo := e.topicInfo(res.Topic)
 		switch topicInfo.MessageType {
 		case wsex.MsgTrade:
 			e.handleTrade(url, message, topicInfo)
@@ -294,12 +359,19 @@ func (e *HuobiWs) messageHandler(url string, message []byte) {
 	if res.Rep != "" {
 		if strings.Contains(res.Rep, "mbp") {
 			//get full order book
-			topicInfo := e.subTopicInfo[res.Rep]
+			topicInfo := e.topicInfo(res.Rep)
 			e.handleFullDepth(url, message, topicInfo)
 		}
 	}
 }
 
+// topicInfo the subscription of the topic, the shards read it concurrently
+func (e *HuobiWs) topicInfo(topic string) SubTopic {
+	e.RwLock.RLock()
+	defer e.RwLock.RUnlock()
+	return e.subTopicInfo[topic]
+}
+
 func (e *HuobiWs) reConnectedHandler(url string) {
 	e.BaseExchange.ReConnectedHandler(url, nil)
 }
@@ -330,7 +402,7 @@ func (e *HuobiWs) handleTicker(url string, message []byte, topicInfo SubTopic) {
 		return
 	}
 	ticker := data.parseWsTicker(topicInfo.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
 }
 
 func (e *HuobiWs) handleDepth(url string, message []byte, topicInfo SubTopic) {
@@ -339,17 +411,18 @@ func (e *HuobiWs) handleDepth(url string, message []byte, topicInfo SubTopic) {
 		e.errorHandler(url, fmt.Errorf("[huobiWs] handleTicker - message Unmarshal to ticker error:%v", err))
 		return
 	}
-	if data.Depth.SeqNum > topicInfo.LastUpdateID {
-		res := data.parseOrderBook(topicInfo.Symbol)
-		topicInfo.LastUpdateID = data.Depth.SeqNum
+	// the refresh depth is full data each time, just drop the old one
+	if data.Depth.SeqNum <= topicInfo.LastUpdateID {
+		return
+	}
+	res := data.parseOrderBook(topicInfo.Symbol)
+	topicInfo.LastUpdateID = data.Depth.SeqNum
+	e.RwLock.Lock()
+	if _, ok := e.subTopicInfo[topicInfo.Topic]; ok {
 		e.subTopicInfo[topicInfo.Topic] = topicInfo
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: res})
-	} else {
-		err := wsex.ExError{Code: wsex.ErrInvalidDepth,
-			Message: fmt.Sprintf("[HuobiWs] handleDepth - recv dirty data, new.SeqNum: %v < old.SeqNum: %v ", data.Depth.PrevSeqNum, topicInfo.LastUpdateID),
-			Data:    map[string]interface{}{"symbol": topicInfo.Symbol}}
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: err})
 	}
+	e.RwLock.Unlock()
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgOrderBook, Data: res})
 }
 
 func (e *HuobiWs) handleIncrementalDepth(url string, message []byte, topicInfo SubTopic) {
@@ -358,29 +431,15 @@ func (e *HuobiWs) handleIncrementalDepth(url string, message []byte, topicInfo S
 		e.errorHandler(url, fmt.Errorf("[huobiWs] handleTicker - message Unmarshal to ticker error:%v", err))
 		return
 	}
-
-	symbolOrderBook, ok := e.orderBooks[url]
-	if !ok {
-		symbolOrderBook = &SymbolOrderBook{}
-		e.orderBooks[url] = symbolOrderBook
-	}
-	fullOrderBook, ok := (*symbolOrderBook)[topicInfo.Symbol]
-	if !ok {
-		e.send(url, map[string]string{"req": topicInfo.Topic})
-		(*symbolOrderBook)[topicInfo.Symbol] = &OrderBook{}
-		return
+	delta := book.Delta{
+		Bids: data.Depth.Bids,
+		Asks: data.Depth.Asks,
+		Last: int64(data.Depth.SeqNum),
+		Prev: int64(data.Depth.PrevSeqNum),
+		Time: data.Timestamp,
 	}
-	if fullOrderBook != nil {
-		if fullOrderBook.SeqNum == data.Depth.PrevSeqNum {
-			fullOrderBook.update(data)
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
-		} else if fullOrderBook.SeqNum != 0 {
-			delete(*symbolOrderBook, topicInfo.Symbol)
-			err := wsex.ExError{Code: wsex.ErrInvalidDepth,
-				Message: fmt.Sprintf("[HuobiWs] handleIncrementalDepth - recv dirty data, new.PrevSeqNum: %v != old.SeqNum: %v ", data.Depth.PrevSeqNum, fullOrderBook.SeqNum),
-				Data:    map[string]interface{}{"symbol": fullOrderBook.Symbol}}
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: err})
-		}
+	for _, msg := range e.orderBook(url).Update(topicInfo.Symbol, delta) {
+		e.ConnectionMgr.Publish(url, topicInfo.Topic, msg)
 	}
 }
 
@@ -391,14 +450,39 @@ func (e *HuobiWs) handleFullDepth(url string, message []byte, topicInfo SubTopic
 		e.errorHandler(url, fmt.Errorf("[huobiWs] handleTicker - message Unmarshal to ticker error:%v", err))
 		return
 	}
-	ob := OrderBook{}
-	ob.update(data)
-	symbolOrderBook, ok := e.orderBooks[url]
+	snapshot := book.Snapshot{Bids: data.Depth.Bids, Asks: data.Depth.Asks, Sequence: int64(data.Depth.SeqNum), Time: data.Timestamp}
+	for _, msg := range e.orderBook(url).Snapshot(topicInfo.Symbol, snapshot) {
+		e.ConnectionMgr.Publish(url, topicInfo.Topic, msg)
+	}
+}
+
+// orderBook the local order books of the connection, the snapshot is requested by websocket and received in handleFullDepth
+func (e *HuobiWs) orderBook(url string) *book.Engine {
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	engine, ok := e.orderBooks[url]
 	if !ok {
-		symbolOrderBook = &SymbolOrderBook{}
-		e.orderBooks[url] = symbolOrderBook
+		engine = book.NewEngine(func(symbol string) (*book.Snapshot, error) {
+			e.RwLock.RLock()
+			var depthTopic string
+			for topic, info := range e.subTopicInfo {
+				if info.Symbol == symbol && info.MessageType == wsex.MsgOrderBook && !strings.Contains(topic, "refresh") {
+					depthTopic = topic
+					break
+				}
+			}
+			e.RwLock.RUnlock()
+			if depthTopic != "" {
+				return nil, e.send(url, map[string]string{"req": depthTopic})
+			}
+			return nil, fmt.Errorf("[HuobiWs] orderBook - not found the depth topic of %s", symbol)
+		})
+		engine.SetDepth(e.Option.OrderBookDepth)
+		engine.SetMetrics(e.Option.ExchangeName, e.Option.Metrics)
+		engine.SetCompareInterval(e.Option.OrderBookCheckTime)
+		e.orderBooks[url] = engine
 	}
-	(*symbolOrderBook)[topicInfo.Symbol] = &ob
+	return engine
 }
 
 func (e *HuobiWs) handleTrade(url string, message []byte, topicInfo SubTopic) {
@@ -431,7 +515,7 @@ func (e *HuobiWs) handleTrade(url string, message []byte, topicInfo SubTopic) {
 	} else {
 		ticker.Side = wsex.Buy
 	}
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: ticker})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgTrade, Data: ticker})
 }
 
 func (e *HuobiWs) handleKLine(url string, message []byte, topicInfo SubTopic) {
@@ -441,7 +525,7 @@ func (e *HuobiWs) handleKLine(url string, message []byte, topicInfo SubTopic) {
 		return
 	}
 	kline := data.parseKline(topicInfo.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgKLine, Data: kline})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgKLine, Data: kline})
 }
 
 func (e *HuobiWs) handleBalance(url string, message []byte, topicInfo SubTopic) {
@@ -460,7 +544,7 @@ func (e *HuobiWs) handleBalance(url string, message []byte, topicInfo SubTopic)
 	}
 	balances.Balances[balance.Asset] = balance
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgBalance, Data: balances})
 }
 
 func (e *HuobiWs) handleOrder(url string, message []byte, topicInfo SubTopic) {
@@ -476,7 +560,7 @@ func (e *HuobiWs) handleOrder(url string, message []byte, topicInfo SubTopic) {
 	}
 
 	order := data.parseOrder(topicInfo.Symbol, market)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrder, Data: order})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgOrder, Data: order})
 }
 
 func (e *HuobiWs) sign(timeNow string) (string, error) {
@@ -532,6 +616,13 @@ func (e *HuobiWs) handleAction(message []byte, res Response, url string) bool {
 			e.loginChan <- struct{}{}
 		}
 		return true
+	} else if res.Action == "sub" {
+		var err error
+		if res.Code != 200 {
+			err = wsex.ExError{Code: wsex.ErrChannelNotExist, Message: res.Message, Data: map[string]interface{}{"code": res.Code}}
+		}
+		e.SubscribeAck.Done(res.Topic, err)
+		return true
 	}
 	return false
 }
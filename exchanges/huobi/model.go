@@ -1,6 +1,7 @@
 package huobi
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -17,10 +18,18 @@ type Response struct {
 	Rep    string  `json:"rep"`
 	Ping   float64 `json:"ping"`
 	Code   int     `json:"code"`
+
+	// ack of the sub request, {"id":"1","status":"ok","subbed":"market.btcusdt.trade.detail"}
+	ID      string `json:"id"`
+	Status  string `json:"status"`
+	ErrCode string `json:"err-code"`
+	ErrMsg  string `json:"err-msg"`
+	Message string `json:"message"`
 }
 
 type OrderBookRes struct {
-	Depth struct {
+	Timestamp time.Duration `json:"ts" rep:"ts"`
+	Depth     struct {
 		SeqNum     float64       `json:"seqNum"`
 		PrevSeqNum float64       `json:"prevSeqNum"`
 		Asks       wsex.RawDepth `json:"asks"`
@@ -31,6 +40,7 @@ type OrderBookRes struct {
 func (o OrderBookRes) parseOrderBook(symbol string) wsex.OrderBook {
 	orderBook := wsex.OrderBook{}
 	orderBook.Symbol = symbol
+	orderBook.Timestamp = o.Timestamp
 	for _, ask := range o.Depth.Asks {
 		depthItem, err := ask.ParseRawDepthItem()
 		if err != nil {
@@ -240,6 +250,11 @@ type Order struct {
 	Type        string        `json:"type" open:"type" ws:"type"`
 	ClientID    string        `json:"client-order-id" open:"client-order-id" ws:"clientOrderId"`
 	FillPrice   string        `ws:"tradePrice"`
+	Fee         string        `json:"field-fees" open:"filled-fees"`
+	EventType   string        `ws:"eventType"`
+	Aggressor   bool          `ws:"aggressor"` //true: taker, false: maker
+	StopPrice   string        `json:"stop-price" open:"stop-price" ws:"stopPrice"`
+	Operator    string        `json:"operator" open:"operator"` //gte,lte 触发条件
 }
 type OrderRes struct {
 	Data Order `json:"data"`
@@ -268,6 +283,21 @@ func (o OrderRes) parseOrder(symbol string, market wsex.Market) wsex.Order {
 	case "buy":
 		order.Side = wsex.Buy
 	}
+	if o.Data.Fee != "" {
+		// 买单手续费扣base币,卖单扣quote币
+		order.Fee = o.Data.Fee
+		if order.Side == wsex.Buy {
+			order.FeeCurrency = market.BaseID
+		} else {
+			order.FeeCurrency = market.QuoteID
+		}
+	}
+	if o.Data.EventType == "trade" {
+		order.Liquidity = wsex.Maker
+		if o.Data.Aggressor {
+			order.Liquidity = wsex.Taker
+		}
+	}
 	switch strings.Join(types[1:], "-") {
 	case "limit":
 		order.Type = wsex.LIMIT
@@ -276,6 +306,14 @@ func (o OrderRes) parseOrder(symbol string, market wsex.Market) wsex.Order {
 	case "ioc":
 		order.OrderType = wsex.IOC
 	case "limit-fok":
+	case "stop-limit", "stop-limit-fok":
+		// 买单价格向上突破、卖单价格向下突破为止损,反之为止盈
+		order.Type = wsex.STOP_LIMIT
+		if (order.Side == wsex.Buy) != (o.Data.Operator == "gte") {
+			order.Type = wsex.TAKE_PROFIT_LIMIT
+		}
+		order.StopPrice = o.Data.StopPrice
+		order.TriggerType = wsex.TriggerLast
 	}
 	switch o.Data.State {
 	case "canceled":
@@ -307,21 +345,6 @@ type ResponseEvent struct {
 	Message string `json:"message"`
 }
 
-type OrderBook struct {
-	wsex.OrderBook
-	SeqNum     float64
-	PrevSeqNum float64
-}
-
-func (o *OrderBook) update(bookData OrderBookRes) {
-	o.Bids = o.Bids.Update(bookData.Depth.Bids, true)
-	o.Asks = o.Asks.Update(bookData.Depth.Asks, false)
-	o.SeqNum = bookData.Depth.SeqNum
-	o.PrevSeqNum = bookData.Depth.PrevSeqNum
-}
-
-type SymbolOrderBook map[string]*OrderBook
-
 type PingAction struct {
 	Data struct {
 		Timestamp time.Duration `json:"ts"`
@@ -413,3 +436,32 @@ func (k WsKlineRes) parseKline(symbol string) wsex.KLine {
 	}
 	return kline
 }
+
+type TradingFeeRes struct {
+	Data []struct {
+		Symbol          string `json:"symbol"`
+		ActualMakerRate string `json:"actualMakerRate"`
+		ActualTakerRate string `json:"actualTakerRate"`
+	} `json:"data"`
+}
+
+type BatchOrderRes struct {
+	Data []struct {
+		OrderID       json.Number `json:"order-id"`
+		ClientOrderID string      `json:"client-order-id"`
+		ErrCode       string      `json:"err-code"`
+		ErrMsg        string      `json:"err-msg"`
+	} `json:"data"`
+}
+
+type BatchCancelRes struct {
+	Data struct {
+		Success []string `json:"success"`
+		Failed  []struct {
+			OrderID       string `json:"order-id"`
+			ClientOrderID string `json:"client-order-id"`
+			ErrCode       string `json:"err-code"`
+			ErrMsg        string `json:"err-msg"`
+		} `json:"failed"`
+	} `json:"data"`
+}
@@ -0,0 +1,125 @@
+/*
+@Time : 2021/6/15 11:02 上午
+@Author : shiguantian
+@File : latency
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+// LatencyBuckets the default upper bounds of the latency histogram buckets
+var LatencyBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond, time.Second, 5 * time.Second,
+}
+
+type LatencyKey struct {
+	Exchange string
+	Channel  string
+	Stage    wsex.LatencyStage
+}
+
+// Histogram Counts[i] is the count of the latencies <= Buckets[i], the last count is the one beyond all buckets
+type Histogram struct {
+	Buckets []time.Duration
+	Counts  []uint64
+	Count   uint64
+	Sum     time.Duration
+	Max     time.Duration
+}
+
+func (h *Histogram) observe(latency time.Duration) {
+	i := sort.Search(len(h.Buckets), func(i int) bool { return latency <= h.Buckets[i] })
+	h.Counts[i]++
+	h.Count++
+	h.Sum += latency
+	if latency > h.Max {
+		h.Max = latency
+	}
+}
+
+// Mean the average latency
+func (h Histogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile the upper bound of the bucket containing the q quantile, eg: Quantile(0.99), Max if it's beyond all buckets
+func (h Histogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(h.Count) + 0.5)
+	if rank == 0 {
+		rank = 1
+	}
+	var count uint64
+	for i, c := range h.Counts {
+		count += c
+		if count >= rank {
+			if i < len(h.Buckets) {
+				return h.Buckets[i]
+			}
+			break
+		}
+	}
+	return h.Max
+}
+
+// LatencyHistograms the in-memory wsex.Metrics, which keeps the latency histograms per exchange, channel and stage.
+// pass it by Options.Metrics, and check Snapshot periodically to detect the degraded feeds
+type LatencyHistograms struct {
+	wsex.NopMetrics
+	mu         sync.Mutex
+	buckets    []time.Duration
+	histograms map[LatencyKey]*Histogram
+}
+
+// NewLatencyHistograms the buckets must be ascending, nil means LatencyBuckets
+func NewLatencyHistograms(buckets []time.Duration) *LatencyHistograms {
+	if buckets == nil {
+		buckets = LatencyBuckets
+	}
+	return &LatencyHistograms{buckets: buckets, histograms: make(map[LatencyKey]*Histogram)}
+}
+
+func (l *LatencyHistograms) ObserveLatency(exchange, channel string, stage wsex.LatencyStage, latency time.Duration) {
+	key := LatencyKey{Exchange: exchange, Channel: channel, Stage: stage}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.histograms[key]
+	if !ok {
+		h = &Histogram{Buckets: l.buckets, Counts: make([]uint64, len(l.buckets)+1)}
+		l.histograms[key] = h
+	}
+	h.observe(latency)
+}
+
+// Snapshot a copy of the histograms
+func (l *LatencyHistograms) Snapshot() map[LatencyKey]Histogram {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	snapshot := make(map[LatencyKey]Histogram, len(l.histograms))
+	for key, h := range l.histograms {
+		c := *h
+		c.Counts = append([]uint64(nil), h.Counts...)
+		snapshot[key] = c
+	}
+	return snapshot
+}
+
+// Reset remove all histograms, eg: after reporting the snapshot
+func (l *LatencyHistograms) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.histograms = make(map[LatencyKey]*Histogram)
+}
@@ -0,0 +1,66 @@
+/*
+@Time : 2021/6/15 2:40 下午
+@Author : shiguantian
+@File : latency_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+func TestLatencyHistograms(t *testing.T) {
+	h := NewLatencyHistograms([]time.Duration{10 * time.Millisecond, 100 * time.Millisecond})
+	for _, latency := range []time.Duration{time.Millisecond, 5 * time.Millisecond, 50 * time.Millisecond, time.Second} {
+		h.ObserveLatency("binance", "trade", wsex.LatencyFeed, latency)
+	}
+	snapshot := h.Snapshot()
+	hist, ok := snapshot[LatencyKey{Exchange: "binance", Channel: "trade", Stage: wsex.LatencyFeed}]
+	if !ok {
+		t.Fatal("the histogram should exist")
+	}
+	if hist.Count != 4 || hist.Counts[0] != 2 || hist.Counts[1] != 1 || hist.Counts[2] != 1 || hist.Max != time.Second {
+		t.Fatalf("unexpected histogram %+v", hist)
+	}
+	if q := hist.Quantile(0.5); q != 10*time.Millisecond {
+		t.Fatalf("unexpected p50 %v", q)
+	}
+	if q := hist.Quantile(0.99); q != time.Second {
+		t.Fatalf("unexpected p99 %v", q)
+	}
+}
+
+func TestConnection_ObserveLatency(t *testing.T) {
+	mgr := NewConnectionManager()
+	metrics := NewLatencyHistograms(nil)
+	mgr.SetExchange("huobipro")
+	mgr.SetMetrics(metrics)
+	conn, _ := mgr.GetConnection("wss://api.huobi.pro/ws", func(url string) (*Connection, error) {
+		return NewConnection(), nil
+	})
+	ch := make(wsex.MessageChan, 1)
+	conn.Subscribe("market.btcusdt.mbp.150", ch)
+
+	eventTime := time.Duration(time.Now().Add(-200*time.Millisecond).UnixNano() / 1e6)
+	conn.Publish("market.btcusdt.mbp.150", wsex.Message{Type: wsex.MsgOrderBook, Data: wsex.OrderBook{Symbol: "BTC/USDT", Timestamp: eventTime}})
+	msg, ok := recv(ch)
+	if !ok {
+		t.Fatal("the subscriber should receive the message")
+	}
+	if msg.Time != eventTime || msg.PublishTime < msg.RecvTime || msg.RecvTime < msg.Time {
+		t.Fatalf("unexpected stamp %+v", msg)
+	}
+
+	snapshot := metrics.Snapshot()
+	feed := snapshot[LatencyKey{Exchange: "huobipro", Channel: "orderbook", Stage: wsex.LatencyFeed}]
+	if feed.Count != 1 || feed.Max < 200*time.Millisecond {
+		t.Fatalf("unexpected feed latency %+v", feed)
+	}
+	if process := snapshot[LatencyKey{Exchange: "huobipro", Channel: "orderbook", Stage: wsex.LatencyProcess}]; process.Count != 1 {
+		t.Fatalf("unexpected process latency %+v", process)
+	}
+}
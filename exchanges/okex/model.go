@@ -7,6 +7,7 @@
 package okex
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -62,9 +63,6 @@ func (o *OrderBook) update(bookData DepthData) {
 	o.Asks = o.Asks.Update(bookData.Asks, false)
 }
 
-// OrderBook of one symbol
-type SymbolOrderBook map[string]*OrderBook
-
 type DepthData struct {
 	Checksum  int32         `json:"checksum"`
 	Symbol    string        `json:"instrument_id"`
@@ -191,6 +189,9 @@ type Order struct {
 	State          string `json:"state"`      //-2:Failed,-1:Canceled,0:Open ,1:Partially Filled, 2:Fully Filled,3:Submitting,4:Cancelling
 	Timestamp      string `json:"timestamp"`
 	CreatedAt      string `json:"created_at"`
+	Fee            string `json:"fee"`          //negative: charged, positive: rebate
+	FeeCurrency    string `json:"fee_currency"` //
+	ExecType       string `json:"exec_type"`    //T: taker, M: maker, only pushed by websocket
 }
 
 func (o Order) parseOrder(symbol string) wsex.Order {
@@ -204,6 +205,23 @@ func (o Order) parseOrder(symbol string) wsex.Order {
 		Cost:       o.FilledNotional,
 		CreateTime: ParseIsoTime(o.CreatedAt, nil),
 	}
+	if o.Fee != "" {
+		// okex以负数表示扣除的手续费,统一为正数表示扣费
+		if strings.HasPrefix(o.Fee, "-") {
+			order.Fee = o.Fee[1:]
+		} else if SafeParseFloat(o.Fee) != 0 {
+			order.Fee = "-" + o.Fee
+		} else {
+			order.Fee = o.Fee
+		}
+		order.FeeCurrency = strings.ToUpper(o.FeeCurrency)
+	}
+	switch o.ExecType {
+	case "T":
+		order.Liquidity = wsex.Taker
+	case "M":
+		order.Liquidity = wsex.Maker
+	}
 	switch o.Side {
 	case "sell":
 		order.Side = wsex.Sell
@@ -245,6 +263,112 @@ func (o Order) parseOrder(symbol string) wsex.Order {
 	return order
 }
 
+// AlgoOrder 策略委托单
+type AlgoOrder struct {
+	Symbol       string `json:"instrument_id"`
+	AlgoId       string `json:"algo_id"`
+	OrderType    string `json:"order_type"` //1:计划委托 2:跟踪委托
+	Side         string `json:"side"`
+	Size         string `json:"size"`
+	Status       string `json:"status"` //1:待生效 2:已生效 3:已撤销 4:部分生效 5:暂停生效 6:委托失败
+	TriggerPrice string `json:"trigger_price"`
+	AlgoPrice    string `json:"algo_price"`
+	AlgoType     string `json:"algo_type"` //1:限价 2:市价
+	CreatedAt    string `json:"created_at"`
+}
+
+// parseOrder 计划委托按触发价自动判断方向,不区分止损和止盈,统一为STOP_LIMIT/STOP_MARKET
+func (o AlgoOrder) parseOrder(symbol string) wsex.Order {
+	order := wsex.Order{
+		ID:          o.AlgoId,
+		Symbol:      symbol,
+		Price:       o.AlgoPrice,
+		Amount:      o.Size,
+		StopPrice:   o.TriggerPrice,
+		TriggerType: wsex.TriggerLast,
+		CreateTime:  ParseIsoTime(o.CreatedAt, nil),
+	}
+	switch o.Side {
+	case "sell":
+		order.Side = wsex.Sell
+	case "buy":
+		order.Side = wsex.Buy
+	}
+	switch {
+	case o.OrderType == "2":
+		order.Type = wsex.TRAILING_STOP
+	case o.AlgoType == "2":
+		order.Type = wsex.STOP_MARKET
+	default:
+		order.Type = wsex.STOP_LIMIT
+	}
+	switch o.Status {
+	case "1", "5":
+		order.Status = wsex.Open
+	case "2":
+		order.Status = wsex.Close
+	case "3", "6":
+		order.Status = wsex.Canceled
+	case "4":
+		order.Status = wsex.Partial
+	default:
+		order.Status = wsex.OrderStatusUnKnown
+	}
+	return order
+}
+
+// algoOrderType order_type of the algo order
+func algoOrderType(t wsex.TradeType) string {
+	if t == wsex.TRAILING_STOP {
+		return "2"
+	}
+	return "1"
+}
+
 type OrderRes struct {
 	Data []Order `json:"data"`
 }
+
+type TradeFee struct {
+	Maker     string `json:"maker"`
+	Taker     string `json:"taker"`
+	Timestamp string `json:"timestamp"`
+}
+
+func (f TradeFee) parseTradingFee(symbol string) wsex.TradingFee {
+	return wsex.TradingFee{
+		Symbol: symbol,
+		Maker:  SafeParseFloat(f.Maker),
+		Taker:  SafeParseFloat(f.Taker),
+	}
+}
+
+// BatchResult result of one order in batch_orders/cancel_batch_orders, also the response of amend_order
+type BatchResult struct {
+	OrderId      string      `json:"order_id"`
+	ClientOId    string      `json:"client_oid"`
+	Result       bool        `json:"result"`
+	ErrorCode    interface{} `json:"error_code"`
+	ErrorMessage string      `json:"error_message"`
+}
+
+func (r BatchResult) err(errors map[string]int) error {
+	code := fmt.Sprintf("%v", r.ErrorCode)
+	if r.Result || code == "0" || code == "" || code == "<nil>" {
+		return nil
+	}
+	if errCode, ok := errors[code]; ok {
+		return wsex.ExError{Code: errCode, Message: r.ErrorMessage}
+	}
+	return wsex.ExError{Code: wsex.UnHandleError, Message: fmt.Sprintf("code:%v msg:%v", code, r.ErrorMessage)}
+}
+
+// findBatchResult batch responses are keyed by instrument, eg: btc-usdt or btc_usdt
+func findBatchResult(items map[string][]BatchResult, symbolID string) []BatchResult {
+	for key, data := range items {
+		if strings.EqualFold(strings.Replace(key, "_", "-", -1), symbolID) {
+			return data
+		}
+	}
+	return nil
+}
@@ -28,6 +28,9 @@ type OkexRest struct {
 
 func (e *OkexRest) Init(option wsex.Options) {
 	e.Option = option
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.Okex
+	}
 	e.errors = map[string]int{
 		"30009": wsex.ErrExchangeSystem,
 		"36216": wsex.ErrOrderNotFound,
@@ -256,20 +259,59 @@ func (e *OkexRest) FetchBalance() (balances map[string]wsex.Balance, err error)
 }
 
 func (e *OkexRest) CreateOrder(symbol string, price, amount float64, side wsex.Side, tradeType wsex.TradeType, orderType wsex.OrderType, useClientID bool) (order wsex.Order, err error) {
-	market, err := e.GetMarket(symbol)
+	return e.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Price: price, Amount: amount, Side: side, Type: tradeType, OrderType: orderType, UseClientID: useClientID})
+}
+
+func (e *OkexRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
+	market, err := e.GetMarket(req.Symbol)
 	if err != nil {
 		return
 	}
-	params := url.Values{}
+	req = req.Normalize()
+	if req.Type.IsConditional() {
+		return e.placeAlgoOrder(market, req)
+	}
+	params, err := e.orderParams(market, req)
+	if err != nil {
+		return
+	}
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/api/spot/v3/orders", params, http.Header{})
+	if err != nil {
+		return
+	}
+
+	type response struct {
+		ID  string `json:"order_id"`
+		CID string `json:"client_oid"`
+	}
+	data := response{}
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	order.ID = data.ID
+	order.ClientID = data.CID
+	return
+}
+
+func (e *OkexRest) orderParams(market wsex.Market, req wsex.OrderRequest) (params url.Values, err error) {
+	if err = exchanges.CheckSpotRequest("okex spot", req); err != nil {
+		return
+	}
+	if req = req.Normalize(); req.Type.IsConditional() {
+		err = exchanges.ErrNotSupport("okex spot", fmt.Sprintf("%s order in batch", req.Type))
+		return
+	}
+	params = url.Values{}
 	params.Set("instrument_id", market.SymbolID)
-	params.Set("price", utils.Round(price, market.PricePrecision, false))
-	params.Set("size", utils.Round(amount, market.AmountPrecision, false))
-	if side == wsex.Sell {
+	params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+	params.Set("size", utils.Round(req.Amount, market.AmountPrecision, false))
+	if req.Side == wsex.Sell {
 		params.Set("side", "sell")
-	} else if side == wsex.Buy {
+	} else if req.Side == wsex.Buy {
 		params.Set("side", "buy")
 	}
-	switch orderType {
+	switch req.OrderType {
 	case wsex.PostOnly:
 		params.Set("order_type", "1")
 	case wsex.FOK:
@@ -277,35 +319,155 @@ func (e *OkexRest) CreateOrder(symbol string, price, amount float64, side wsex.S
 	case wsex.IOC:
 		params.Set("order_type", "3")
 	}
-	switch tradeType {
+	switch req.Type {
 	case wsex.MARKET:
 		params.Set("type", "market")
-		params.Set("notional", fmt.Sprintf("%v", price*amount))
+		if req.QuoteAmount > 0 {
+			params.Set("notional", utils.Round(req.QuoteAmount, market.PricePrecision, false))
+		} else {
+			params.Set("notional", fmt.Sprintf("%v", req.Price*req.Amount))
+		}
 	default:
 		params.Set("type", "limit")
 	}
-	if useClientID {
-		params.Set("client_oid", GenerateOrderClientId(e.Option.ClientOrderIDPrefix, 32))
+	if clientID := req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32); clientID != "" {
+		params.Set("client_oid", clientID)
+	}
+	exchanges.MergeParams(params, req.Params)
+	return
+}
+
+// placeAlgoOrder stop and take-profit orders are placed as trigger orders, okex decides the direction by the trigger price
+func (e *OkexRest) placeAlgoOrder(market wsex.Market, req wsex.OrderRequest) (order wsex.Order, err error) {
+	if err = exchanges.CheckSpotRequest("okex spot", req); err != nil {
+		return
+	}
+	if err = exchanges.CheckConditionalRequest("okex spot", req, wsex.TriggerLast); err != nil {
+		return
+	}
+	switch {
+	case req.QuoteAmount > 0:
+		err = exchanges.ErrNotSupport("okex spot", "quote amount of conditional order")
+	case req.OrderType == wsex.PostOnly || req.OrderType == wsex.FOK || req.OrderType == wsex.IOC:
+		err = exchanges.ErrNotSupport("okex spot", "time in force of conditional order")
+	case req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32) != "":
+		err = exchanges.ErrNotSupport("okex spot", "client order id of conditional order")
+	case req.Type == wsex.TRAILING_STOP && req.ActivationPrice <= 0:
+		err = exchanges.ErrNotSupport("okex spot", "trailing stop without activation price")
 	}
-	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/api/spot/v3/orders", params, http.Header{})
 	if err != nil {
 		return
 	}
-
-	type response struct {
-		ID  string `json:"order_id"`
-		CID string `json:"client_oid"`
+	params := url.Values{}
+	params.Set("instrument_id", market.SymbolID)
+	params.Set("mode", "1")
+	params.Set("order_type", algoOrderType(req.Type))
+	params.Set("size", utils.Round(req.Amount, market.AmountPrecision, false))
+	if req.Side == wsex.Sell {
+		params.Set("side", "sell")
+	} else {
+		params.Set("side", "buy")
+	}
+	if req.Type == wsex.TRAILING_STOP {
+		params.Set("callback_rate", strconv.FormatFloat(req.CallbackRate, 'f', -1, 64))
+		params.Set("trigger_price", utils.Round(req.ActivationPrice, market.PricePrecision, false))
+	} else {
+		params.Set("trigger_price", utils.Round(req.StopPrice, market.PricePrecision, false))
+		if req.Type.IsLimit() {
+			params.Set("algo_type", "1")
+			params.Set("algo_price", utils.Round(req.Price, market.PricePrecision, false))
+		} else {
+			params.Set("algo_type", "2")
+		}
+	}
+	exchanges.MergeParams(params, req.Params)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/api/spot/v3/order_algo", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data struct {
+		AlgoId string `json:"algo_id"`
 	}
-	data := response{}
 	if err = json.Unmarshal(res, &data); err != nil {
 		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
 		return
 	}
-	order.ID = data.ID
-	order.ClientID = data.CID
+	order.ID = data.AlgoId
 	return
 }
 
+// CreateOrders place orders by /api/spot/v3/batch_orders, 10 orders of 4 instruments at most per request
+func (e *OkexRest) CreateOrders(requests []wsex.OrderRequest) (results []wsex.OrderResult, err error) {
+	const batchSize, maxInstruments = 10, 4
+	results = make([]wsex.OrderResult, len(requests))
+	markets := make([]wsex.Market, len(requests))
+	batch := make([]map[string]interface{}, len(requests))
+	for i, req := range requests {
+		market, err := e.GetMarket(req.Symbol)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		markets[i] = market
+		params, err := e.orderParams(market, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		batch[i] = utils.UrlValuesToMap(params)
+	}
+	var indexes []int
+	instruments := make(map[string]bool)
+	for i := range requests {
+		if batch[i] == nil {
+			continue
+		}
+		if len(indexes) == batchSize || (!instruments[markets[i].SymbolID] && len(instruments) == maxInstruments) {
+			e.createOrderBatch(requests, markets, batch, indexes, results)
+			indexes = nil
+			instruments = make(map[string]bool)
+		}
+		indexes = append(indexes, i)
+		instruments[markets[i].SymbolID] = true
+	}
+	if len(indexes) > 0 {
+		e.createOrderBatch(requests, markets, batch, indexes, results)
+	}
+	return results, nil
+}
+
+func (e *OkexRest) createOrderBatch(requests []wsex.OrderRequest, markets []wsex.Market, batch []map[string]interface{}, indexes []int, results []wsex.OrderResult) {
+	orders := make([]map[string]interface{}, len(indexes))
+	groups := make(map[string][]int)
+	for j, i := range indexes {
+		orders[j] = batch[i]
+		groups[markets[i].SymbolID] = append(groups[markets[i].SymbolID], i)
+	}
+	params, err := utils.RawJsonValues(orders)
+	if err == nil {
+		var items map[string][]BatchResult
+		if items, err = e.fetchBatch("/api/spot/v3/batch_orders", params); err == nil {
+			for symbolID, group := range groups {
+				data := findBatchResult(items, symbolID)
+				for j, i := range group {
+					if j >= len(data) {
+						results[i].Err = wsex.ExError{Code: wsex.ErrDataParse, Message: "order missing in batch response"}
+						continue
+					}
+					results[i].Err = data[j].err(e.errors)
+					results[i].Order.ID = data[j].OrderId
+					results[i].Order.ClientID = data[j].ClientOId
+					results[i].Order.Symbol = requests[i].Symbol
+				}
+			}
+			return
+		}
+	}
+	for _, i := range indexes {
+		results[i].Err = err
+	}
+}
+
 func (e *OkexRest) CancelOrder(symbol, orderID string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -319,16 +481,140 @@ func (e *OkexRest) CancelOrder(symbol, orderID string) (err error) {
 	return err
 }
 
+// AmendOrder by /api/spot/v3/amend_order, the order is amended asynchronously and kept if amend fails
+func (e *OkexRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (result wsex.AmendResult, err error) {
+	result.Method = wsex.AmendInPlace
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	if IsClientOrderID(orderID, e.Option.ClientOrderIDPrefix) {
+		params.Set("client_oid", orderID)
+	} else {
+		params.Set("order_id", orderID)
+	}
+	if newPrice != 0 {
+		params.Set("new_price", utils.Round(newPrice, market.PricePrecision, false))
+	}
+	if newAmount != 0 {
+		params.Set("new_size", utils.Round(newAmount, market.AmountPrecision, false))
+	}
+	params.Set("cancel_on_fail", "0")
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/api/spot/v3/amend_order/"+market.SymbolID, params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data BatchResult
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	if err = data.err(e.errors); err != nil {
+		return
+	}
+	result.Order = wsex.Order{
+		ID:       data.OrderId,
+		ClientID: data.ClientOId,
+		Symbol:   market.Symbol,
+		Price:    params.Get("new_price"),
+		Amount:   params.Get("new_size"),
+	}
+	return
+}
+
+// CancelOrders cancel orders by /api/spot/v3/cancel_batch_orders, 10 orders at most per request
+func (e *OkexRest) CancelOrders(symbol string, orderIDs []string) (results []wsex.CancelResult, err error) {
+	const batchSize = 10
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	results = make([]wsex.CancelResult, len(orderIDs))
+	// order ids and client order ids can not be mixed in one request
+	var ids, clientIDs []int
+	for i, orderID := range orderIDs {
+		results[i].OrderID = orderID
+		if IsClientOrderID(orderID, e.Option.ClientOrderIDPrefix) {
+			clientIDs = append(clientIDs, i)
+		} else {
+			ids = append(ids, i)
+		}
+	}
+	for _, group := range [][]int{ids, clientIDs} {
+		for start := 0; start < len(group); start += batchSize {
+			end := start + batchSize
+			if end > len(group) {
+				end = len(group)
+			}
+			e.cancelOrderBatch(market, group[start:end], results)
+		}
+	}
+	return results, nil
+}
+
+func (e *OkexRest) cancelOrderBatch(market wsex.Market, indexes []int, results []wsex.CancelResult) {
+	key := "order_ids"
+	if IsClientOrderID(results[indexes[0]].OrderID, e.Option.ClientOrderIDPrefix) {
+		key = "client_oids"
+	}
+	orderIDs := make([]string, len(indexes))
+	for j, i := range indexes {
+		orderIDs[j] = results[i].OrderID
+	}
+	params, err := utils.RawJsonValues([]map[string]interface{}{{"instrument_id": market.SymbolID, key: orderIDs}})
+	if err == nil {
+		var items map[string][]BatchResult
+		if items, err = e.fetchBatch("/api/spot/v3/cancel_batch_orders", params); err == nil {
+			// the results are matched by the order id or client order id, not by the position
+			found := make(map[string]BatchResult)
+			for _, item := range findBatchResult(items, market.SymbolID) {
+				if item.OrderId != "" {
+					found[item.OrderId] = item
+				}
+				if item.ClientOId != "" {
+					found[item.ClientOId] = item
+				}
+			}
+			for _, i := range indexes {
+				item, ok := found[results[i].OrderID]
+				if !ok {
+					results[i].Err = wsex.ExError{Code: wsex.ErrDataParse, Message: "order missing in batch response"}
+					continue
+				}
+				results[i].Err = item.err(e.errors)
+			}
+			return
+		}
+	}
+	for _, i := range indexes {
+		results[i].Err = err
+	}
+}
+
+func (e *OkexRest) fetchBatch(function string, params url.Values) (items map[string][]BatchResult, err error) {
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, function, params, http.Header{})
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(res, &items); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+	}
+	return
+}
+
 func (e *OkexRest) CancelAllOrders(symbol string) (err error) {
 	for {
 		orders, err := e.FetchOpenOrders(symbol, 1, 10)
 		if err != nil || len(orders) == 0 {
 			break
 		}
-		for _, order := range orders {
-			_ = e.CancelOrder(order.ID, symbol)
-			time.Sleep(time.Millisecond * 200)
+		orderIDs := make([]string, len(orders))
+		for i, order := range orders {
+			orderIDs[i] = order.ID
 		}
+		_, _ = e.CancelOrders(symbol, orderIDs)
+		time.Sleep(time.Millisecond * 200)
 	}
 	return
 }
@@ -382,6 +668,84 @@ func (e *OkexRest) FetchOpenOrders(symbol string, pageIndex, pageSize int) (orde
 	return
 }
 
+//FetchConditionalOrders : 获取待生效的计划委托和跟踪委托
+func (e *OkexRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	for _, orderType := range []string{"1", "2"} {
+		params := url.Values{}
+		params.Set("instrument_id", market.SymbolID)
+		params.Set("order_type", orderType)
+		params.Set("status", "1")
+		res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/api/spot/v3/algo", params, http.Header{})
+		if err != nil {
+			return nil, err
+		}
+		var data struct {
+			Spot []AlgoOrder `json:"spot"`
+		}
+		if err = json.Unmarshal(res, &data); err != nil {
+			return nil, wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		}
+		for _, o := range data.Spot {
+			orders = append(orders, o.parseOrder(market.Symbol))
+		}
+	}
+	return
+}
+
+// CancelConditionalOrder order_type is required by cancel_batch_algos, so the order is looked up first
+func (e *OkexRest) CancelConditionalOrder(symbol, orderID string) (err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	orders, err := e.FetchConditionalOrders(symbol)
+	if err != nil {
+		return
+	}
+	orderType := ""
+	for _, order := range orders {
+		if order.ID == orderID {
+			orderType = algoOrderType(order.Type)
+		}
+	}
+	if orderType == "" {
+		return wsex.ExError{Code: wsex.ErrInvalidOrder, Message: fmt.Sprintf("conditional order %s not found", orderID)}
+	}
+	params, err := utils.RawJsonValues(map[string]interface{}{"instrument_id": market.SymbolID, "algo_ids": []string{orderID}, "order_type": orderType})
+	if err != nil {
+		return
+	}
+	_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/api/spot/v3/cancel_batch_algos", params, http.Header{})
+	return
+}
+
+//FetchTradingFees :
+func (e *OkexRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("instrument_id", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/api/spot/v3/trade_fee", params, http.Header{})
+	if err != nil {
+		return
+	}
+
+	var data TradeFee
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+
+	fee = data.parseTradingFee(market.Symbol)
+	return
+}
+
 func (e *OkexRest) Sign(access, method, function string, param url.Values, header http.Header) (request exchanges.Request) {
 	request.Method = method
 	request.Headers = header
@@ -11,7 +11,6 @@ import (
 	"compress/flate"
 	"encoding/json"
 	"fmt"
-	"hash/crc32"
 	"io/ioutil"
 	"log"
 	"strings"
@@ -21,6 +20,7 @@ import (
 	"github.com/shiguantian/wsex/exchanges"
 
 	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/book"
 
 	"github.com/shiguantian/wsex/exchanges/websocket"
 	. "github.com/shiguantian/wsex/utils"
@@ -30,7 +30,7 @@ import (
 
 type OkexWs struct {
 	exchanges.BaseExchange
-	orderBooks map[string]*SymbolOrderBook
+	orderBooks map[string]map[string]*book.Engine // key: ws url, table
 	errors     map[int]wsex.ExError
 	loginLock  sync.Mutex
 	loginChan  chan struct{}
@@ -40,7 +40,7 @@ type OkexWs struct {
 func (e *OkexWs) Init(option wsex.Options) {
 	e.BaseExchange.Init()
 	e.Option = option
-	e.orderBooks = make(map[string]*SymbolOrderBook)
+	e.orderBooks = make(map[string]map[string]*book.Engine)
 	e.errors = map[int]wsex.ExError{
 		30040: wsex.ExError{Code: wsex.ErrChannelNotExist},
 		30008: wsex.ExError{Code: wsex.ErrAuthFailed},
@@ -51,6 +51,12 @@ func (e *OkexWs) Init(option wsex.Options) {
 	e.loginLock = sync.Mutex{}
 	e.loginChan = make(chan struct{})
 	e.isLogin = false
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.Okex
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 	if e.Option.WsHost == "" {
 		e.Option.WsHost = "wss://real.okex.com:8443/ws/v3"
 	}
@@ -115,19 +121,59 @@ func (e *OkexWs) SubscribeOrder(symbol string, sub wsex.MessageChan) (string, er
 }
 
 func (e *OkexWs) UnSubscribe(event string, sub wsex.MessageChan) error {
-	conn, err := e.ConnectionMgr.GetConnection(e.Option.WsHost, nil)
+	var (
+		conn *exchanges.Connection
+		err  error
+	)
+	if strings.HasPrefix(event, "spot/account:") || strings.HasPrefix(event, "spot/order:") {
+		conn, err = e.ConnectionMgr.GetConnection(e.Option.WsHost, nil)
+	} else {
+		conn, err = e.ConnectionMgr.FindShard(e.Option.WsHost, event)
+	}
 	if err != nil {
 		return err
 	}
-	if err := e.send(conn, UnSubscribeStream(event)); err != nil {
+	if !conn.UnSubscribe(event, sub) {
+		return nil
+	}
+	if !strings.HasPrefix(event, "spot/account:") {
+		if err := e.send(conn, UnSubscribeStream(event)); err != nil {
+			return err
+		}
+		// close the empty shards, the topics are not moved
+		return e.ConnectionMgr.Rebalance(e.Option.WsHost, nil)
+	}
+	// the account channel is subscribed by currency, keep the ones still used by other symbols
+	currencies := e.accountCurrencies(conn)
+	market, err := e.GetMarketByID(strings.TrimPrefix(event, "spot/account:"))
+	if err != nil {
 		return err
 	}
-
-	conn.UnSubscribe(sub)
-
+	for _, currency := range []string{market.BaseID, market.QuoteID} {
+		if !currencies[currency] {
+			if err := e.send(conn, UnSubscribeStream(fmt.Sprintf("spot/account:%s", currency))); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
+// accountCurrencies currencies of the subscribed account topics
+func (e *OkexWs) accountCurrencies(conn *exchanges.Connection) map[string]bool {
+	currencies := make(map[string]bool)
+	for _, topic := range conn.Topics() {
+		if !strings.HasPrefix(topic, "spot/account:") {
+			continue
+		}
+		if market, err := e.GetMarketByID(strings.TrimPrefix(topic, "spot/account:")); err == nil {
+			currencies[market.BaseID] = true
+			currencies[market.QuoteID] = true
+		}
+	}
+	return currencies
+}
+
 func (e *OkexWs) Connect(url string) (*exchanges.Connection, error) {
 	conn := exchanges.NewConnection()
 	err := conn.Connect(
@@ -156,7 +202,13 @@ func (e *OkexWs) subscribe(url, table, symbol string, needLogin bool, sub wsex.M
 		return "", err
 	}
 	topic := fmt.Sprintf("%s:%s", table, market.SymbolID)
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	var conn *exchanges.Connection
+	if needLogin {
+		// the login state belongs to the connection, so private topics stay on one connection
+		conn, err = e.ConnectionMgr.GetConnection(url, e.Connect)
+	} else {
+		conn, err = e.ConnectionMgr.GetShard(url, topic, sub, e.Connect)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -177,18 +229,35 @@ func (e *OkexWs) subscribe(url, table, symbol string, needLogin bool, sub wsex.M
 		}
 	}
 
+	channels := []string{topic}
 	if table == "spot/account" {
-		e.send(conn, SubscribeStream(fmt.Sprintf("%s:%s", table, market.BaseID)))
-		e.send(conn, SubscribeStream(fmt.Sprintf("%s:%s", table, market.QuoteID)))
-	} else {
-		if err := e.send(conn, SubscribeStream(topic)); err != nil {
-			return "", err
+		channels = []string{fmt.Sprintf("%s:%s", table, market.BaseID), fmt.Sprintf("%s:%s", table, market.QuoteID)}
+	}
+	acks := make([]<-chan error, len(channels))
+	for i, channel := range channels {
+		acks[i] = e.SubscribeAck.Register(channel)
+	}
+	conn.Subscribe(topic, sub)
+	err = e.send(conn, SubscribeStream(channels...))
+	for i, channel := range channels {
+		if err != nil {
+			e.SubscribeAck.Done(channel, err)
+			continue
 		}
+		err = e.WaitSubscribeAck(channel, acks[i])
+	}
+	if err != nil {
+		conn.UnSubscribe(topic, sub)
+		return "", err
 	}
-	conn.Subscribe(sub)
 	return topic, nil
 }
 
+// publish send the message to the subscribers of the table of the instrument
+func (e *OkexWs) publish(url, table, symbolID string, msg wsex.Message) {
+	e.ConnectionMgr.Publish(url, fmt.Sprintf("%s:%s", table, symbolID), msg)
+}
+
 func (e *OkexWs) send(conn *exchanges.Connection, data Stream) (err error) {
 	if conn == nil {
 		return errors.New("connect session is nil")
@@ -212,13 +281,21 @@ func (e *OkexWs) messageHandler(url string, message []byte) {
 	}
 
 	if res.Event == "error" {
+		// the error response has no channel field, but the message contains it, eg: Channel spot/ticker:BTC-USDX doesn't exist
+		err := e.handleError(res)
+		if e.SubscribeAck.DoneMatch(func(channel string) bool { return strings.Contains(res.Message, channel) }, err) {
+			return
+		}
 		e.errorHandler(url, fmt.Errorf("[OkexWs] messageHandler - business errcode:%v errmsg:%v", res.ErrorCode, res.Message))
-		e.ConnectionMgr.Publish(url, wsex.ErrorMessage(e.handleError(res)))
+		e.ConnectionMgr.Broadcast(url, wsex.ErrorMessage(err))
 		return
 	} else if res.Event == "login" {
 		e.isLogin = true
 		e.loginChan <- struct{}{}
 		return
+	} else if res.Event == "subscribe" {
+		e.SubscribeAck.Done(res.Channel, nil)
+		return
 	} else if res.Event != "" {
 		log.Printf("[OkexWs] messageHandler - op:%v channel:%s success\n", res.Event, res.Channel)
 		return
@@ -226,14 +303,14 @@ func (e *OkexWs) messageHandler(url string, message []byte) {
 
 	switch res.Table {
 	case "spot/depth_l2_tbt", "spot/depth", "spot/depth5":
-		e.handleDepth(url, message)
+		e.handleDepth(url, res.Table, message)
 	case "spot/ticker":
 		e.handleTicker(url, message)
 	case "spot/trade":
 		e.handleTrade(url, message)
 	case "spot/candle60s", "spot/candle180s", "spot/candle300s", "spot/candle900s", "spot/candle1800s", "spot/candle3600s",
 		"spot/candle7200s", "spot/candle14400s", "spot/candle21600s", "spot/candle43200s", "spot/candle86400s", "spot/candle604800s":
-		e.handleKLine(url, message)
+		e.handleKLine(url, res.Table, message)
 	case "spot/order":
 		e.handleOrder(url, message)
 	case "spot/account":
@@ -280,7 +357,7 @@ func (e *OkexWs) decompressHandler(data []byte) ([]byte, error) {
 	return ioutil.ReadAll(reader)
 }
 
-func (e *OkexWs) handleDepth(url string, message []byte) {
+func (e *OkexWs) handleDepth(url, table string, message []byte) {
 	rawOB := OrderBookRes{}
 	if err := json.Unmarshal(message, &rawOB); err != nil {
 		e.errorHandler(url, fmt.Errorf("[OkexWs] handleDepth - message Unmarshal to UpdateOrderBook error:%v", err))
@@ -297,39 +374,59 @@ func (e *OkexWs) handleDepth(url string, message []byte) {
 		return
 	}
 
-	symbolOrderBook, exit := e.orderBooks[url]
-	newOrderBook := &OrderBook{}
-	newOrderBook.Symbol = market.Symbol
-
 	//The 400 entries of market depth data of the order book that return for the first time after subscription will be pushed;
 	//subsequently as long as there's any change of market depth data of the order book, the changes will be pushed tick by tick.
+	var ts time.Duration
+	if !data.Timestamp.IsZero() {
+		ts = time.Duration(data.Timestamp.UnixNano() / 1e6)
+	}
+	var msgs []wsex.Message
 	if rawOB.Action == "partial" {
-		symbolOrderBook = &SymbolOrderBook{}
+		msgs = e.orderBook(url, table).Snapshot(market.Symbol, book.Snapshot{Bids: data.Bids, Asks: data.Asks, Checksum: int64(data.Checksum), Time: ts})
 	} else if rawOB.Action == "update" {
-		if !exit || symbolOrderBook == nil {
-			return
-		}
-		cacheOrderBook, ok := (*symbolOrderBook)[market.Symbol]
-		if ok {
-			newOrderBook = cacheOrderBook
-		}
+		msgs = e.orderBook(url, table).Update(market.Symbol, book.Delta{Bids: data.Bids, Asks: data.Asks, Checksum: int64(data.Checksum), Time: ts})
 	}
-	newOrderBook.update(data)
-
-	crc32BaseBuffer, expectCrc32 := e.calCrc32(&newOrderBook.Asks, &newOrderBook.Bids)
-	if expectCrc32 == data.Checksum {
-		(*symbolOrderBook)[market.Symbol] = newOrderBook
-		e.orderBooks[url] = symbolOrderBook
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: newOrderBook.OrderBook})
-	} else {
-		err := wsex.ExError{Code: wsex.ErrInvalidDepth,
-			Message: fmt.Sprintf("[OkexWs] handleDepth - recv dirty data, Checksum's not correct. LocalString: %s, LocalCrc32: %d, RemoteCrc32: %d",
-				crc32BaseBuffer.String(), expectCrc32, data.Checksum),
-			Data: map[string]interface{}{"symbol": newOrderBook.Symbol}}
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: err})
+	for _, msg := range msgs {
+		e.publish(url, table, market.SymbolID, msg)
 	}
 }
 
+// orderBook the local order books of the connection, verified by the checksum.
+// the book is resynced by subscribing the channel again, then the partial data is pushed.
+// the depth tables of one symbol are different books, so the engines are kept by table
+func (e *OkexWs) orderBook(url, table string) *book.Engine {
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	engines, ok := e.orderBooks[url]
+	if !ok {
+		engines = make(map[string]*book.Engine)
+		e.orderBooks[url] = engines
+	}
+	engine, ok := engines[table]
+	if !ok {
+		engine = book.NewEngine(func(symbol string) (*book.Snapshot, error) {
+			market, err := e.GetMarket(symbol)
+			if err != nil {
+				return nil, err
+			}
+			conn, err := e.ConnectionMgr.GetConnection(url, nil)
+			if err != nil {
+				return nil, err
+			}
+			channel := fmt.Sprintf("%s:%s", table, market.SymbolID)
+			if err := e.send(conn, UnSubscribeStream(channel)); err != nil {
+				return nil, err
+			}
+			return nil, e.send(conn, SubscribeStream(channel))
+		})
+		engine.SetVerify(book.VerifyChecksum(book.OkxChecksum))
+		engine.SetDepth(e.Option.OrderBookDepth)
+		engine.SetMetrics(e.Option.ExchangeName, e.Option.Metrics)
+		engines[table] = engine
+	}
+	return engine
+}
+
 func (e *OkexWs) handleTicker(url string, message []byte) {
 	data := TickerRes{}
 	if err := json.Unmarshal(message, &data); err != nil {
@@ -337,7 +434,7 @@ func (e *OkexWs) handleTicker(url string, message []byte) {
 		return
 	}
 
-	tickers := make([]wsex.Ticker, 0)
+	tickers := make(map[string][]wsex.Ticker)
 	for _, t := range data.Data {
 		market, err := e.GetMarketByID(t.Symbol)
 		if err != nil {
@@ -345,9 +442,11 @@ func (e *OkexWs) handleTicker(url string, message []byte) {
 			continue
 		}
 		ticker := t.parseTicker(market.Symbol)
-		tickers = append(tickers, ticker)
+		tickers[market.SymbolID] = append(tickers[market.SymbolID], ticker)
+	}
+	for symbolID, t := range tickers {
+		e.publish(url, "spot/ticker", symbolID, wsex.Message{Type: wsex.MsgTicker, Data: t})
 	}
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: tickers})
 }
 
 func (e *OkexWs) handleTrade(url string, message []byte) {
@@ -357,7 +456,7 @@ func (e *OkexWs) handleTrade(url string, message []byte) {
 		return
 	}
 
-	trades := make([]wsex.Trade, 0)
+	trades := make(map[string][]wsex.Trade)
 	for _, t := range data.Data {
 		market, err := e.GetMarketByID(t.Symbol)
 		if err != nil {
@@ -365,19 +464,21 @@ func (e *OkexWs) handleTrade(url string, message []byte) {
 			continue
 		}
 		trade := t.parseTrade(market.Symbol)
-		trades = append(trades, trade)
+		trades[market.SymbolID] = append(trades[market.SymbolID], trade)
+	}
+	for symbolID, t := range trades {
+		e.publish(url, "spot/trade", symbolID, wsex.Message{Type: wsex.MsgTrade, Data: t})
 	}
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: trades})
 }
 
-func (e *OkexWs) handleKLine(url string, message []byte) {
+func (e *OkexWs) handleKLine(url, table string, message []byte) {
 	data := KLineRes{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[OkexWs] handleKLine - message Unmarshal to KLine error:%v", err))
 		return
 	}
 
-	klines := make([]wsex.KLine, 0)
+	klines := make(map[string][]wsex.KLine)
 	for _, k := range data.Data {
 		market, err := e.GetMarketByID(k.Symbol)
 		if err != nil {
@@ -385,10 +486,11 @@ func (e *OkexWs) handleKLine(url string, message []byte) {
 			continue
 		}
 		kline := k.Candle.parseKLine(market.Symbol)
-		klines = append(klines, kline)
-
+		klines[market.SymbolID] = append(klines[market.SymbolID], kline)
+	}
+	for symbolID, k := range klines {
+		e.publish(url, table, symbolID, wsex.Message{Type: wsex.MsgKLine, Data: k})
 	}
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgKLine, Data: klines})
 }
 
 func (e *OkexWs) handleBalance(url string, message []byte) {
@@ -398,13 +500,30 @@ func (e *OkexWs) handleBalance(url string, message []byte) {
 		return
 	}
 
-	balances := wsex.BalanceUpdate{Balances: make(map[string]wsex.Balance)}
-	for _, b := range data.Data {
-		balance := b.parseBalance()
-		balances.Balances[balance.Asset] = balance
+	conn, err := e.ConnectionMgr.GetConnection(url, nil)
+	if err != nil {
+		return
+	}
+	// the account channel is pushed by currency, route it to the symbols which have the currency
+	for _, topic := range conn.Topics() {
+		if !strings.HasPrefix(topic, "spot/account:") {
+			continue
+		}
+		market, err := e.GetMarketByID(strings.TrimPrefix(topic, "spot/account:"))
+		if err != nil {
+			continue
+		}
+		balances := wsex.BalanceUpdate{Balances: make(map[string]wsex.Balance)}
+		for _, b := range data.Data {
+			balance := b.parseBalance()
+			if balance.Asset == market.BaseID || balance.Asset == market.QuoteID {
+				balances.Balances[balance.Asset] = balance
+			}
+		}
+		if len(balances.Balances) > 0 {
+			conn.Publish(topic, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+		}
 	}
-
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgBalance, Data: balances})
 }
 
 func (e *OkexWs) handleOrder(url string, message []byte) {
@@ -422,7 +541,7 @@ func (e *OkexWs) handleOrder(url string, message []byte) {
 		}
 		order := d.parseOrder(market.Symbol)
 
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrder, Data: order})
+		e.publish(url, "spot/order", market.SymbolID, wsex.Message{Type: wsex.MsgOrder, Data: order})
 	}
 }
 
@@ -446,49 +565,10 @@ func (e *OkexWs) preHashString(timestamp string, method string, requestPath stri
 	return timestamp + strings.ToUpper(method) + requestPath + body
 }
 
-func (e *OkexWs) calCrc32(askDepths *wsex.Depth, bidDepths *wsex.Depth) (bytes.Buffer, int32) {
-	crc32BaseBuffer := bytes.Buffer{}
-	crcAskDepth, crcBidDepth := 25, 25
-	if len(*askDepths) < 25 {
-		crcAskDepth = len(*askDepths)
-	}
-	if len(*bidDepths) < 25 {
-		crcBidDepth = len(*bidDepths)
-	}
-	if crcAskDepth == crcBidDepth {
-		for i := 0; i < crcAskDepth; i++ {
-			if crc32BaseBuffer.Len() > 0 {
-				crc32BaseBuffer.WriteString(":")
-			}
-			crc32BaseBuffer.WriteString(
-				fmt.Sprintf("%v:%v:%v:%v",
-					(*bidDepths)[i].Price, (*bidDepths)[i].Amount,
-					(*askDepths)[i].Price, (*askDepths)[i].Amount))
-		}
-	} else {
-		for i := 0; i < crcBidDepth; i++ {
-			if crc32BaseBuffer.Len() > 0 {
-				crc32BaseBuffer.WriteString(":")
-			}
-			crc32BaseBuffer.WriteString(
-				fmt.Sprintf("%v:%v", (*bidDepths)[i].Price, (*bidDepths)[i].Amount))
-		}
-
-		for i := 0; i < crcAskDepth; i++ {
-			if crc32BaseBuffer.Len() > 0 {
-				crc32BaseBuffer.WriteString(":")
-			}
-			crc32BaseBuffer.WriteString(
-				fmt.Sprintf("%v:%v", (*askDepths)[i].Price, (*askDepths)[i].Amount))
-		}
-	}
-	expectCrc32 := int32(crc32.ChecksumIEEE(crc32BaseBuffer.Bytes()))
-	return crc32BaseBuffer, expectCrc32
-}
-
 func (e *OkexWs) handleError(res ResponseEvent) wsex.ExError {
 	err, ok := e.errors[res.ErrorCode]
 	if ok {
+		err.Message = res.Message
 		return err
 	}
 	return wsex.ExError{Code: res.ErrorCode, Message: res.Message}
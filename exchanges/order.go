@@ -0,0 +1,112 @@
+/*
+@Time : 2021/6/7 4:12 下午
+@Author : shiguantian
+@File : order
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+
+	"github.com/shiguantian/wsex"
+)
+
+// MergeParams set the exchange specific params of OrderRequest, they override the unified ones
+func MergeParams(params url.Values, extra map[string]string) url.Values {
+	for key, value := range extra {
+		params.Set(key, value)
+	}
+	return params
+}
+
+// ErrNotSupport the option of the request is not supported by the exchange
+func ErrNotSupport(exchange, option string) error {
+	return wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("%s does not support %s", exchange, option)}
+}
+
+// CheckSpotRequest spot exchanges have no position, reject the future only options
+func CheckSpotRequest(exchange string, req wsex.OrderRequest) error {
+	if req.Side != wsex.Buy && req.Side != wsex.Sell {
+		return ErrNotSupport(exchange, fmt.Sprintf("side %s", req.Side))
+	}
+	if req.ReduceOnly {
+		return ErrNotSupport(exchange, "reduce only order")
+	}
+	if req.ClosePosition {
+		return ErrNotSupport(exchange, "close position order")
+	}
+	if req.PositionSide != "" && req.PositionSide != wsex.PositionTypeUnKonwn {
+		return ErrNotSupport(exchange, "position side")
+	}
+	return nil
+}
+
+// CheckConditionalRequest the trigger options of the request are set and supported, triggerTypes are the supported ones
+// besides TriggerTypeUnKnown. the request should be normalized first
+func CheckConditionalRequest(exchange string, req wsex.OrderRequest, triggerTypes ...wsex.TriggerType) error {
+	if !req.Type.IsConditional() {
+		return nil
+	}
+	if req.Type == wsex.TRAILING_STOP {
+		if req.CallbackRate <= 0 {
+			return wsex.ExError{Code: wsex.ErrRequestParams, Message: "callback rate is required by trailing stop order"}
+		}
+	} else if req.StopPrice <= 0 {
+		return wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("stop price is required by %s order", req.Type)}
+	}
+	if req.TriggerType == wsex.TriggerTypeUnKnown {
+		return nil
+	}
+	for _, t := range triggerTypes {
+		if t == req.TriggerType {
+			return nil
+		}
+	}
+	return ErrNotSupport(exchange, fmt.Sprintf("trigger type %s", req.TriggerType))
+}
+
+// FindPosition the non-empty position of symbol and positionType, the one-way position(unknown type, signed amount)
+// is matched by the sign of the amount
+func FindPosition(positions []wsex.Position, symbol string, positionType wsex.PositionType) (wsex.Position, bool) {
+	for _, position := range positions {
+		if position.Symbol != symbol || position.Amount == 0 {
+			continue
+		}
+		switch position.PositionType {
+		case positionType:
+			return position, true
+		case "", wsex.PositionTypeUnKonwn:
+			if (positionType == wsex.PositionLong) == (position.Amount > 0) {
+				return position, true
+			}
+		}
+	}
+	return wsex.Position{}, false
+}
+
+// ClosePositionRequest the market order closing the whole position of symbol and positionType in positions.
+// the hedge mode position is closed by CloseLong/CloseShort, the one-way position is closed by a reduce only Buy/Sell
+func ClosePositionRequest(positions []wsex.Position, symbol string, positionType wsex.PositionType) (req wsex.OrderRequest, err error) {
+	if positionType != wsex.PositionLong && positionType != wsex.PositionShort {
+		return req, wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("invalid position type %s", positionType)}
+	}
+	position, ok := FindPosition(positions, symbol, positionType)
+	if !ok {
+		return req, wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("no %s position of %s", positionType, symbol)}
+	}
+	req = wsex.OrderRequest{Symbol: symbol, Type: wsex.MARKET, Amount: math.Abs(position.Amount)}
+	switch {
+	case position.PositionType == wsex.PositionLong:
+		req.Side = wsex.CloseLong
+	case position.PositionType == wsex.PositionShort:
+		req.Side = wsex.CloseShort
+	case positionType == wsex.PositionLong:
+		req.Side, req.ReduceOnly = wsex.Sell, true
+	default:
+		req.Side, req.ReduceOnly = wsex.Buy, true
+	}
+	return
+}
@@ -0,0 +1,151 @@
+/*
+@Time : 2021/6/7 4:40 下午
+@Author : shiguantian
+@File : order_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/shiguantian/wsex"
+)
+
+func TestCheckSpotRequest(t *testing.T) {
+	cases := []struct {
+		req wsex.OrderRequest
+		ok  bool
+	}{
+		{wsex.OrderRequest{Side: wsex.Buy}, true},
+		{wsex.OrderRequest{Side: wsex.Sell, PositionSide: wsex.PositionTypeUnKonwn}, true},
+		{wsex.OrderRequest{Side: wsex.OpenLong}, false},
+		{wsex.OrderRequest{Side: wsex.Buy, ReduceOnly: true}, false},
+		{wsex.OrderRequest{Side: wsex.Sell, ClosePosition: true}, false},
+		{wsex.OrderRequest{Side: wsex.Sell, PositionSide: wsex.PositionLong}, false},
+	}
+	for i, c := range cases {
+		err := CheckSpotRequest("test", c.req)
+		if (err == nil) != c.ok {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+		if err != nil && err.(wsex.ExError).Code != wsex.ErrRequestParams {
+			t.Fatalf("case %d: unexpected error code %v", i, err)
+		}
+	}
+}
+
+func TestMergeParams(t *testing.T) {
+	params := url.Values{}
+	params.Set("timeInForce", "GTC")
+	MergeParams(params, map[string]string{"timeInForce": "GTX", "selfTradePrevention": "EXPIRE_TAKER"})
+	if params.Get("timeInForce") != "GTX" || params.Get("selfTradePrevention") != "EXPIRE_TAKER" {
+		t.Fatalf("unexpected params %v", params)
+	}
+}
+
+func TestOrderRequest_ClientOrderID(t *testing.T) {
+	if id := (wsex.OrderRequest{ClientID: "my-id"}).ClientOrderID("x", 32); id != "my-id" {
+		t.Fatalf("client id should be kept, got %s", id)
+	}
+	if id := (wsex.OrderRequest{}).ClientOrderID("x", 32); id != "" {
+		t.Fatalf("client id should be empty, got %s", id)
+	}
+	if id := (wsex.OrderRequest{UseClientID: true}).ClientOrderID("x", 32); !strings.HasPrefix(id, "x") {
+		t.Fatalf("client id should be generated with prefix, got %s", id)
+	}
+}
+
+func TestOrderRequest_Normalize(t *testing.T) {
+	cases := []struct {
+		req  wsex.OrderRequest
+		want wsex.TradeType
+	}{
+		{wsex.OrderRequest{}, wsex.LIMIT},
+		{wsex.OrderRequest{Type: wsex.MARKET}, wsex.MARKET},
+		{wsex.OrderRequest{Type: wsex.LIMIT, StopPrice: 1}, wsex.STOP_LIMIT},
+		{wsex.OrderRequest{Type: wsex.MARKET, StopPrice: 1}, wsex.STOP_MARKET},
+		{wsex.OrderRequest{Type: wsex.TAKE_PROFIT_LIMIT, StopPrice: 1}, wsex.TAKE_PROFIT_LIMIT},
+	}
+	for i, c := range cases {
+		if got := c.req.Normalize().Type; got != c.want {
+			t.Fatalf("case %d: want %s, got %s", i, c.want, got)
+		}
+	}
+}
+
+func TestCheckConditionalRequest(t *testing.T) {
+	cases := []struct {
+		req wsex.OrderRequest
+		ok  bool
+	}{
+		{wsex.OrderRequest{Type: wsex.LIMIT}, true},
+		{wsex.OrderRequest{Type: wsex.STOP_MARKET}, false},
+		{wsex.OrderRequest{Type: wsex.STOP_MARKET, StopPrice: 1}, true},
+		{wsex.OrderRequest{Type: wsex.STOP_MARKET, StopPrice: 1, TriggerType: wsex.TriggerLast}, true},
+		{wsex.OrderRequest{Type: wsex.STOP_MARKET, StopPrice: 1, TriggerType: wsex.TriggerMark}, false},
+		{wsex.OrderRequest{Type: wsex.TRAILING_STOP}, false},
+		{wsex.OrderRequest{Type: wsex.TRAILING_STOP, CallbackRate: 0.01}, true},
+	}
+	for i, c := range cases {
+		err := CheckConditionalRequest("test", c.req, wsex.TriggerLast)
+		if (err == nil) != c.ok {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+	}
+}
+
+func TestClosePositionRequest(t *testing.T) {
+	positions := []wsex.Position{
+		{Symbol: "ETH/USDT", PositionType: wsex.PositionLong, Amount: 3},
+		{Symbol: "BTC/USDT", PositionType: wsex.PositionLong, Amount: 0},
+		{Symbol: "BTC/USDT", PositionType: wsex.PositionShort, Amount: -2},
+		{Symbol: "LTC/USDT", Amount: -5},
+	}
+	cases := []struct {
+		symbol       string
+		positionType wsex.PositionType
+		side         wsex.Side
+		amount       float64
+		reduceOnly   bool
+		ok           bool
+	}{
+		{"ETH/USDT", wsex.PositionLong, wsex.CloseLong, 3, false, true},
+		{"BTC/USDT", wsex.PositionShort, wsex.CloseShort, 2, false, true},
+		{"BTC/USDT", wsex.PositionLong, "", 0, false, false},
+		{"LTC/USDT", wsex.PositionShort, wsex.Buy, 5, true, true},
+		{"LTC/USDT", wsex.PositionLong, "", 0, false, false},
+		{"LTC/USDT", wsex.PositionTypeUnKonwn, "", 0, false, false},
+	}
+	for i, c := range cases {
+		req, err := ClosePositionRequest(positions, c.symbol, c.positionType)
+		if (err == nil) != c.ok {
+			t.Fatalf("case %d: unexpected error %v", i, err)
+		}
+		if req.Side != c.side || req.Amount != c.amount || req.ReduceOnly != c.reduceOnly || (c.ok && req.Type != wsex.MARKET) {
+			t.Fatalf("case %d: unexpected request %+v", i, req)
+		}
+	}
+}
+
+func TestFindPosition(t *testing.T) {
+	positions := []wsex.Position{
+		{Symbol: "BTC/USDT", PositionType: wsex.PositionLong, Amount: 0},
+		{Symbol: "BTC/USDT", PositionType: wsex.PositionShort, Amount: -2, Margin: 10},
+		{Symbol: "ETH/USDT", PositionType: wsex.PositionTypeUnKonwn, Amount: 1},
+	}
+	if _, ok := FindPosition(positions, "BTC/USDT", wsex.PositionLong); ok {
+		t.Fatal("the empty position should be skipped")
+	}
+	if p, ok := FindPosition(positions, "BTC/USDT", wsex.PositionShort); !ok || p.Margin != 10 {
+		t.Fatalf("unexpected position %+v", p)
+	}
+	if _, ok := FindPosition(positions, "ETH/USDT", wsex.PositionLong); !ok {
+		t.Fatal("the one-way position should be matched by the amount")
+	}
+	if _, ok := FindPosition(positions, "ETH/USDT", wsex.PositionShort); ok {
+		t.Fatal("the one-way long position should not be matched as short")
+	}
+}
@@ -0,0 +1,50 @@
+/*
+@Time : 2021/6/16 10:20 上午
+@Author : shiguantian
+@File : setting
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"github.com/shiguantian/wsex"
+)
+
+type FutureSetter interface {
+	SetPositionMode(symbol string, positionMode wsex.FuturePositionsMode) error
+	SetMarginMode(symbol string, marginMode wsex.FutureMarginMode) error
+	SetLeverage(symbol string, leverage int) error
+}
+
+// ApplySetting set the position mode, margin mode and leverage in order, stops at the first failure.
+// the error is an ExError keeping the code of the failed step, Data["step"] is the failed step
+// and Data["applied"] are the succeeded ones, which are not rolled back
+func ApplySetting(setter FutureSetter, symbol string, leverage int, marginMode wsex.FutureMarginMode, positionMode wsex.FuturePositionsMode) error {
+	steps := []struct {
+		name string
+		set  func() error
+	}{
+		{"positionMode", func() error { return setter.SetPositionMode(symbol, positionMode) }},
+		{"marginMode", func() error { return setter.SetMarginMode(symbol, marginMode) }},
+		{"leverage", func() error { return setter.SetLeverage(symbol, leverage) }},
+	}
+	applied := make([]string, 0, len(steps))
+	for _, step := range steps {
+		if err := step.set(); err != nil {
+			exErr, ok := err.(wsex.ExError)
+			if !ok {
+				exErr = wsex.ExError{Code: wsex.UnHandleError, Message: err.Error()}
+			}
+			data := map[string]interface{}{}
+			for key, value := range exErr.Data {
+				data[key] = value
+			}
+			data["symbol"], data["step"], data["applied"] = symbol, step.name, applied
+			exErr.Message = "set " + step.name + ": " + exErr.Message
+			exErr.Data = data
+			return exErr
+		}
+		applied = append(applied, step.name)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+/*
+@Time : 2021/6/16 10:45 上午
+@Author : shiguantian
+@File : setting_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shiguantian/wsex"
+)
+
+type testSetter struct {
+	calls []string
+	fail  string
+}
+
+func (s *testSetter) set(step string) error {
+	s.calls = append(s.calls, step)
+	if step == s.fail {
+		return wsex.ExError{Code: wsex.ErrRequestParams, Message: "rejected"}
+	}
+	return nil
+}
+
+func (s *testSetter) SetPositionMode(string, wsex.FuturePositionsMode) error {
+	return s.set("positionMode")
+}
+
+func (s *testSetter) SetMarginMode(string, wsex.FutureMarginMode) error {
+	return s.set("marginMode")
+}
+
+func (s *testSetter) SetLeverage(string, int) error {
+	return s.set("leverage")
+}
+
+func TestApplySetting(t *testing.T) {
+	setter := &testSetter{}
+	if err := ApplySetting(setter, "BTC/USDT", 10, wsex.CrossedMargin, wsex.OneWay); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"positionMode", "marginMode", "leverage"}; !reflect.DeepEqual(setter.calls, want) {
+		t.Fatalf("unexpected calls %v", setter.calls)
+	}
+
+	setter = &testSetter{fail: "marginMode"}
+	err := ApplySetting(setter, "BTC/USDT", 10, wsex.CrossedMargin, wsex.OneWay)
+	exErr, ok := err.(wsex.ExError)
+	if !ok || exErr.Code != wsex.ErrRequestParams || exErr.Data["step"] != "marginMode" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+	if applied := exErr.Data["applied"].([]string); !reflect.DeepEqual(applied, []string{"positionMode"}) {
+		t.Fatalf("unexpected applied steps %v", applied)
+	}
+	if len(setter.calls) != 2 {
+		t.Fatalf("the leverage should not be set after the failure, %v", setter.calls)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+@Time : 2021/6/9 2:20 下午
+@Author : shiguantian
+@File : subscribeAck
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+// DefaultSubscribeTimeout the default timeout of waiting the subscribe ack
+const DefaultSubscribeTimeout = time.Second * 5
+
+// SubscribeAck correlate the subscribe request with the ack or error response of the exchange
+type SubscribeAck struct {
+	mu      sync.Mutex
+	pending map[string]chan error // key: request id or topic
+}
+
+func NewSubscribeAck() *SubscribeAck {
+	return &SubscribeAck{pending: make(map[string]chan error)}
+}
+
+// Register must be called before the subscribe request is sent, otherwise the ack may be missed
+func (s *SubscribeAck) Register(id string) <-chan error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ch := make(chan error, 1)
+	s.pending[id] = ch
+	return ch
+}
+
+// Done deliver the ack of the request, err is nil if subscribe successfully.
+// returns false if no request is waiting for the id
+func (s *SubscribeAck) Done(id string, err error) bool {
+	s.mu.Lock()
+	ch, ok := s.pending[id]
+	delete(s.pending, id)
+	s.mu.Unlock()
+	if ok {
+		ch <- err
+	}
+	return ok
+}
+
+// DoneMatch deliver the ack to the requests whose id matches, for the exchanges that the error response has no request id.
+// returns false if no request matches
+func (s *SubscribeAck) DoneMatch(match func(id string) bool, err error) bool {
+	s.mu.Lock()
+	var matched []chan error
+	for id, ch := range s.pending {
+		if match(id) {
+			matched = append(matched, ch)
+			delete(s.pending, id)
+		}
+	}
+	s.mu.Unlock()
+	for _, ch := range matched {
+		ch <- err
+	}
+	return len(matched) > 0
+}
+
+// Wait block until the ack arrives, returns ErrTimeout if no ack in timeout
+func (s *SubscribeAck) Wait(id string, ch <-chan error, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = DefaultSubscribeTimeout
+	}
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(timeout):
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return wsex.ExError{Code: wsex.ErrTimeout, Message: fmt.Sprintf("subscribe %v timeout, no ack in %v", id, timeout)}
+	}
+}
@@ -0,0 +1,61 @@
+/*
+@Time : 2021/6/9 3:05 下午
+@Author : shiguantian
+@File : subscribeAck_test
+@Software: GoLand
+*/
+package exchanges
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shiguantian/wsex"
+)
+
+func TestSubscribeAck_Done(t *testing.T) {
+	ack := NewSubscribeAck()
+	ch := ack.Register("1")
+	go ack.Done("1", nil)
+	if err := ack.Wait("1", ch, time.Second); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if ack.Done("1", nil) {
+		t.Fatal("the ack should be delivered only once")
+	}
+
+	ch = ack.Register("2")
+	go ack.Done("2", wsex.ExError{Code: wsex.ErrChannelNotExist})
+	if err := ack.Wait("2", ch, time.Second); err == nil || err.(wsex.ExError).Code != wsex.ErrChannelNotExist {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestSubscribeAck_Timeout(t *testing.T) {
+	ack := NewSubscribeAck()
+	ch := ack.Register("1")
+	err := ack.Wait("1", ch, 10*time.Millisecond)
+	if err == nil || err.(wsex.ExError).Code != wsex.ErrTimeout {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if ack.Done("1", nil) {
+		t.Fatal("the request should be removed after timeout")
+	}
+}
+
+func TestSubscribeAck_DoneMatch(t *testing.T) {
+	ack := NewSubscribeAck()
+	btc := ack.Register("spot/ticker:BTC-USDX")
+	eth := ack.Register("spot/ticker:ETH-USDT")
+	msg := "Channel spot/ticker:BTC-USDX doesn't exist"
+	if !ack.DoneMatch(func(id string) bool { return strings.Contains(msg, id) }, wsex.ExError{Code: wsex.ErrChannelNotExist}) {
+		t.Fatal("BTC-USDX should be matched")
+	}
+	if err := ack.Wait("spot/ticker:BTC-USDX", btc, time.Second); err == nil {
+		t.Fatal("BTC-USDX should fail")
+	}
+	if err := ack.Wait("spot/ticker:ETH-USDT", eth, 10*time.Millisecond); err == nil || err.(wsex.ExError).Code != wsex.ErrTimeout {
+		t.Fatalf("ETH-USDT should not be matched, %v", err)
+	}
+}
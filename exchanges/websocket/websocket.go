@@ -12,7 +12,9 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -24,13 +26,15 @@ type Message struct {
 }
 
 type WsConn struct {
-	conn *websocket.Conn
+	recvTime int64 // unix nano of receiving the last frame, the first field for the alignment of atomic
+	conn     *websocket.Conn
 	Options
 
 	messageBufferChan chan Message
 	stop              chan struct{}
 	lock              sync.Mutex
 	once              sync.Once
+	closeReason       string
 }
 
 func (w *WsConn) Connect(options ...Option) (err error) {
@@ -53,10 +57,21 @@ func (w *WsConn) Connect(options ...Option) (err error) {
 }
 
 func (w *WsConn) Close() {
+	w.closeWith("closed")
+}
+
+// CloseReason why the connection is closed, it's valid in the CloseHandler
+func (w *WsConn) CloseReason() string {
+	return w.closeReason
+}
+
+func (w *WsConn) closeWith(reason string) {
 	w.once.Do(func() {
-		err := w.conn.Close()
-		if err != nil {
-			log.Printf("[WsConn] %s - close websocket error: %s", w.ExchangeName, err)
+		w.closeReason = reason
+		if w.conn != nil {
+			if err := w.conn.Close(); err != nil {
+				log.Printf("[WsConn] %s - close websocket error: %s", w.ExchangeName, err)
+			}
 		}
 		if w.closeHandler != nil {
 			w.closeHandler(w.wsUrl)
@@ -64,6 +79,16 @@ func (w *WsConn) Close() {
 	})
 }
 
+// RecvTime the time receiving the last frame, the message handler is called in the read loop,
+// so it's the receive time of the message being handled
+func (w *WsConn) RecvTime() time.Time {
+	nano := atomic.LoadInt64(&w.recvTime)
+	if nano == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nano)
+}
+
 func (w *WsConn) SendMessage(msg []byte) {
 	w.messageBufferChan <- Message{Msg: msg, Type: websocket.TextMessage}
 }
@@ -102,7 +127,12 @@ func (w *WsConn) connect() (*websocket.Conn, error) {
 		dialer.Proxy = http.ProxyURL(proxy)
 	}
 
-	conn, _, err := dialer.Dial(w.wsUrl, http.Header(w.ReqHeaders))
+	// the fragment is used to distinguish the connections of the same url, eg: wss://host/ws#1
+	wsUrl := w.wsUrl
+	if i := strings.Index(wsUrl, "#"); i >= 0 {
+		wsUrl = wsUrl[:i]
+	}
+	conn, _, err := dialer.Dial(wsUrl, http.Header(w.ReqHeaders))
 	if err != nil {
 		return nil, fmt.Errorf("[WsConn] %s -  connect host: %s error:%s", w.ExchangeName, w.wsUrl, err)
 	}
@@ -132,7 +162,7 @@ func (w *WsConn) reconnect() {
 
 	if err != nil {
 		log.Printf("[WsConn] %s - try reconnect 20 times failed: %s", w.ExchangeName, err)
-		w.Close()
+		w.closeWith("reconnect failed")
 		return
 	}
 
@@ -180,10 +210,11 @@ func (w *WsConn) readLoop() {
 				if w.IsAutoReconnect {
 					w.reconnect()
 				} else {
-					w.Close()
+					w.closeWith("disconnected")
 				}
 				return
 			}
+			atomic.StoreInt64(&w.recvTime, time.Now().UnixNano())
 			if w.messageHandler == nil {
 				return
 			}
@@ -21,6 +21,7 @@ import (
 	"github.com/shiguantian/wsex/utils"
 
 	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/book"
 
 	"github.com/shiguantian/wsex/exchanges/websocket"
 )
@@ -34,7 +35,7 @@ type SubTopic struct {
 
 type ZbFutureWs struct {
 	exchanges.BaseExchange
-	orderBooks   map[string]*SymbolOrderBook
+	orderBooks   map[string]*book.Engine // key: ws url
 	subTopicInfo map[string]SubTopic
 	errors       map[int]wsex.ExError
 	isLogin      bool
@@ -48,11 +49,17 @@ type ZbFutureWs struct {
 func (e *ZbFutureWs) Init(option wsex.Options) {
 	e.BaseExchange.Init()
 	e.Option = option
-	e.orderBooks = make(map[string]*SymbolOrderBook)
+	e.orderBooks = make(map[string]*book.Engine)
 	e.errors = map[int]wsex.ExError{}
 	e.loginChan = make(chan struct{})
 	e.isLogin = false
 	e.subTopicInfo = make(map[string]SubTopic)
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.ZB
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 	if e.Option.WsHost == "" {
 		e.Option.WsHost = "wss://futures.zb.com/ws"
 	}
@@ -158,6 +165,22 @@ func (e *ZbFutureWs) SubscribeMarkPrice(symbol string, sub wsex.MessageChan) (st
 
 }
 
+func (e *ZbFutureWs) SubscribeIndexPrice(symbol string, sub wsex.MessageChan) (string, error) {
+	topic, err := e.getTopicBySymbol("", symbol, ".index")
+	if err != nil {
+		return "", err
+	}
+	stream := Stream{
+		"channel": topic,
+	}
+	return e.subscribe(fmt.Sprintf("%s/public/v1", e.Option.WsHost), symbol, SubTopic{Topic: topic, Symbol: symbol, MessageType: wsex.MsgIndexPrice}, false, stream, sub)
+}
+
+// SubscribeLiquidations zb future doesn't push the liquidation orders
+func (e *ZbFutureWs) SubscribeLiquidations(symbol string, sub wsex.MessageChan) (string, error) {
+	return "", exchanges.ErrNotSupport("zb future", "liquidation stream")
+}
+
 func (e *ZbFutureWs) SubscribeBalance(symbol string, sub wsex.MessageChan) (string, error) {
 	stream := Stream{
 		"channel":            "Fund.assetChange",
@@ -194,26 +217,92 @@ func (e *ZbFutureWs) SubscribePositions(symbol string, sub wsex.MessageChan) (st
 	return e.subscribe(fmt.Sprintf("%s/private/api/v2", e.Option.WsHost), topic, SubTopic{Topic: "Positions.change", Symbol: symbol, MessageType: wsex.MsgPositions}, true, stream, sub)
 }
 
+// topicAccount 账户变动由资产和所有币对的仓位频道合成, zb不推送追加保证金通知
+const topicAccount = "account"
+
+// accountChannels 账户变动使用的频道, 与单独的资产和仓位订阅共用, 都取消订阅后才取消频道
+var accountChannels = []string{"Fund.assetChange", "Positions.change"}
+
+func isAccountChannel(topic string) bool {
+	return topic == accountChannels[0] || topic == accountChannels[1]
+}
+
+func (e *ZbFutureWs) SubscribeAccount(sub wsex.MessageChan) (string, error) {
+	conn, err := e.ConnectionMgr.GetConnection(fmt.Sprintf("%s/private/api/v2", e.Option.WsHost), e.Connect)
+	if err != nil {
+		return "", err
+	}
+	if err := e.ensureLogin(conn); err != nil {
+		return "", err
+	}
+	e.subTopicInfo[topicAccount] = SubTopic{Topic: topicAccount, MessageType: wsex.MsgAccount}
+	if _, ok := e.subTopicInfo["Fund.assetChange"]; !ok {
+		e.subTopicInfo["Fund.assetChange"] = SubTopic{Topic: "Fund.assetChange", MessageType: wsex.MsgBalance}
+	}
+	if _, ok := e.subTopicInfo["Positions.change"]; !ok {
+		e.subTopicInfo["Positions.change"] = SubTopic{Topic: "Positions.change", MessageType: wsex.MsgPositions}
+	}
+	conn.Subscribe(topicAccount, sub)
+	for _, channel := range accountChannels {
+		stream := Stream{
+			"channel":            channel,
+			"futuresAccountType": e.getAccountType(),
+			"action":             "subscribe",
+		}
+		if err := conn.SendJsonMessage(stream); err != nil {
+			conn.UnSubscribe(topicAccount, sub)
+			return "", err
+		}
+	}
+	return topicAccount, nil
+}
+
 func (e *ZbFutureWs) UnSubscribe(topic string, sub wsex.MessageChan) error {
 	topicInfo, ok := e.subTopicInfo[topic] //ok是看当前key是否存在返回布尔，value返回对应key的值
-	if ok {
+	if !ok {
+		return nil
+	}
+	var (
+		conn *exchanges.Connection
+		err  error
+	)
+	switch topicInfo.MessageType {
+	case wsex.MsgKLine, wsex.MsgTicker, wsex.MsgOrderBook, wsex.MsgTrade, wsex.MsgMarkPrice, wsex.MsgIndexPrice:
+		conn, err = e.ConnectionMgr.FindShard(fmt.Sprintf("%s/public/v1", e.Option.WsHost), topic)
+	default:
+		conn, err = e.ConnectionMgr.GetConnection(fmt.Sprintf("%s/private/api/v2", e.Option.WsHost), nil)
+	}
+	if err != nil {
+		return err
+	}
+	// 仍有其他订阅者时只移除当前路由
+	if !conn.UnSubscribe(topic, sub) {
+		return nil
+	}
+	if topic == topicAccount {
 		delete(e.subTopicInfo, topic)
-		stream := Stream{"channel": topic, "action": "unsubscribe"}
-		url := fmt.Sprintf("%s/private/api/v2", e.Option.WsHost)
-		switch topicInfo.MessageType {
-		case wsex.MsgKLine, wsex.MsgTicker, wsex.MsgOrderBook, wsex.MsgTrade:
-			url = fmt.Sprintf("%s/public/v1", e.Option.WsHost)
-		}
-		conn, err := e.ConnectionMgr.GetConnection(url, nil)
-		if err != nil {
-			return err
-		}
-		if err := conn.SendJsonMessage(stream); err != nil {
-			return err
+		// 资产和仓位频道仍有单独的订阅时保留
+		for _, channel := range accountChannels {
+			if conn.HasTopic(channel) {
+				continue
+			}
+			delete(e.subTopicInfo, channel)
+			if err := conn.SendJsonMessage(Stream{"channel": channel, "action": "unsubscribe"}); err != nil {
+				return err
+			}
 		}
-		conn.UnSubscribe(sub)
+		return nil
 	}
-	return nil
+	if isAccountChannel(topic) && conn.HasTopic(topicAccount) {
+		// 频道仍被账户订阅使用
+		return nil
+	}
+	delete(e.subTopicInfo, topic)
+	if err := conn.SendJsonMessage(Stream{"channel": topic, "action": "unsubscribe"}); err != nil {
+		return err
+	}
+	// 关闭空闲的分片连接, 不迁移频道
+	return e.ConnectionMgr.Rebalance(fmt.Sprintf("%s/public/v1", e.Option.WsHost), nil)
 }
 
 func (e *ZbFutureWs) Connect(url string) (*exchanges.Connection, error) {
@@ -242,34 +331,63 @@ func (e *ZbFutureWs) subscribe(url, symbol string, topic SubTopic, needLogin boo
 		e.subTopicInfo[topic.Topic] = topic
 	}
 
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	var (
+		conn *exchanges.Connection
+		err  error
+	)
+	if needLogin {
+		// 登录状态属于连接, 私有频道只使用一个连接
+		conn, err = e.ConnectionMgr.GetConnection(url, e.Connect)
+	} else {
+		conn, err = e.ConnectionMgr.GetShard(url, topic.Topic, sub, e.Connect)
+	}
 	if err != nil {
 		return "", err
 	}
 
 	if needLogin {
-		e.loginLock.Lock()
-		defer e.loginLock.Unlock()
-		if !e.isLogin {
-			if err := e.login(conn); err != nil {
-				return "", err
-			}
-			select {
-			case <-e.loginChan:
-				break
-			case <-time.After(time.Second * 5):
-				return "", errors.New("login failed")
-			}
+		if err := e.ensureLogin(conn); err != nil {
+			return "", err
 		}
 	}
 	stream["action"] = "subscribe"
-	if err := conn.SendJsonMessage(stream); err != nil {
+	conn.Subscribe(topic.Topic, sub)
+	// same as spot, the first push or the error of the public channel is regarded as the ack
+	var ack <-chan error
+	if !needLogin {
+		ack = e.SubscribeAck.Register(topic.Topic)
+	}
+	err = conn.SendJsonMessage(stream)
+	if err != nil {
+		e.SubscribeAck.Done(topic.Topic, err)
+	} else if ack != nil {
+		err = e.WaitSubscribeAck(topic.Topic, ack)
+	}
+	if err != nil {
+		conn.UnSubscribe(topic.Topic, sub)
 		return "", err
 	}
-	conn.Subscribe(sub)
 	return topic.Topic, nil
 }
 
+// ensureLogin 私有频道订阅前登录, 每个连接只登录一次
+func (e *ZbFutureWs) ensureLogin(conn *exchanges.Connection) error {
+	e.loginLock.Lock()
+	defer e.loginLock.Unlock()
+	if e.isLogin {
+		return nil
+	}
+	if err := e.login(conn); err != nil {
+		return err
+	}
+	select {
+	case <-e.loginChan:
+		return nil
+	case <-time.After(time.Second * 5):
+		return errors.New("login failed")
+	}
+}
+
 func (e *ZbFutureWs) login(conn *exchanges.Connection) error {
 	timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
 	stream := Stream{
@@ -314,7 +432,10 @@ func (e *ZbFutureWs) messageHandler(url string, message []byte) {
 	}
 
 	if len(res.Code) > 0 {
-		e.errorHandler(url, e.handleError(res))
+		err := e.handleError(res)
+		if !e.SubscribeAck.Done(res.Channel, err) {
+			e.errorHandler(url, err)
+		}
 		return
 	}
 
@@ -325,6 +446,7 @@ func (e *ZbFutureWs) messageHandler(url string, message []byte) {
 	}
 
 	if res.Channel != "" {
+		e.SubscribeAck.Done(res.Channel, nil)
 		if topicInfo, ok := e.subTopicInfo[res.Channel]; ok {
 			switch topicInfo.MessageType {
 			case wsex.MsgOrderBook:
@@ -347,6 +469,8 @@ func (e *ZbFutureWs) messageHandler(url string, message []byte) {
 				e.handlePositions(url, message, topicInfo)
 			case wsex.MsgMarkPrice:
 				e.handleMarkPrice(url, message, topicInfo)
+			case wsex.MsgIndexPrice:
+				e.handleIndexPrice(url, message, topicInfo)
 			}
 		}
 	}
@@ -407,9 +531,9 @@ func (e *ZbFutureWs) handleDepth(url string, message []byte, topicInfo SubTopic)
 			orderBook.Bids = append(orderBook.Bids, item)
 		}
 	}
-	sort.Reverse(orderBook.Bids)
+	sort.Sort(sort.Reverse(orderBook.Bids))
 	sort.Sort(orderBook.Asks)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: orderBook})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgOrderBook, Data: orderBook})
 }
 
 func (e *ZbFutureWs) handleIncrementalDepth(url string, message []byte, topicInfo SubTopic) {
@@ -424,44 +548,30 @@ func (e *ZbFutureWs) handleIncrementalDepth(url string, message []byte, topicInf
 		e.errorHandler(url, err)
 		return
 	}
-	orderBook := &OrderBook{}
-	orderBook.Symbol = topicInfo.Symbol
-	for _, ask := range response.Data.Asks {
-		if item, err := ask.ParseRawDepthItem(); err == nil {
-			orderBook.Asks = append(orderBook.Asks, item)
-		}
-	}
-	for _, bid := range response.Data.Bids {
-		if item, err := bid.ParseRawDepthItem(); err == nil {
-			orderBook.Bids = append(orderBook.Bids, item)
-		}
-	}
-	sort.Reverse(orderBook.Bids)
-	sort.Sort(orderBook.Asks)
-	symbolOrderBook, ok := e.orderBooks[url]
-	if !ok {
-		symbolOrderBook = &SymbolOrderBook{}
-		e.orderBooks[url] = symbolOrderBook
-	}
+	// the whole depth is pushed first after subscribing, then the changes
+	var msgs []wsex.Message
 	if len(response.Type) > 0 {
-		(*symbolOrderBook)[topicInfo.Symbol] = orderBook
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: orderBook.OrderBook})
+		msgs = e.orderBook(url).Snapshot(topicInfo.Symbol, book.Snapshot{Bids: response.Data.Bids, Asks: response.Data.Asks})
 	} else {
-		fullOrderBook, ok := (*symbolOrderBook)[topicInfo.Symbol]
-		if !ok || fullOrderBook == nil {
-			return
-		}
-		fullOrderBook.OrderBookUpdate(response.Data.Asks, response.Data.Bids)
-		if fullOrderBook.OrderBook.Asks.Len() == 0 || fullOrderBook.OrderBook.Bids.Len() == 0 {
-			fmt.Println("234")
-		}
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
+		msgs = e.orderBook(url).Update(topicInfo.Symbol, book.Delta{Bids: response.Data.Bids, Asks: response.Data.Asks})
+	}
+	for _, msg := range msgs {
+		e.ConnectionMgr.Publish(url, topicInfo.Topic, msg)
 	}
 }
 
-func (o *OrderBook) OrderBookUpdate(asks wsex.RawDepth, bids wsex.RawDepth) {
-	o.Bids = o.Bids.Update(bids, true)
-	o.Asks = o.Asks.Update(asks, false)
+// orderBook the local order books of the connection, the snapshot is pushed by websocket
+func (e *ZbFutureWs) orderBook(url string) *book.Engine {
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	engine, ok := e.orderBooks[url]
+	if !ok {
+		engine = book.NewEngine(nil)
+		engine.SetDepth(e.Option.OrderBookDepth)
+		engine.SetMetrics(e.Option.ExchangeName, e.Option.Metrics)
+		e.orderBooks[url] = engine
+	}
+	return engine
 }
 
 func (e *ZbFutureWs) handleTicker(url string, message []byte, topicInfo SubTopic) {
@@ -474,7 +584,7 @@ func (e *ZbFutureWs) handleTicker(url string, message []byte, topicInfo SubTopic
 	}
 	ticker := response.Data.parseTicker()
 	ticker.Symbol = topicInfo.Symbol
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
 }
 
 func (e *ZbFutureWs) handleTrade(url string, message []byte, topicInfo SubTopic) {
@@ -490,7 +600,7 @@ func (e *ZbFutureWs) handleTrade(url string, message []byte, topicInfo SubTopic)
 	}
 	trade := response.Data[0].parseTrade()
 	trade.Symbol = topicInfo.Symbol
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: trade})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgTrade, Data: trade})
 }
 
 func (e *ZbFutureWs) handleKLine(url string, message []byte, topicInfo SubTopic) {
@@ -511,7 +621,7 @@ func (e *ZbFutureWs) handleKLine(url string, message []byte, topicInfo SubTopic)
 			Low:       ele[2],
 			Volume:    ele[4],
 		}
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgKLine, Data: kline})
+		e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgKLine, Data: kline})
 	}
 }
 
@@ -527,7 +637,22 @@ func (e *ZbFutureWs) handleMarkPrice(url string, message []byte, topicInfo SubTo
 		Price:  response.Data,
 		Symbol: topicInfo.Symbol,
 	}
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgMarkPrice, Data: markPrice})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgMarkPrice, Data: markPrice})
+}
+
+func (e *ZbFutureWs) handleIndexPrice(url string, message []byte, topicInfo SubTopic) {
+	var response struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(message, &response); err != nil {
+		e.errorHandler(url, err)
+		return
+	}
+	indexPrice := wsex.IndexPrice{
+		Price:  response.Data,
+		Symbol: topicInfo.Symbol,
+	}
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgIndexPrice, Data: indexPrice})
 }
 
 func (e *ZbFutureWs) handleBalance(url string, message []byte) {
@@ -542,7 +667,8 @@ func (e *ZbFutureWs) handleBalance(url string, message []byte) {
 
 	balances := wsex.BalanceUpdate{Balances: make(map[string]wsex.Balance)}
 	balances.Balances[strings.ToUpper(response.Data.Currency)] = response.Data.parseBalance()
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	e.ConnectionMgr.Publish(url, "Fund.assetChange", wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	e.ConnectionMgr.Publish(url, topicAccount, wsex.Message{Type: wsex.MsgAccount, Data: wsex.AccountUpdate{Balances: balances.Balances}})
 }
 
 func (e *ZbFutureWs) handleOrder(url string, message []byte, topicInfo SubTopic) {
@@ -554,7 +680,7 @@ func (e *ZbFutureWs) handleOrder(url string, message []byte, topicInfo SubTopic)
 		return
 	}
 	order := response.Data.parseOrder(topicInfo.Symbol)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrder, Data: order})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgOrder, Data: order})
 }
 
 func (e *ZbFutureWs) handlePositions(url string, message []byte, topicInfo SubTopic) {
@@ -572,16 +698,19 @@ func (e *ZbFutureWs) handlePositions(url string, message []byte, topicInfo SubTo
 
 	positions := response.Data.parsePositions(market.BaseID, market.Symbol)
 	var futurePosition = wsex.FuturePositonsUpdate{
-		Positons: make([]wsex.FuturePositons, 0),
+		Positons: make([]wsex.Position, 0),
 	}
 	futurePosition.Symbol = market.Symbol
 	futurePosition.Positons = append(futurePosition.Positons, positions)
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgPositions, Data: futurePosition})
+	e.ConnectionMgr.Publish(url, topicInfo.Topic, wsex.Message{Type: wsex.MsgPositions, Data: futurePosition})
+	e.ConnectionMgr.Publish(url, topicAccount, wsex.Message{Type: wsex.MsgAccount, Data: wsex.AccountUpdate{Positions: futurePosition.Positons}})
 }
 
 func (e *ZbFutureWs) handleError(res FutureResponseEvent) wsex.ExError {
 	return wsex.ExError{
-		Message: res.Code,
+		Code:    wsex.ErrChannelNotExist,
+		Message: res.Message,
+		Data:    map[string]interface{}{"code": res.Code, "channel": res.Channel},
 	}
 }
 
@@ -76,6 +76,7 @@ type OrderInfo struct {
 	TotalAmount float64 `json:"total_amount"`
 	TradeAmount float64 `json:"trade_amount"`
 	TradeMoney  float64 `json:"trade_money"`
+	Fees        float64 `json:"fees"`
 	TradeDate   int64   `json:"trade_date"`
 	Type        int     `json:"type"`
 }
@@ -203,6 +204,14 @@ type FutureMarket struct {
 	Lot             string `json:"minAmount"`
 	QuoteID         string `json:"buyerCurrencyName"`
 	BaseID          string `json:"sellerCurrencyName"`
+	MaxLeverage     int    `json:"maxLeverage"`
+}
+
+// FutureSetting marginMode 1:逐仓 2:全仓, positionsMode 1:单向 2:双向
+type FutureSetting struct {
+	Leverage      int `json:"leverage"`
+	MarginMode    int `json:"marginMode"`
+	PositionsMode int `json:"positionsMode"`
 }
 
 type FutureTicker []float64
@@ -289,6 +298,44 @@ func (o FutureOrder) parseOrder(symbol string) (order wsex.Order) {
 	return
 }
 
+// FutureAlgoOrder 计划委托/止盈止损委托
+type FutureAlgoOrder struct {
+	ID           string `json:"id"`
+	Side         int    `json:"side"`
+	OrderType    int    `json:"orderType"` //1:计划委托 2:止盈止损
+	BizType      int    `json:"bizType"`   //1:止盈 2:止损
+	PriceType    int    `json:"priceType"` //1:标记价格 2:最新价格
+	TriggerPrice string `json:"triggerPrice"`
+	AlgoPrice    string `json:"algoPrice"`
+	Amount       string `json:"amount"`
+	Status       int    `json:"status"` //1:等待委托 2:已取消 3:已委托 4:委托失败 5:已完成
+	CreateTime   string `json:"createTime"`
+}
+
+func (o FutureAlgoOrder) parseOrder(symbol string) (order wsex.Order) {
+	order = FutureOrder{ID: o.ID, Price: o.AlgoPrice, Side: o.Side, TotalAmount: o.Amount, TradeDate: o.CreateTime}.parseOrder(symbol)
+	order.StopPrice = o.TriggerPrice
+	order.Type = wsex.STOP_LIMIT
+	if o.OrderType == 2 && o.BizType == 1 {
+		order.Type = wsex.TAKE_PROFIT_LIMIT
+	}
+	order.TriggerType = wsex.TriggerLast
+	if o.PriceType == 1 {
+		order.TriggerType = wsex.TriggerMark
+	}
+	switch o.Status {
+	case 1:
+		order.Status = wsex.Open
+	case 3, 5:
+		order.Status = wsex.Close
+	case 2, 4:
+		order.Status = wsex.Canceled
+	default:
+		order.Status = wsex.OrderStatusUnKnown
+	}
+	return
+}
+
 type FutureBalance struct {
 	Currency  string `json:"currencyName" ws:"unit"`
 	Available string `json:"amount" ws:"available"`
@@ -304,6 +351,7 @@ func (b FutureBalance) parseBalance() wsex.Balance {
 }
 
 type FuturePosition struct {
+	ID             string `json:"id"`             //仓位ID
 	AvgPrice       string `json:"avgPrice"`       //开仓均价
 	LiquidatePrice string `json:"liquidatePrice"` //强平价格
 	Margin         string `json:"margin"`         //保证金
@@ -316,9 +364,11 @@ type FuturePosition struct {
 	Leverage       int    `json:"leverage"`       //杠杆倍数
 	Symbol         string `json:"marketName"`     //marketName
 	MarginRate     string `json:"marginRate"`
+	UnrealizedPnl  string `json:"unrealizedPnl"` //未实现盈亏
+	NominalValue   string `json:"nominalValue"`  //名义价值
 }
 
-func (p FuturePosition) parsePositions(coin, symbol string) (positions wsex.FuturePositons) {
+func (p FuturePosition) parsePositions(coin, symbol string) (positions wsex.Position) {
 
 	switch p.Side {
 	case 0:
@@ -336,14 +386,16 @@ func (p FuturePosition) parsePositions(coin, symbol string) (positions wsex.Futu
 	}
 	positions.Coin = coin
 	positions.Symbol = symbol
-	positions.AvgPrice = p.AvgPrice
-	positions.FreezeAmount = p.FreezeAmount
+	positions.AvgPrice = SafeParseFloat(p.AvgPrice)
+	positions.FreezeAmount = SafeParseFloat(p.FreezeAmount)
 	positions.Leverage = p.Leverage
-	positions.LiquidatePrice = p.LiquidatePrice
-	positions.Margin = p.Margin
-	positions.MarginBalance = p.MarginBalance
-	positions.MarginRate = p.MarginRate
-	positions.MaintainMargin = p.MaintainMargin
+	positions.LiquidatePrice = SafeParseFloat(p.LiquidatePrice)
+	positions.Margin = SafeParseFloat(p.Margin)
+	positions.MarginBalance = SafeParseFloat(p.MarginBalance)
+	positions.MarginRate = SafeParseFloat(p.MarginRate)
+	positions.MaintainMargin = SafeParseFloat(p.MaintainMargin)
+	positions.UnrealizedPnl = SafeParseFloat(p.UnrealizedPnl)
+	positions.Notional = SafeParseFloat(p.NominalValue)
 	return positions
 }
 
@@ -365,11 +417,14 @@ type FutureFundingRate struct {
 	NextTimestamp string `json:"nextCalculateTime"`
 }
 
-func (f FutureFundingRate) parseFundingRate() (fundingRate wsex.FundingRate) {
+func (f FutureFundingRate) parseFundingRate(symbol string) (fundingRate wsex.FundingRate) {
+	fundingRate.Symbol = symbol
 	fundingRate.Rate = f.Rate
-	t, err := time.Parse("2006-01-02 15:04:05", f.NextTimestamp)
+	fundingRate.Interval = 8 * time.Hour / time.Millisecond
+	// 北京时间, 转为毫秒时间戳, 之前的版本为秒
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", f.NextTimestamp, time.FixedZone("CST", 8*3600))
 	if err == nil {
-		fundingRate.NextTimestamp = time.Duration(t.Unix())
+		fundingRate.NextTimestamp = time.Duration(t.UnixNano() / 1e6)
 	}
 	return
 }
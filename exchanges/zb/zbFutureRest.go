@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
 	"sort"
@@ -26,12 +27,16 @@ type ZbFutureRest struct {
 	accountType  wsex.FutureAccountType
 	contractType wsex.ContractType
 	futuresKind  wsex.FuturesKind
+	maxLeverage  map[string]int // key: symbol
 	exchanges.BaseExchange
 	errors map[int]int
 }
 
 func (e *ZbFutureRest) Init(option wsex.Options) {
 	e.Option = option
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.ZB
+	}
 	e.errors = map[int]int{
 		10027: wsex.ErrExchangeSystem,
 		10028: wsex.ErrExchangeSystem,
@@ -95,7 +100,7 @@ func (e *ZbFutureRest) FetchOrderBook(symbol string, size int) (orderBook wsex.O
 		}
 		orderBook.Bids = append(orderBook.Bids, item)
 	}
-	sort.Reverse(orderBook.Bids)
+	sort.Sort(sort.Reverse(orderBook.Bids))
 	sort.Sort(orderBook.Asks)
 	return
 }
@@ -263,6 +268,42 @@ func (e *ZbFutureRest) FetchMarkPrice(symbol string) (markPrice wsex.MarkPrice,
 	return
 }
 
+func (e *ZbFutureRest) FetchIndexPrice(symbol string) (indexPrice wsex.IndexPrice, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/api/public/v1/indexPrice", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var response struct {
+		Data map[string]string `json:"data"`
+	}
+	if err = json.Unmarshal(res, &response); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	indexPrice.Price = response.Data[market.SymbolID]
+	indexPrice.Symbol = symbol
+	return
+}
+
+// FetchOpenInterest zb future has no open interest endpoint
+func (e *ZbFutureRest) FetchOpenInterest(symbol string) (wsex.OpenInterest, error) {
+	return wsex.OpenInterest{}, exchanges.ErrNotSupport("zb future", "open interest")
+}
+
+func (e *ZbFutureRest) FetchOpenInterestHistory(symbol string, period wsex.KLineType, limit int) ([]wsex.OpenInterest, error) {
+	return nil, exchanges.ErrNotSupport("zb future", "open interest history")
+}
+
+func (e *ZbFutureRest) FetchLongShortRatio(symbol string, period wsex.KLineType, limit int) ([]wsex.LongShortRatio, error) {
+	return nil, exchanges.ErrNotSupport("zb future", "long short ratio")
+}
+
 func (e *ZbFutureRest) FetchFundingRate(symbol string) (fundingRate wsex.FundingRate, err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -281,30 +322,40 @@ func (e *ZbFutureRest) FetchFundingRate(symbol string) (fundingRate wsex.Funding
 		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
 		return
 	}
-	fundingRate = response.Data.parseFundingRate()
+	fundingRate = response.Data.parseFundingRate(market.Symbol)
+	// 资金费率接口不含标记价格和指数价格
+	markPrice, err := e.FetchMarkPrice(symbol)
+	if err != nil {
+		return
+	}
+	indexPrice, err := e.FetchIndexPrice(symbol)
+	if err != nil {
+		return
+	}
+	fundingRate.MarkPrice, fundingRate.IndexPrice = markPrice.Price, indexPrice.Price
 	return
 }
 
+// FetchFundingRateHistory zb future has no funding rate history endpoint
+func (e *ZbFutureRest) FetchFundingRateHistory(symbol string, since time.Duration, limit int) ([]wsex.FundingRate, error) {
+	return nil, exchanges.ErrNotSupport("zb future", "funding rate history")
+}
+
+// FetchFundingPayments zb future has no funding payment endpoint
+func (e *ZbFutureRest) FetchFundingPayments(symbol string, since time.Duration) ([]wsex.FundingPayment, error) {
+	return nil, exchanges.ErrNotSupport("zb future", "funding payments")
+}
+
 func (e *ZbFutureRest) FetchMarkets() (map[string]wsex.Market, error) {
 	if len(e.Option.Markets) > 0 {
 		return e.Option.Markets, nil
 	}
-	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/Server/api/v2/config/marketList", url.Values{}, http.Header{})
+	list, err := e.fetchMarketList()
 	if err != nil {
 		return e.Option.Markets, err
 	}
-
-	type Markets struct {
-		Data []FutureMarket `json:"data"`
-	}
-	var markets = Markets{}
-	if err = json.Unmarshal(res, &markets); err != nil {
-		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
-		return e.Option.Markets, err
-	}
-
 	e.Option.Markets = make(map[string]wsex.Market)
-	for _, value := range markets.Data {
+	for _, value := range list {
 		market := wsex.Market{
 			SymbolID:        strings.ToUpper(value.Symbol),
 			Symbol:          fmt.Sprintf("%v/%v", strings.ToUpper(value.BaseID), strings.ToUpper(value.QuoteID)),
@@ -319,35 +370,87 @@ func (e *ZbFutureRest) FetchMarkets() (map[string]wsex.Market, error) {
 	return e.Option.Markets, nil
 }
 
+// fetchMarketList the market list also keeps the max leverage of the markets
+func (e *ZbFutureRest) fetchMarketList() ([]FutureMarket, error) {
+	res, err := e.Fetch(e, exchanges.Public, exchanges.GET, "/Server/api/v2/config/marketList", url.Values{}, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	type Markets struct {
+		Data []FutureMarket `json:"data"`
+	}
+	var markets = Markets{}
+	if err = json.Unmarshal(res, &markets); err != nil {
+		return nil, wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+	}
+	e.RwLock.Lock()
+	defer e.RwLock.Unlock()
+	e.maxLeverage = make(map[string]int)
+	for _, value := range markets.Data {
+		symbol := fmt.Sprintf("%v/%v", strings.ToUpper(value.BaseID), strings.ToUpper(value.QuoteID))
+		e.maxLeverage[symbol] = value.MaxLeverage
+	}
+	return markets.Data, nil
+}
+
 func (e *ZbFutureRest) CreateOrder(symbol string, price, amount float64, side wsex.Side, tradeType wsex.TradeType, orderType wsex.OrderType, useClientID bool) (order wsex.Order, err error) {
-	market, err := e.GetMarket(symbol)
+	return e.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Price: price, Amount: amount, Side: side, Type: tradeType, OrderType: orderType, UseClientID: useClientID})
+}
+
+func (e *ZbFutureRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
+	market, err := e.GetMarket(req.Symbol)
 	if err != nil {
 		return
 	}
-	params := url.Values{}
-	params.Set("price", utils.Round(price, market.PricePrecision, false))
-	params.Set("amount", utils.Round(amount, market.AmountPrecision, false))
-	t := "1"
-	if side == wsex.OpenShort {
-		t = "2"
+	req = req.Normalize()
+	if req.Type.IsConditional() {
+		return e.placeAlgoOrder(market, req)
 	}
-	if side == wsex.CloseLong {
-		t = "3"
+	switch {
+	case req.ClosePosition:
+		err = exchanges.ErrNotSupport("zb future", "close position order")
+	case req.QuoteAmount > 0:
+		err = exchanges.ErrNotSupport("zb future", "quote amount")
+	case req.Type == wsex.MARKET && req.OrderType == wsex.PostOnly:
+		err = exchanges.ErrNotSupport("zb future", "post only market order")
 	}
-	if side == wsex.CloseShort {
-		t = "4"
+	if err != nil {
+		return
 	}
-	params.Set("side", t)
-	if tradeType == wsex.MARKET {
-		params.Set("action", "11")
+	side, err := futureSide(req.Side, req.PositionSide, req.ReduceOnly)
+	if err != nil {
 		return
 	}
+	params := url.Values{}
+	params.Set("amount", utils.Round(req.Amount, market.AmountPrecision, false))
+	params.Set("side", side)
+	if req.Type == wsex.MARKET {
+		// action 11:对手价 31:对手价IOC 51:对手价FOK, no price
+		switch req.OrderType {
+		case wsex.IOC:
+			params.Set("action", "31")
+		case wsex.FOK:
+			params.Set("action", "51")
+		default:
+			params.Set("action", "11")
+		}
+	} else {
+		params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+		switch req.OrderType {
+		case wsex.IOC:
+			params.Set("action", "3")
+		case wsex.PostOnly:
+			params.Set("action", "4")
+		case wsex.FOK:
+			params.Set("action", "5")
+		}
+	}
 	params.Set("symbol", market.SymbolID)
-	clientOrderId := ""
-	if useClientID {
-		clientOrderId = utils.GenerateOrderClientId(e.Option.ClientOrderIDPrefix, 32)
+	clientOrderId := req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32)
+	if clientOrderId != "" {
 		params.Set("clientOrderId", clientOrderId)
 	}
+	exchanges.MergeParams(params, req.Params)
 	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/trade/order", params, http.Header{})
 	if err != nil {
 		return
@@ -369,6 +472,67 @@ func (e *ZbFutureRest) CreateOrder(symbol string, price, amount float64, side ws
 	return
 }
 
+// placeAlgoOrder 开仓为计划委托,平仓为止盈止损委托,只支持限价
+func (e *ZbFutureRest) placeAlgoOrder(market wsex.Market, req wsex.OrderRequest) (order wsex.Order, err error) {
+	if err = exchanges.CheckConditionalRequest("zb future", req, wsex.TriggerLast, wsex.TriggerMark); err != nil {
+		return
+	}
+	switch {
+	case !req.Type.IsLimit():
+		err = exchanges.ErrNotSupport("zb future", fmt.Sprintf("%s order", req.Type))
+	case req.ClosePosition:
+		err = exchanges.ErrNotSupport("zb future", "close position order")
+	case req.QuoteAmount > 0:
+		err = exchanges.ErrNotSupport("zb future", "quote amount")
+	case req.OrderType == wsex.PostOnly || req.OrderType == wsex.FOK || req.OrderType == wsex.IOC:
+		err = exchanges.ErrNotSupport("zb future", "time in force of conditional order")
+	case req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32) != "":
+		err = exchanges.ErrNotSupport("zb future", "client order id of conditional order")
+	}
+	if err != nil {
+		return
+	}
+	side, err := futureSide(req.Side, req.PositionSide, req.ReduceOnly)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("side", side)
+	params.Set("amount", utils.Round(req.Amount, market.AmountPrecision, false))
+	params.Set("triggerPrice", utils.Round(req.StopPrice, market.PricePrecision, false))
+	params.Set("algoPrice", utils.Round(req.Price, market.PricePrecision, false))
+	// priceType 1:标记价格 2:最新价格
+	params.Set("priceType", "2")
+	if req.TriggerType == wsex.TriggerMark {
+		params.Set("priceType", "1")
+	}
+	// orderType 1:计划委托 2:止盈止损, bizType 1:止盈 2:止损
+	if side == "1" || side == "2" {
+		params.Set("orderType", "1")
+	} else {
+		params.Set("orderType", "2")
+		params.Set("bizType", "2")
+		if req.Type == wsex.TAKE_PROFIT_LIMIT {
+			params.Set("bizType", "1")
+		}
+	}
+	exchanges.MergeParams(params, req.Params)
+	res, err := e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/trade/orderAlgo", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data struct {
+		ID string `json:"data"`
+	}
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	order.ID = data.ID
+	return
+}
+
 func (e *ZbFutureRest) CancelOrder(symbol, orderID string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -385,6 +549,61 @@ func (e *ZbFutureRest) CancelOrder(symbol, orderID string) (err error) {
 	return err
 }
 
+// futureSide 1:开多 2:开空 3:平多 4:平空, Buy/Sell is mapped by the position side,
+// the reduce only Buy/Sell always closes the opposite position
+func futureSide(side wsex.Side, positionSide wsex.PositionType, reduceOnly bool) (string, error) {
+	if reduceOnly {
+		switch side {
+		case wsex.Sell, wsex.CloseLong:
+			return "3", nil
+		case wsex.Buy, wsex.CloseShort:
+			return "4", nil
+		}
+		return "", exchanges.ErrNotSupport("zb future", fmt.Sprintf("reduce only %s order", side))
+	}
+	switch side {
+	case wsex.OpenLong:
+		return "1", nil
+	case wsex.OpenShort:
+		return "2", nil
+	case wsex.CloseLong:
+		return "3", nil
+	case wsex.CloseShort:
+		return "4", nil
+	case wsex.Buy:
+		if positionSide == wsex.PositionShort {
+			return "4", nil
+		}
+		return "1", nil
+	case wsex.Sell:
+		if positionSide == wsex.PositionLong {
+			return "3", nil
+		}
+		return "2", nil
+	}
+	return "", exchanges.ErrNotSupport("zb future", fmt.Sprintf("side %s", side))
+}
+
+// CreateOrders zb future has no batch endpoint, orders are placed concurrently
+func (e *ZbFutureRest) CreateOrders(requests []wsex.OrderRequest) ([]wsex.OrderResult, error) {
+	return exchanges.CreateOrdersConcurrently(requests, e.PlaceOrder), nil
+}
+
+// CancelOrders zb future has no batch endpoint, orders are cancelled concurrently
+func (e *ZbFutureRest) CancelOrders(symbol string, orderIDs []string) ([]wsex.CancelResult, error) {
+	if _, err := e.GetMarket(symbol); err != nil {
+		return nil, err
+	}
+	return exchanges.CancelOrdersConcurrently(orderIDs, func(orderID string) error {
+		return e.CancelOrder(symbol, orderID)
+	}), nil
+}
+
+// AmendOrder no amend endpoint, the order is cancelled and created again
+func (e *ZbFutureRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (wsex.AmendResult, error) {
+	return exchanges.AmendOrderByCancelCreate(e, symbol, orderID, newPrice, newAmount)
+}
+
 func (e *ZbFutureRest) CancelAllOrders(symbol string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
@@ -456,46 +675,186 @@ func (e *ZbFutureRest) FetchOpenOrders(symbol string, pageIndex, pageSize int) (
 	return
 }
 
-func (e *ZbFutureRest) Setting(symbol string, leverage int, marginMode wsex.FutureMarginMode, positionMode wsex.FuturePositionsMode) error {
+//FetchConditionalOrders : 获取等待委托的计划委托和止盈止损
+func (e *ZbFutureRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set("status", "1")
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/Server/api/v2/trade/getOrderAlgos", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data struct {
+		Data struct {
+			List []FutureAlgoOrder
+		} `json:"data"`
+	}
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	for _, order := range data.Data.List {
+		orders = append(orders, order.parseOrder(market.Symbol))
+	}
+	return
+}
+
+func (e *ZbFutureRest) CancelConditionalOrder(symbol, orderID string) (err error) {
 	market, err := e.GetMarket(symbol)
 	if err != nil {
+		return
+	}
+	params, err := utils.RawJsonValues(map[string]interface{}{"symbol": market.SymbolID, "ids": []string{orderID}})
+	if err != nil {
+		return
+	}
+	_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/trade/cancelAlgos", params, http.Header{})
+	return
+}
+
+// Setting 依次设置持仓模式、保证金模式和杠杆
+func (e *ZbFutureRest) Setting(symbol string, leverage int, marginMode wsex.FutureMarginMode, positionMode wsex.FuturePositionsMode) error {
+	if _, err := e.GetMarket(symbol); err != nil {
 		return err
 	}
-	if leverage > 100 {
-		leverage = 100
+	return exchanges.ApplySetting(e, symbol, leverage, marginMode, positionMode)
+}
+
+func (e *ZbFutureRest) setting(symbol string) (setting FutureSetting, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
 	}
 	params := url.Values{}
 	params.Set("symbol", market.SymbolID)
-	params.Set("leverage", strconv.Itoa(leverage))
 	params.Set("futuresAccountType", e.getAccountType())
-	_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/setting/setLeverage", params, http.Header{})
-	// if err != nil {
-	// 	return err
-	// }
-	// params = url.Values{}
-	// margin := 1
-	// if marginMode == wsex.CrossedMargin {
-	// 	margin = 2
-	// }
-	// params.Set("symbol", market.SymbolID)
-	// params.Set("marginMode", strconv.Itoa(margin))
-	// params.Set("futuresAccountType", e.getAccountType())
-	// _, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/setting/setMarginMode", params, http.Header{})
-	// if err != nil {
-	// 	return err
-	// }
-	// params = url.Values{}
-	// position := 1
-	// if positionMode == wsex.TwoWay {
-	// 	position = 2
-	// }
-	// params.Set("symbol", market.SymbolID)
-	// params.Set("positionsMode", strconv.Itoa(position))
-	// params.Set("futuresAccountType", e.getAccountType())
-	// _, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/setting/setPositionsMode", params, http.Header{})
+	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "/Server/api/v2/setting/get", params, http.Header{})
+	if err != nil {
+		return
+	}
+	var data struct {
+		Data FutureSetting `json:"data"`
+	}
+	if err = json.Unmarshal(res, &data); err != nil {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
+		return
+	}
+	return data.Data, nil
+}
+
+func (e *ZbFutureRest) updateSetting(symbol, function, key, value string) error {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("symbol", market.SymbolID)
+	params.Set(key, value)
+	params.Set("futuresAccountType", e.getAccountType())
+	_, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/setting/"+function, params, http.Header{})
 	return err
 }
 
+func (e *ZbFutureRest) GetLeverage(symbol string) (int, error) {
+	setting, err := e.setting(symbol)
+	return setting.Leverage, err
+}
+
+// SetLeverage the leverage must be between 1 and the max leverage of the market
+func (e *ZbFutureRest) SetLeverage(symbol string, leverage int) error {
+	maxLeverage, err := e.FetchMaxLeverage(symbol)
+	if err != nil {
+		return err
+	}
+	if leverage < 1 || leverage > maxLeverage {
+		return wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("leverage %d of %s is out of range [1, %d]", leverage, symbol, maxLeverage)}
+	}
+	return e.updateSetting(symbol, "setLeverage", "leverage", strconv.Itoa(leverage))
+}
+
+func (e *ZbFutureRest) GetMarginMode(symbol string) (wsex.FutureMarginMode, error) {
+	setting, err := e.setting(symbol)
+	if err != nil {
+		return "", err
+	}
+	if setting.MarginMode == 2 {
+		return wsex.CrossedMargin, nil
+	}
+	return wsex.FixedMargin, nil
+}
+
+// SetMarginMode nothing is sent if the margin mode is not changed
+func (e *ZbFutureRest) SetMarginMode(symbol string, marginMode wsex.FutureMarginMode) error {
+	current, err := e.GetMarginMode(symbol)
+	if err != nil || current == marginMode {
+		return err
+	}
+	margin := "1"
+	if marginMode == wsex.CrossedMargin {
+		margin = "2"
+	}
+	return e.updateSetting(symbol, "setMarginMode", "marginMode", margin)
+}
+
+func (e *ZbFutureRest) GetPositionMode(symbol string) (wsex.FuturePositionsMode, error) {
+	setting, err := e.setting(symbol)
+	if err != nil {
+		return "", err
+	}
+	if setting.PositionsMode == 2 {
+		return wsex.TwoWay, nil
+	}
+	return wsex.OneWay, nil
+}
+
+// SetPositionMode nothing is sent if the position mode is not changed
+func (e *ZbFutureRest) SetPositionMode(symbol string, positionMode wsex.FuturePositionsMode) error {
+	current, err := e.GetPositionMode(symbol)
+	if err != nil || current == positionMode {
+		return err
+	}
+	position := "1"
+	if positionMode == wsex.TwoWay {
+		position = "2"
+	}
+	return e.updateSetting(symbol, "setPositionsMode", "positionsMode", position)
+}
+
+// FetchLeverageBrackets zb future has no bracket, the only one is the max leverage of the market list
+func (e *ZbFutureRest) FetchLeverageBrackets(symbol string) (brackets []wsex.LeverageBracket, err error) {
+	leverage, err := e.FetchMaxLeverage(symbol)
+	if err != nil {
+		return
+	}
+	return []wsex.LeverageBracket{{Bracket: 1, MaxLeverage: leverage}}, nil
+}
+
+// FetchMaxLeverage the max leverage is fetched with the market list on demand, the preset markets don't carry it
+func (e *ZbFutureRest) FetchMaxLeverage(symbol string) (int, error) {
+	if _, err := e.GetMarket(symbol); err != nil {
+		return 0, err
+	}
+	e.RwLock.RLock()
+	leverage, ok := e.maxLeverage[symbol]
+	e.RwLock.RUnlock()
+	if !ok {
+		if _, err := e.fetchMarketList(); err != nil {
+			return 0, err
+		}
+		e.RwLock.RLock()
+		leverage = e.maxLeverage[symbol]
+		e.RwLock.RUnlock()
+	}
+	if leverage > 0 {
+		return leverage, nil
+	}
+	return 0, wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("no max leverage of %s", symbol)}
+}
+
 func (e *ZbFutureRest) FetchBalance() (balances map[string]wsex.Balance, err error) {
 	params := url.Values{}
 	params.Set("futuresAccountType", e.getAccountType())
@@ -520,7 +879,23 @@ func (e *ZbFutureRest) FetchBalance() (balances map[string]wsex.Balance, err err
 	return
 }
 
-func (e *ZbFutureRest) FetchPositions(symbol string) (positions []wsex.FuturePositons, err error) {
+func (e *ZbFutureRest) FetchPositions(symbol string) (positions []wsex.Position, err error) {
+	data, err := e.fetchPositions(symbol)
+	if err != nil {
+		return
+	}
+	positions = make([]wsex.Position, 0)
+	for _, position := range data {
+		m, err := e.GetMarketByID(position.Symbol)
+		if err != nil {
+			continue
+		}
+		positions = append(positions, position.parsePositions(m.BaseID, m.Symbol))
+	}
+	return
+}
+
+func (e *ZbFutureRest) fetchPositions(symbol string) (positions []FuturePosition, err error) {
 	params := url.Values{}
 	if symbol != "" {
 		market, err1 := e.GetMarket(symbol)
@@ -543,17 +918,77 @@ func (e *ZbFutureRest) FetchPositions(symbol string) (positions []wsex.FuturePos
 		err = wsex.ExError{Code: wsex.ErrDataParse, Message: err.Error()}
 		return
 	}
-	positions = make([]wsex.FuturePositons, 0)
-	for _, position := range data.Data {
-		m, err := e.GetMarketByID(position.Symbol)
-		if err != nil {
-			continue
+	return data.Data, nil
+}
+
+// AdjustPositionMargin 调整保证金需要仓位ID, type 1:增加 0:减少
+func (e *ZbFutureRest) AdjustPositionMargin(symbol string, positionType wsex.PositionType, amount float64) (position wsex.Position, err error) {
+	market, err := e.GetMarket(symbol)
+	if err != nil {
+		return
+	}
+	if amount == 0 {
+		err = wsex.ExError{Code: wsex.ErrRequestParams, Message: "margin amount is required"}
+		return
+	}
+	side := 1
+	if positionType == wsex.PositionShort {
+		side = 0
+	}
+	positions, err := e.fetchPositions(symbol)
+	if err != nil {
+		return
+	}
+	var id string
+	for _, p := range positions {
+		if p.Side == side && utils.SafeParseFloat(p.Amount) != 0 {
+			id = p.ID
 		}
-		positions = append(positions, position.parsePositions(m.BaseID, m.Symbol))
+	}
+	if id == "" {
+		err = wsex.ExError{Code: wsex.ErrRequestParams, Message: fmt.Sprintf("no %s position of %s", positionType, symbol)}
+		return
+	}
+	params := url.Values{}
+	params.Set("positionsId", id)
+	params.Set("amount", strconv.FormatFloat(math.Abs(amount), 'f', -1, 64))
+	params.Set("type", "1")
+	if amount < 0 {
+		params.Set("type", "0")
+	}
+	params.Set("futuresAccountType", e.getAccountType())
+	if _, err = e.Fetch(e, exchanges.Private, exchanges.POST, "/Server/api/v2/Positions/updateMargin", params, http.Header{}); err != nil {
+		return
+	}
+	updated, err := e.FetchPositions(symbol)
+	if err != nil {
+		return
+	}
+	position, ok := exchanges.FindPosition(updated, market.Symbol, positionType)
+	if !ok {
+		err = wsex.ExError{Code: wsex.ErrDataParse, Message: fmt.Sprintf("no %s position of %s", positionType, symbol)}
 	}
 	return
 }
 
+// FetchMarginHistory zb future has no margin adjustment history endpoint
+func (e *ZbFutureRest) FetchMarginHistory(symbol string) ([]wsex.MarginChange, error) {
+	return nil, exchanges.ErrNotSupport("zb future", "margin history")
+}
+
+// ClosePosition 对手价市价单平掉整个仓位
+func (e *ZbFutureRest) ClosePosition(symbol string, positionType wsex.PositionType) (order wsex.Order, err error) {
+	positions, err := e.FetchPositions(symbol)
+	if err != nil {
+		return
+	}
+	req, err := exchanges.ClosePositionRequest(positions, symbol, positionType)
+	if err != nil {
+		return
+	}
+	return e.PlaceOrder(req)
+}
+
 func (e *ZbFutureRest) FetchAccountInfo() (accountInfo wsex.FutureAccountInfo, err error) {
 	params := url.Values{}
 	params.Set("futuresAccountType", e.getAccountType())
@@ -576,11 +1011,11 @@ func (e *ZbFutureRest) FetchAccountInfo() (accountInfo wsex.FutureAccountInfo, e
 	if err != nil {
 		return
 	}
-	accountInfo.Positions = make(map[string]map[wsex.PositionType]wsex.FuturePositons)
+	accountInfo.Positions = make(map[string]map[wsex.PositionType]wsex.Position)
 	for _, position := range positions {
 		ps, ok := accountInfo.Positions[position.Coin]
 		if !ok {
-			ps = make(map[wsex.PositionType]wsex.FuturePositons)
+			ps = make(map[wsex.PositionType]wsex.Position)
 		}
 		ps[position.PositionType] = position
 		accountInfo.Positions[position.Coin] = ps
@@ -588,7 +1023,12 @@ func (e *ZbFutureRest) FetchAccountInfo() (accountInfo wsex.FutureAccountInfo, e
 	return
 }
 
-func (e *ZbFutureRest) FetchAllPositions() (positions []wsex.FuturePositons, err error) {
+func (e *ZbFutureRest) FetchAllPositions() (positions []wsex.Position, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *ZbFutureRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
 	err = wsex.ExError{Code: wsex.NotImplement}
 	return
 }
@@ -121,6 +121,30 @@ func TestZbFutureRest_Setting(t *testing.T) {
 	}
 }
 
+func TestZbFutureRest_GetSetting(t *testing.T) {
+	leverage, err := zbFuture.GetLeverage(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	marginMode, err := zbFuture.GetMarginMode(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	positionMode, err := zbFuture.GetPositionMode(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(leverage, marginMode, positionMode)
+}
+
+func TestZbFutureRest_FetchLeverageBrackets(t *testing.T) {
+	brackets, err := zbFuture.FetchLeverageBrackets(symbol)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(brackets)
+}
+
 func TestZbFutureRest_CreateOrder(t *testing.T) {
 	order, err := zbFuture.CreateOrder(symbol, 45700, 1, wsex.OpenLong, wsex.LIMIT, wsex.PostOnly, false)
 	if err != nil {
@@ -176,3 +200,11 @@ func TestZbFutureRest_Pressure(t *testing.T) {
 		time.Sleep(time.Second)
 	}
 }
+
+func TestZbFutureRest_AdjustPositionMargin(t *testing.T) {
+	position, err := zbFuture.AdjustPositionMargin(symbol, wsex.PositionLong, 1)
+	if err != nil {
+		t.Error(err)
+	}
+	t.Log(position)
+}
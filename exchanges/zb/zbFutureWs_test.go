@@ -118,6 +118,12 @@ func TestZbFutureWs_SubscribeMarkPrice(t *testing.T) {
 	}
 }
 
+func TestZbFutureWs_SubscribeIndexPrice(t *testing.T) {
+	if _, err := zbFuture.SubscribeIndexPrice(symbol, msgChan); err == nil {
+		handleFutureMsg(msgChan)
+	}
+}
+
 func TestZbFutureWs_SubscribeBalance(t *testing.T) {
 	if _, err := zbFuture.SubscribeBalance(symbol, msgChan); err == nil {
 		handleFutureMsg(msgChan)
@@ -134,3 +140,9 @@ func TestZbFutureWs_SubscribePositions(t *testing.T) {
 		handleFutureMsg(msgChan)
 	}
 }
+
+func TestZbFutureWs_SubscribeAccount(t *testing.T) {
+	if _, err := zbFuture.SubscribeAccount(msgChan); err == nil {
+		handleFutureMsg(msgChan)
+	}
+}
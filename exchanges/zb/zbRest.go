@@ -32,6 +32,9 @@ type ZbRest struct {
 
 func (e *ZbRest) Init(option wsex.Options) {
 	e.Option = option
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.ZB
+	}
 	e.errors = map[int]int{
 		1001: wsex.ErrExchangeSystem,
 		3001: wsex.ErrOrderNotFound,
@@ -92,7 +95,7 @@ func (e *ZbRest) FetchOrderBook(symbol string, size int) (orderBook wsex.OrderBo
 		}
 		orderBook.Bids = append(orderBook.Bids, item)
 	}
-	sort.Reverse(orderBook.Bids)
+	sort.Sort(sort.Reverse(orderBook.Bids))
 	sort.Sort(orderBook.Asks)
 	return
 }
@@ -290,31 +293,49 @@ func (e *ZbRest) FetchBalance() (balances map[string]wsex.Balance, err error) {
 }
 
 func (e *ZbRest) CreateOrder(symbol string, price, amount float64, side wsex.Side, tradeType wsex.TradeType, orderType wsex.OrderType, useClientID bool) (order wsex.Order, err error) {
-	market, err := e.GetMarket(symbol)
+	return e.PlaceOrder(wsex.OrderRequest{Symbol: symbol, Price: price, Amount: amount, Side: side, Type: tradeType, OrderType: orderType, UseClientID: useClientID})
+}
+
+func (e *ZbRest) PlaceOrder(req wsex.OrderRequest) (order wsex.Order, err error) {
+	market, err := e.GetMarket(req.Symbol)
+	if err != nil {
+		return
+	}
+	req = req.Normalize()
+	if err = exchanges.CheckSpotRequest("zb spot", req); err != nil {
+		return
+	}
+	switch {
+	case req.Type.IsConditional():
+		err = exchanges.ErrNotSupport("zb spot", fmt.Sprintf("%s order", req.Type))
+	case req.Type == wsex.MARKET:
+		err = exchanges.ErrNotSupport("zb spot", "market order")
+	case req.OrderType == wsex.FOK:
+		err = exchanges.ErrNotSupport("zb spot", "fok order")
+	}
 	if err != nil {
 		return
 	}
 	params := url.Values{}
-	params.Set("price", utils.Round(price, market.PricePrecision, false))
-	params.Set("amount", utils.Round(amount, market.AmountPrecision, false))
+	params.Set("price", utils.Round(req.Price, market.PricePrecision, false))
+	params.Set("amount", utils.Round(req.Amount, market.AmountPrecision, false))
 	t := "1"
-	if side == wsex.Sell {
+	if req.Side == wsex.Sell {
 		t = "0"
 	}
 	params.Set("tradeType", t)
-	if orderType != wsex.Normal {
-		ot := "1" // PostOnly
-		if orderType == wsex.IOC {
-			ot = "2"
-		}
-		params.Set("orderType", ot)
+	switch req.OrderType {
+	case wsex.PostOnly:
+		params.Set("orderType", "1")
+	case wsex.IOC:
+		params.Set("orderType", "2")
 	}
 	params.Set("currency", market.SymbolID)
-	clientOrderId := ""
-	if useClientID {
-		clientOrderId = utils.GenerateOrderClientId(e.Option.ClientOrderIDPrefix, 32)
+	clientOrderId := req.ClientOrderID(e.Option.ClientOrderIDPrefix, 32)
+	if clientOrderId != "" {
 		params.Set("customerOrderId", clientOrderId)
 	}
+	exchanges.MergeParams(params, req.Params)
 	res, err := e.Fetch(e, exchanges.Private, exchanges.GET, "order", params, http.Header{})
 	if err != nil {
 		return
@@ -350,6 +371,26 @@ func (e *ZbRest) CancelOrder(symbol, orderID string) (err error) {
 	return err
 }
 
+// CreateOrders zb spot has no batch endpoint, orders are placed concurrently
+func (e *ZbRest) CreateOrders(requests []wsex.OrderRequest) ([]wsex.OrderResult, error) {
+	return exchanges.CreateOrdersConcurrently(requests, e.PlaceOrder), nil
+}
+
+// CancelOrders zb spot has no batch endpoint, orders are cancelled concurrently
+func (e *ZbRest) CancelOrders(symbol string, orderIDs []string) ([]wsex.CancelResult, error) {
+	if _, err := e.GetMarket(symbol); err != nil {
+		return nil, err
+	}
+	return exchanges.CancelOrdersConcurrently(orderIDs, func(orderID string) error {
+		return e.CancelOrder(symbol, orderID)
+	}), nil
+}
+
+// AmendOrder no amend endpoint, the order is cancelled and created again
+func (e *ZbRest) AmendOrder(symbol, orderID string, newPrice, newAmount float64) (wsex.AmendResult, error) {
+	return exchanges.AmendOrderByCancelCreate(e, symbol, orderID, newPrice, newAmount)
+}
+
 func (e *ZbRest) CancelAllOrders(symbol string) (err error) {
 	count := 0 //
 	for count < 100 {
@@ -423,6 +464,21 @@ func (e *ZbRest) FetchOpenOrders(symbol string, pageIndex, pageSize int) (orders
 	return
 }
 
+func (e *ZbRest) FetchConditionalOrders(symbol string) (orders []wsex.Order, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *ZbRest) CancelConditionalOrder(symbol, orderID string) (err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
+func (e *ZbRest) FetchTradingFees(symbol string) (fee wsex.TradingFee, err error) {
+	err = wsex.ExError{Code: wsex.NotImplement}
+	return
+}
+
 func (e *ZbRest) Sign(access, method, function string, param url.Values, header http.Header) exchanges.Request {
 	var request = exchanges.Request{Method: method}
 	if access == exchanges.Public {
@@ -479,6 +535,13 @@ func (e *ZbRest) parseOrder(orderInfo *OrderInfo, market wsex.Market) (order wse
 	order.Amount = strconv.FormatFloat(orderInfo.TotalAmount, 'f', market.AmountPrecision, 64)
 	order.Filled = fmt.Sprintf("%v", orderInfo.TradeAmount)
 	order.Cost = fmt.Sprintf("%v", orderInfo.TradeMoney)
+	order.Fee = fmt.Sprintf("%v", orderInfo.Fees)
+	// 买单手续费扣base币,卖单扣quote币
+	if order.Side == wsex.Buy {
+		order.FeeCurrency = market.BaseID
+	} else {
+		order.FeeCurrency = market.QuoteID
+	}
 	order.CreateTime = time.Duration(orderInfo.TradeDate)
 	order.Status = parseStatus(orderInfo.Status, orderInfo.TradeAmount)
 	return
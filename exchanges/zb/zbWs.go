@@ -40,6 +40,7 @@ type ZbWs struct {
 	exchanges.BaseExchange
 	orderBooks map[string]*SymbolOrderBook
 	errors     map[int]wsex.ExError
+	topicUrls  map[string]string // 深度按币种使用独立连接，退订时需要找到对应 url
 }
 
 func (e *ZbWs) Init(option wsex.Options) {
@@ -47,6 +48,13 @@ func (e *ZbWs) Init(option wsex.Options) {
 	e.Option = option
 	e.orderBooks = make(map[string]*SymbolOrderBook)
 	e.errors = map[int]wsex.ExError{}
+	e.topicUrls = make(map[string]string)
+	e.ConnectionMgr.SetMaxTopics(e.Option.MaxTopicsPerConn)
+	if e.Option.ExchangeName == "" {
+		e.Option.ExchangeName = wsex.ZB
+	}
+	e.ConnectionMgr.SetExchange(e.Option.ExchangeName)
+	e.ConnectionMgr.SetMetrics(e.Option.Metrics)
 
 	if e.Option.WsHost == "" {
 		e.Option.WsHost = "wss://api.zb.com/websocket"
@@ -127,18 +135,29 @@ func (e *ZbWs) SubscribeOrder(symbol string, sub wsex.MessageChan) (string, erro
 }
 
 func (e *ZbWs) UnSubscribe(topic string, sub wsex.MessageChan) error {
-	stream := Stream{"event": topic}
-	stream.unSubscribe()
-	conn, err := e.ConnectionMgr.GetConnection(e.Option.WsHost, nil)
+	e.RwLock.RLock()
+	url, ok := e.topicUrls[topic]
+	e.RwLock.RUnlock()
+	if !ok {
+		url = e.Option.WsHost
+	}
+	conn, err := e.ConnectionMgr.FindShard(url, topic)
 	if err != nil {
 		return err
 	}
+	if !conn.UnSubscribe(topic, sub) {
+		return nil
+	}
+	e.RwLock.Lock()
+	delete(e.topicUrls, topic)
+	e.RwLock.Unlock()
+	stream := Stream{"channel": topic}
+	stream.unSubscribe()
 	if err := conn.SendJsonMessage(stream); err != nil {
 		return err
 	}
-	conn.UnSubscribe(sub)
-
-	return nil
+	// close the empty shards, the topics are not moved
+	return e.ConnectionMgr.Rebalance(url, nil)
 }
 
 func (e *ZbWs) Connect(url string) (*exchanges.Connection, error) {
@@ -162,7 +181,8 @@ func (e *ZbWs) Connect(url string) (*exchanges.Connection, error) {
 }
 
 func (e *ZbWs) subscribe(url, topic string, needSign bool, stream Stream, sub wsex.MessageChan) (string, error) {
-	conn, err := e.ConnectionMgr.GetConnection(url, e.Connect)
+	// every private request is signed, so all the channels can be placed on any connection
+	conn, err := e.ConnectionMgr.GetShard(url, topic, sub, e.Connect)
 	if err != nil {
 		return "", err
 	}
@@ -175,10 +195,30 @@ func (e *ZbWs) subscribe(url, topic string, needSign bool, stream Stream, sub ws
 		stream.set("sign", signData)
 	}
 	stream.subscribe()
-	if err := e.send(conn, stream); err != nil {
+	e.RwLock.Lock()
+	e.topicUrls[topic] = url
+	e.RwLock.Unlock()
+	conn.Subscribe(topic, sub)
+	// zb has no ack of the public channel, the first push or the error of the channel is regarded as the ack,
+	// the private channel may not push for a long time, so its error is reported by MsgError
+	var ack <-chan error
+	if !needSign {
+		ack = e.SubscribeAck.Register(topic)
+	}
+	err = e.send(conn, stream)
+	if err != nil {
+		e.SubscribeAck.Done(topic, err)
+	} else if ack != nil {
+		err = e.WaitSubscribeAck(topic, ack)
+	}
+	if err != nil {
+		if conn.UnSubscribe(topic, sub) {
+			e.RwLock.Lock()
+			delete(e.topicUrls, topic)
+			e.RwLock.Unlock()
+		}
 		return "", err
 	}
-	conn.Subscribe(sub)
 	return topic, nil
 }
 
@@ -210,8 +250,12 @@ func (e *ZbWs) messageHandler(url string, message []byte) {
 		return
 	}
 
+	topic := channelTopic(res.Channel)
 	if res.Code != 0 && res.Code != 1000 {
-		e.errorHandler(url, e.handleError(res))
+		err := e.handleError(res)
+		if !e.SubscribeAck.Done(topic, err) {
+			e.errorHandler(url, err)
+		}
 		return
 	}
 
@@ -219,17 +263,18 @@ func (e *ZbWs) messageHandler(url string, message []byte) {
 		e.errorHandler(url, fmt.Errorf("[ZbWs] messageHandler - message no channel field, %v", res))
 		return
 	}
+	e.SubscribeAck.Done(topic, nil)
 
 	if strings.Contains(res.Channel, "depth") {
-		e.handleDepth(url, message)
+		e.handleDepth(url, topic, message)
 	} else if strings.Contains(res.Channel, "ticker") {
-		e.handleTicker(url, message)
+		e.handleTicker(url, topic, message)
 	} else if strings.Contains(res.Channel, "trades") {
-		e.handleTrade(url, message)
+		e.handleTrade(url, topic, message)
 	} else if strings.Contains(res.Channel, "record") {
-		e.handleOrder(url, message)
+		e.handleOrder(url, topic, message)
 	} else if strings.Contains(res.Channel, "asset") {
-		e.handleBalance(url, message)
+		e.handleBalance(url, topic, message)
 	} else {
 		e.errorHandler(url, fmt.Errorf("[ZbWs] messageHandler - not support this channel :%v", res.Channel))
 	}
@@ -264,7 +309,7 @@ func (e *ZbWs) heartbeatHandler(url string) {
 	conn.SendMessage([]byte("ping"))
 }
 
-func (e *ZbWs) handleDepth(url string, message []byte) {
+func (e *ZbWs) handleDepth(url, topic string, message []byte) {
 	//ZB doesn't support incremental push, push top [level] data each time.
 	data := RawOrderBook{}
 	if err := json.Unmarshal(message, &data); err != nil {
@@ -288,23 +333,23 @@ func (e *ZbWs) handleDepth(url string, message []byte) {
 		fullOrderBook.update(data)
 		fullOrderBook.Symbol = market.Symbol
 		(*symbolOrderBook)[market.Symbol] = fullOrderBook
-		e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
+		e.ConnectionMgr.Publish(url, topic, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
 	} else if fullOrderBook != nil {
 		if data.LastTime >= fullOrderBook.LastTime {
 			fullOrderBook.update(data)
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
+			e.ConnectionMgr.Publish(url, topic, wsex.Message{Type: wsex.MsgOrderBook, Data: fullOrderBook.OrderBook})
 		} else {
 			delete(*symbolOrderBook, market.Symbol)
 			err := wsex.ExError{Code: wsex.ErrInvalidDepth,
 				Message: fmt.Sprintf("[ZbWs] handleDepth - recv dirty data, new.LastTime: %v < old.LastTime: %v ", data.LastTime, fullOrderBook.LastTime),
 				Data:    map[string]interface{}{"symbol": fullOrderBook.Symbol}}
-			e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgError, Data: err})
+			e.ConnectionMgr.Publish(url, topic, wsex.Message{Type: wsex.MsgError, Data: err})
 			return
 		}
 	}
 }
 
-func (e *ZbWs) handleTicker(url string, message []byte) {
+func (e *ZbWs) handleTicker(url, topic string, message []byte) {
 	data := TickerRes{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[ZbWs] handleTicker - message Unmarshal to ticker error:%v", err))
@@ -312,10 +357,10 @@ func (e *ZbWs) handleTicker(url string, message []byte) {
 	}
 
 	ticker := data.parseTicker()
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
+	e.ConnectionMgr.Publish(url, topic, wsex.Message{Type: wsex.MsgTicker, Data: ticker})
 }
 
-func (e *ZbWs) handleTrade(url string, message []byte) {
+func (e *ZbWs) handleTrade(url, topic string, message []byte) {
 	data := TradeRes{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[ZbWs] handleTrade - message Unmarshal to trade error:%v", err))
@@ -326,10 +371,10 @@ func (e *ZbWs) handleTrade(url string, message []byte) {
 	}
 
 	trade := data.Trade[0].parseTrade()
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgTrade, Data: trade})
+	e.ConnectionMgr.Publish(url, topic, wsex.Message{Type: wsex.MsgTrade, Data: trade})
 }
 
-func (e *ZbWs) handleBalance(url string, message []byte) {
+func (e *ZbWs) handleBalance(url, topic string, message []byte) {
 	data := Balances{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[ZbWs] handleBalance - message Unmarshal to balance error:%v", err))
@@ -343,10 +388,10 @@ func (e *ZbWs) handleBalance(url string, message []byte) {
 		balances.Balances[balance.Asset] = balance
 	}
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgBalance, Data: balances})
+	e.ConnectionMgr.Publish(url, topic, wsex.Message{Type: wsex.MsgBalance, Data: balances})
 }
 
-func (e *ZbWs) handleOrder(url string, message []byte) {
+func (e *ZbWs) handleOrder(url, topic string, message []byte) {
 	data := Order{}
 	if err := json.Unmarshal(message, &data); err != nil {
 		e.errorHandler(url, fmt.Errorf("[ZbWs] handleOrder - message Unmarshal to handleOrder error:%v", err))
@@ -382,7 +427,7 @@ func (e *ZbWs) handleOrder(url string, message []byte) {
 	order.Status = parseStatus(int(status), filled)
 	order.Symbol = market.Symbol
 
-	e.ConnectionMgr.Publish(url, wsex.Message{Type: wsex.MsgOrder, Data: order})
+	e.ConnectionMgr.Publish(url, topic, wsex.Message{Type: wsex.MsgOrder, Data: order})
 }
 
 func (e *ZbWs) sign(params map[string]string) (string, error) {
@@ -399,7 +444,17 @@ func (e *ZbWs) sign(params map[string]string) (string, error) {
 }
 
 func (e *ZbWs) handleError(res ResponseEvent) wsex.ExError {
-	return wsex.ExError{}
+	return wsex.ExError{Code: wsex.ErrChannelNotExist, Message: res.Message, Data: map[string]interface{}{"code": res.Code, "channel": res.Channel}}
+}
+
+// channelTopic the topic of the pushed channel, the user data channels are subscribed with fixed topic
+func channelTopic(channel string) string {
+	if strings.Contains(channel, "record") {
+		return "push_user_incr_record"
+	} else if strings.Contains(channel, "asset") {
+		return "push_user_incr_asset"
+	}
+	return channel
 }
 
 func (e *ZbWs) GetMarketByID(symbolID string) (wsex.Market, error) {
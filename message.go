@@ -6,6 +6,8 @@
 */
 package wsex
 
+import "time"
+
 type MessageType int
 
 const (
@@ -23,11 +25,55 @@ const (
 	MsgDisConnected
 	MsgClosed
 	MsgError
+
+	MsgBookInvalid  // the local order book is broken, Data: BookInvalid
+	MsgBookResynced // the local order book is rebuilt, Data: BookResynced
+
+	MsgIndexPrice  // Data: IndexPrice
+	MsgLiquidation // Data: Liquidation
+	MsgAccount     // Data: AccountUpdate
+	MsgMarginCall  // Data: MarginCall
 )
 
+var messageTypeNames = [...]string{
+	MsgOrderBook:    "orderbook",
+	MsgTicker:       "ticker",
+	MsgAllTicker:    "all_ticker",
+	MsgTrade:        "trade",
+	MsgKLine:        "kline",
+	MsgBalance:      "balance",
+	MsgOrder:        "order",
+	MsgPositions:    "positions",
+	MsgMarkPrice:    "mark_price",
+	MsgReConnected:  "reconnected",
+	MsgDisConnected: "disconnected",
+	MsgClosed:       "closed",
+	MsgError:        "error",
+	MsgBookInvalid:  "book_invalid",
+	MsgBookResynced: "book_resynced",
+	MsgIndexPrice:   "index_price",
+	MsgLiquidation:  "liquidation",
+	MsgAccount:      "account",
+	MsgMarginCall:   "margin_call",
+}
+
+func (t MessageType) String() string {
+	if t >= 0 && int(t) < len(messageTypeNames) && messageTypeNames[t] != "" {
+		return messageTypeNames[t]
+	}
+	return "unknown"
+}
+
 type Message struct {
 	Type MessageType
 	Data interface{}
+
+	Exchange    string        // Options.ExchangeName
+	Symbol      string        // the unified symbol, empty if the message isn't about one symbol
+	Time        time.Duration // the event time of the exchange in ms, 0 if unknown
+	RecvTime    time.Duration // the local time receiving the message in ms
+	PublishTime time.Duration // the local time publishing the message to the subscribers in ms
+	Sequence    int64         // the sequence of the exchange, 0 if unknown
 }
 type MessageChan chan Message
 
@@ -0,0 +1,63 @@
+/*
+@Time : 2021/6/15 10:10 上午
+@Author : shiguantian
+@File : metrics
+@Software: GoLand
+*/
+package wsex
+
+import "time"
+
+type LatencyStage string
+
+const (
+	LatencyFeed    LatencyStage = "feed"    // from the event time of the exchange to receiving the websocket frame
+	LatencyProcess LatencyStage = "process" // from receiving the websocket frame to publishing the message
+)
+
+type ConnEvent string
+
+const (
+	ConnConnected    ConnEvent = "connected"
+	ConnReconnected  ConnEvent = "reconnected"
+	ConnDisconnected ConnEvent = "disconnected"
+	ConnClosed       ConnEvent = "closed"
+)
+
+// Metrics collect the runtime metrics of the exchanges, set it by Options.Metrics.
+// the methods are called by the websocket goroutines, so they must be concurrent safe and fast.
+// embed NopMetrics to implement part of them, see the package metrics/prometheus for the prometheus collectors
+type Metrics interface {
+	// ObserveLatency channel is the MessageType.String() of the message
+	ObserveLatency(exchange, channel string, stage LatencyStage, latency time.Duration)
+	// ConnectionEvent the state change of the websocket connection, reason is why it's disconnected or closed
+	ConnectionEvent(exchange, url string, event ConnEvent, reason string)
+	// MessagePublished a message is published, queueDepth is the max queued messages of the subscribers, buffered or being sent
+	MessagePublished(exchange, channel string, queueDepth int)
+	// MessageError the message handler failed, kind is "decode" or the code of ExError
+	MessageError(exchange, url, kind string)
+	// ObserveRest endpoint is the api path without the query, the ids and symbols in it are replaced with {id} and {symbol},
+	// status is the http status or 0 if the request failed
+	ObserveRest(exchange, endpoint string, status int, latency time.Duration)
+	// RateLimit the rate limit usage in the response headers, eg: X-MBX-USED-WEIGHT-1M of binance
+	RateLimit(exchange, name string, value float64)
+	// BookMismatch the local order book differs from the exchange, found by the checksum or the comparison with the snapshot
+	BookMismatch(exchange, symbol string)
+}
+
+// NopMetrics ignore all metrics
+type NopMetrics struct{}
+
+func (NopMetrics) ObserveLatency(string, string, LatencyStage, time.Duration) {}
+
+func (NopMetrics) ConnectionEvent(string, string, ConnEvent, string) {}
+
+func (NopMetrics) MessagePublished(string, string, int) {}
+
+func (NopMetrics) MessageError(string, string, string) {}
+
+func (NopMetrics) ObserveRest(string, string, int, time.Duration) {}
+
+func (NopMetrics) RateLimit(string, string, float64) {}
+
+func (NopMetrics) BookMismatch(string, string) {}
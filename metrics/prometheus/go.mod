@@ -0,0 +1,10 @@
+module github.com/shiguantian/wsex/metrics/prometheus
+
+go 1.13
+
+require (
+	github.com/prometheus/client_golang v1.11.0
+	github.com/shiguantian/wsex v0.0.0-00010101000000-000000000000
+)
+
+replace github.com/shiguantian/wsex => ../../
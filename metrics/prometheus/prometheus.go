@@ -0,0 +1,126 @@
+/*
+@Time : 2021/6/16 10:30 上午
+@Author : shiguantian
+@File : prometheus
+@Software: GoLand
+*/
+
+// Package prometheus the prometheus collectors of wsex.Metrics, it's a separate module so the core doesn't depend on prometheus.
+//
+//	metrics := prometheus.New("wsex")
+//	prom.MustRegister(metrics)
+//	e := binance.New(wsex.Options{Metrics: metrics})
+package prometheus
+
+import (
+	"strconv"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/shiguantian/wsex"
+)
+
+// Metrics implement wsex.Metrics and prom.Collector
+type Metrics struct {
+	latency     *prom.HistogramVec
+	connections *prom.CounterVec
+	messages    *prom.CounterVec
+	queueDepth  *prom.GaugeVec
+	errors      *prom.CounterVec
+	rest        *prom.HistogramVec
+	rateLimit   *prom.GaugeVec
+	mismatches  *prom.CounterVec
+}
+
+// New namespace is the prefix of the metric names, eg: wsex
+func New(namespace string) *Metrics {
+	return &Metrics{
+		latency: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "message_latency_seconds",
+			Help:      "Latency of the websocket messages, feed: exchange event to receive, process: receive to publish.",
+			Buckets:   []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 5},
+		}, []string{"exchange", "channel", "stage"}),
+		connections: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "connection_events_total",
+			Help:      "Websocket connects, reconnects, disconnects and closes.",
+		}, []string{"exchange", "url", "event", "reason"}),
+		messages: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "messages_total",
+			Help:      "Messages published to the subscribers.",
+		}, []string{"exchange", "channel"}),
+		queueDepth: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "subscriber_queue_depth",
+			Help:      "Max queued messages of the subscribers when publishing, buffered or being sent.",
+		}, []string{"exchange", "channel"}),
+		errors: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "message_errors_total",
+			Help:      "Errors of the websocket message handlers, kind is decode or the code of ExError.",
+		}, []string{"exchange", "url", "kind"}),
+		rest: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "rest_request_seconds",
+			Help:      "Latency of the rest requests, status 0 means the request failed.",
+			Buckets:   prom.DefBuckets,
+		}, []string{"exchange", "endpoint", "status"}),
+		rateLimit: prom.NewGaugeVec(prom.GaugeOpts{
+			Namespace: namespace,
+			Name:      "rate_limit",
+			Help:      "Rate limit usage reported by the response headers of the exchange.",
+		}, []string{"exchange", "name"}),
+		mismatches: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "book_mismatches_total",
+			Help:      "Local order books differing from the exchange by the checksum or the snapshot comparison.",
+		}, []string{"exchange", "symbol"}),
+	}
+}
+
+func (m *Metrics) collectors() []prom.Collector {
+	return []prom.Collector{m.latency, m.connections, m.messages, m.queueDepth, m.errors, m.rest, m.rateLimit, m.mismatches}
+}
+
+func (m *Metrics) Describe(ch chan<- *prom.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+func (m *Metrics) Collect(ch chan<- prom.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+func (m *Metrics) ObserveLatency(exchange, channel string, stage wsex.LatencyStage, latency time.Duration) {
+	m.latency.WithLabelValues(exchange, channel, string(stage)).Observe(latency.Seconds())
+}
+
+func (m *Metrics) ConnectionEvent(exchange, url string, event wsex.ConnEvent, reason string) {
+	m.connections.WithLabelValues(exchange, url, string(event), reason).Inc()
+}
+
+func (m *Metrics) MessagePublished(exchange, channel string, queueDepth int) {
+	m.messages.WithLabelValues(exchange, channel).Inc()
+	m.queueDepth.WithLabelValues(exchange, channel).Set(float64(queueDepth))
+}
+
+func (m *Metrics) MessageError(exchange, url, kind string) {
+	m.errors.WithLabelValues(exchange, url, kind).Inc()
+}
+
+func (m *Metrics) ObserveRest(exchange, endpoint string, status int, latency time.Duration) {
+	m.rest.WithLabelValues(exchange, endpoint, strconv.Itoa(status)).Observe(latency.Seconds())
+}
+
+func (m *Metrics) RateLimit(exchange, name string, value float64) {
+	m.rateLimit.WithLabelValues(exchange, name).Set(value)
+}
+
+func (m *Metrics) BookMismatch(exchange, symbol string) {
+	m.mismatches.WithLabelValues(exchange, symbol).Inc()
+}
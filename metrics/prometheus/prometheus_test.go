@@ -0,0 +1,48 @@
+/*
+@Time : 2021/6/16 11:20 上午
+@Author : shiguantian
+@File : prometheus_test
+@Software: GoLand
+*/
+package prometheus
+
+import (
+	"testing"
+	"time"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/shiguantian/wsex"
+)
+
+var _ wsex.Metrics = (*Metrics)(nil)
+
+func TestMetrics(t *testing.T) {
+	m := New("wsex")
+	registry := prom.NewRegistry()
+	registry.MustRegister(m)
+
+	m.ConnectionEvent("binance", "wss://stream.binance.com:9443/stream", wsex.ConnDisconnected, "timeout")
+	m.MessagePublished("binance", "trade", 3)
+	m.MessagePublished("binance", "trade", 1)
+	m.ObserveLatency("binance", "trade", wsex.LatencyFeed, 20*time.Millisecond)
+	m.ObserveRest("binance", "/api/v3/depth", 200, 100*time.Millisecond)
+	m.RateLimit("binance", "x-mbx-used-weight-1m", 120)
+	m.BookMismatch("binance", "BTC/USDT")
+
+	if v := testutil.ToFloat64(m.messages.WithLabelValues("binance", "trade")); v != 2 {
+		t.Fatalf("unexpected messages %v", v)
+	}
+	if v := testutil.ToFloat64(m.queueDepth.WithLabelValues("binance", "trade")); v != 1 {
+		t.Fatalf("unexpected queue depth %v", v)
+	}
+	if v := testutil.ToFloat64(m.rateLimit.WithLabelValues("binance", "x-mbx-used-weight-1m")); v != 120 {
+		t.Fatalf("unexpected rate limit %v", v)
+	}
+	if v := testutil.ToFloat64(m.mismatches.WithLabelValues("binance", "BTC/USDT")); v != 1 {
+		t.Fatalf("unexpected mismatches %v", v)
+	}
+	if n, err := testutil.GatherAndCount(registry); err != nil || n != 7 {
+		t.Fatalf("unexpected metrics count %v %v", n, err)
+	}
+}
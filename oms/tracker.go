@@ -0,0 +1,438 @@
+/*
+@Time : 2021/6/18 10:05 上午
+@Author : shiguantian
+@File : tracker
+@Software: GoLand
+*/
+package oms
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/shiguantian/wsex"
+	"github.com/shiguantian/wsex/utils"
+)
+
+// openOrdersPageSize the open orders of a symbol fetched by one request, the pages are fetched until a partial or repeated one
+const openOrdersPageSize = 100
+
+// API the order methods of the exchange used by the tracker, wsex.IExchange satisfies it
+type API interface {
+	SubscribeOrder(symbol string, sub wsex.MessageChan) (string, error)
+	UnSubscribe(topic string, sub wsex.MessageChan) error
+	FetchOrder(symbol, orderID string) (wsex.Order, error)
+	FetchOpenOrders(symbol string, pageIndex, pageSize int) ([]wsex.Order, error)
+}
+
+// Source where the update comes from
+type Source string
+
+const (
+	SourceLocal Source = "local"     // added by Tracker.Add, eg: the result of PlaceOrder
+	SourceWs    Source = "websocket" // pushed by the order subscription
+	SourceRest  Source = "rest"      // reconciled by FetchOpenOrders/FetchOrder
+)
+
+// Update the change of a tracked order, FillDelta and CostDelta are the increments since Previous
+type Update struct {
+	Order     wsex.Order
+	Previous  wsex.Order // empty if the order is new to the tracker
+	IsNew     bool
+	FillDelta float64
+	CostDelta float64
+	Source    Source
+}
+
+// Done the order is filled or canceled, no more update is applied
+func (u Update) Done() bool {
+	return isDone(u.Order.Status)
+}
+
+func isDone(status wsex.OrderStatus) bool {
+	return status == wsex.Close || status == wsex.Canceled
+}
+
+func statusRank(status wsex.OrderStatus) int {
+	switch status {
+	case wsex.Open:
+		return 1
+	case wsex.Partial:
+		return 2
+	case wsex.Close, wsex.Canceled:
+		return 3
+	}
+	return 0
+}
+
+// newer whether the order o is a later state of the order c.
+// the messages are delivered to the subscribers asynchronously and may be out of order, so the state never goes back:
+// the done order is final, the filled amount and the status only increase.
+// The amendment keeping the status and filled amount is applied only if it's later by TransactionTime,
+// the pushed one without TransactionTime can't be told from a stale update, so only the local and rest states are trusted then
+func newer(c, o wsex.Order, source Source) bool {
+	if isDone(c.Status) {
+		return false
+	}
+	filled, cFilled := utils.SafeParseFloat(o.Filled), utils.SafeParseFloat(c.Filled)
+	if filled < cFilled-utils.ZERO {
+		return false
+	}
+	if filled > cFilled+utils.ZERO {
+		return true
+	}
+	if rank, cRank := statusRank(o.Status), statusRank(c.Status); rank != cRank {
+		return rank > cRank
+	}
+	if !changed(o.Price, c.Price) && !changed(o.Amount, c.Amount) {
+		return false
+	}
+	if o.TransactionTime != 0 && c.TransactionTime != 0 {
+		return o.TransactionTime > c.TransactionTime
+	}
+	return source != SourceWs
+}
+
+func changed(value, previous string) bool {
+	return value != "" && math.Abs(utils.SafeParseFloat(value)-utils.SafeParseFloat(previous)) > utils.ZERO
+}
+
+// merge keep the fields of the previous state which the update doesn't carry
+func merge(previous, order wsex.Order) wsex.Order {
+	if order.ClientID == "" {
+		order.ClientID = previous.ClientID
+	}
+	if order.Symbol == "" {
+		order.Symbol = previous.Symbol
+	}
+	if order.Price == "" {
+		order.Price = previous.Price
+	}
+	if order.Amount == "" {
+		order.Amount = previous.Amount
+	}
+	if order.Side == "" {
+		order.Side = previous.Side
+	}
+	if order.Type == "" {
+		order.Type = previous.Type
+	}
+	if order.Cost == "" {
+		order.Cost = previous.Cost
+	}
+	if order.CreateTime == 0 {
+		order.CreateTime = previous.CreateTime
+	}
+	return order
+}
+
+// Tracker keep the orders of the account keyed by ID and client ID.
+// The updates of the order subscription are applied in sequence, the missed updates after reconnected or closed
+// are recovered by resubscribing and reconciling with FetchOpenOrders/FetchOrder, which can also be done periodically.
+// The callbacks are called in the goroutine applying the update, the serving goroutine or the caller of Add/Reconcile,
+// one at a time in the order the updates are applied, so they must not call Add, Handle or Reconcile.
+type Tracker struct {
+	mu        sync.Mutex
+	notifyMu  sync.Mutex // held while applying and notifying an update, locked before mu
+	api       API
+	orders    map[string]wsex.Order // key: order id
+	clientIDs map[string]string     // key: client id, value: order id
+	symbols   map[string]string     // key: symbol, value: topic of the order subscription
+	sub       wsex.MessageChan
+	done      chan struct{}
+	interval  time.Duration
+
+	onUpdate func(Update)
+	onNew    func(Update)
+	onFill   func(Update)
+	onDone   func(Update)
+	onError  func(error)
+}
+
+func NewTracker(api API) *Tracker {
+	return &Tracker{
+		api:       api,
+		orders:    make(map[string]wsex.Order),
+		clientIDs: make(map[string]string),
+		symbols:   make(map[string]string),
+		sub:       make(wsex.MessageChan, 100),
+		done:      make(chan struct{}),
+	}
+}
+
+// SetReconcileInterval reconcile periodically besides after reconnected, 0 means never, it must be set before Start
+func (t *Tracker) SetReconcileInterval(interval time.Duration) {
+	t.interval = interval
+}
+
+// OnUpdate every applied update
+func (t *Tracker) OnUpdate(f func(Update)) *Tracker {
+	t.onUpdate = f
+	return t
+}
+
+// OnNew the first update of an order
+func (t *Tracker) OnNew(f func(Update)) *Tracker {
+	t.onNew = f
+	return t
+}
+
+// OnFill the update increasing the filled amount, FillDelta is the new filled amount
+func (t *Tracker) OnFill(f func(Update)) *Tracker {
+	t.onFill = f
+	return t
+}
+
+// OnDone the order is filled or canceled
+func (t *Tracker) OnDone(f func(Update)) *Tracker {
+	t.onDone = f
+	return t
+}
+
+// OnError the errors of the subscription and the reconciliation
+func (t *Tracker) OnError(f func(error)) *Tracker {
+	t.onError = f
+	return t
+}
+
+// Start subscribe the orders of the symbols and apply the updates until Stop, the open orders are loaded by reconciling first
+func (t *Tracker) Start(symbols ...string) error {
+	for _, symbol := range symbols {
+		topic, err := t.api.SubscribeOrder(symbol, t.sub)
+		if err != nil {
+			t.Stop()
+			return err
+		}
+		t.mu.Lock()
+		t.symbols[symbol] = topic
+		t.mu.Unlock()
+	}
+	go t.serve()
+	return nil
+}
+
+// Stop unsubscribe the orders, the tracked orders are kept
+func (t *Tracker) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return
+	default:
+		close(t.done)
+	}
+	for _, topic := range t.symbols {
+		t.api.UnSubscribe(topic, t.sub)
+	}
+}
+
+func (t *Tracker) serve() {
+	t.reconcile()
+	var tick <-chan time.Time
+	if t.interval > 0 {
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case msg := <-t.sub:
+			t.Handle(msg)
+		case <-tick:
+			t.reconcile()
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// Handle apply the message of the order subscription, the subscribers are cleared after reconnected,
+// and the updates may be missed while disconnected, so resubscribe and reconcile then
+func (t *Tracker) Handle(msg wsex.Message) {
+	switch msg.Type {
+	case wsex.MsgOrder:
+		if order, ok := msg.Order(); ok {
+			t.apply(order, SourceWs)
+		}
+	case wsex.MsgReConnected, wsex.MsgClosed:
+		t.resubscribe()
+		t.reconcile()
+	case wsex.MsgError:
+		if err, ok := msg.Err(); ok {
+			t.report(err)
+		}
+	}
+}
+
+// Add track the order placed by the caller, eg: the result of PlaceOrder, the later state pushed before is kept
+func (t *Tracker) Add(order wsex.Order) {
+	t.apply(order, SourceLocal)
+}
+
+// Remove stop tracking the order, eg: it's done and handled
+func (t *Tracker) Remove(orderID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if order, ok := t.orders[orderID]; ok {
+		delete(t.clientIDs, order.ClientID)
+		delete(t.orders, orderID)
+	}
+}
+
+func (t *Tracker) Order(orderID string) (wsex.Order, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	order, ok := t.orders[orderID]
+	return order, ok
+}
+
+func (t *Tracker) OrderByClientID(clientID string) (wsex.Order, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	order, ok := t.orders[t.clientIDs[clientID]]
+	return order, ok
+}
+
+// OpenOrders the tracked orders which are not done, empty symbol means all symbols
+func (t *Tracker) OpenOrders(symbol string) []wsex.Order {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var orders []wsex.Order
+	for _, order := range t.orders {
+		if !isDone(order.Status) && (symbol == "" || order.Symbol == symbol) {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+// Reconcile apply the open orders of the subscribed symbols, and fetch the tracked orders which are not open any more,
+// they are filled or canceled while the updates were missed. The last error is returned, the others are still reconciled
+func (t *Tracker) Reconcile() (err error) {
+	t.mu.Lock()
+	symbols := make([]string, 0, len(t.symbols))
+	for symbol := range t.symbols {
+		symbols = append(symbols, symbol)
+	}
+	t.mu.Unlock()
+
+	open := make(map[string]bool)
+	failed := make(map[string]bool)
+	for _, symbol := range symbols {
+		for page := 1; ; page++ {
+			orders, fetchErr := t.api.FetchOpenOrders(symbol, page, openOrdersPageSize)
+			if fetchErr != nil {
+				err = fetchErr
+				failed[symbol] = true
+				break
+			}
+			fresh := 0
+			for _, order := range orders {
+				if !open[order.ID] {
+					fresh++
+				}
+				open[order.ID] = true
+				t.apply(order, SourceRest)
+			}
+			// some exchanges ignore the paging and return all the open orders on every page
+			if len(orders) < openOrdersPageSize || fresh < len(orders) {
+				break
+			}
+		}
+	}
+	for _, order := range t.OpenOrders("") {
+		if open[order.ID] || failed[order.Symbol] {
+			continue
+		}
+		latest, fetchErr := t.api.FetchOrder(order.Symbol, order.ID)
+		if fetchErr != nil {
+			err = fetchErr
+			continue
+		}
+		t.apply(latest, SourceRest)
+	}
+	return
+}
+
+func (t *Tracker) reconcile() {
+	if err := t.Reconcile(); err != nil {
+		t.report(err)
+	}
+}
+
+func (t *Tracker) resubscribe() {
+	t.mu.Lock()
+	symbols := make([]string, 0, len(t.symbols))
+	for symbol := range t.symbols {
+		symbols = append(symbols, symbol)
+	}
+	t.mu.Unlock()
+	for _, symbol := range symbols {
+		topic, err := t.api.SubscribeOrder(symbol, t.sub)
+		if err != nil {
+			t.report(err)
+			continue
+		}
+		t.mu.Lock()
+		t.symbols[symbol] = topic
+		t.mu.Unlock()
+	}
+}
+
+// apply the order if it's a later state, the order without ID is matched by its client ID
+func (t *Tracker) apply(order wsex.Order, source Source) {
+	t.notifyMu.Lock()
+	defer t.notifyMu.Unlock()
+	t.mu.Lock()
+	if order.ID == "" {
+		order.ID = t.clientIDs[order.ClientID]
+	}
+	if order.ID == "" {
+		t.mu.Unlock()
+		return
+	}
+	previous, ok := t.orders[order.ID]
+	if ok {
+		if !newer(previous, order, source) {
+			t.mu.Unlock()
+			return
+		}
+		order = merge(previous, order)
+	}
+	t.orders[order.ID] = order
+	if order.ClientID != "" {
+		t.clientIDs[order.ClientID] = order.ID
+	}
+	t.mu.Unlock()
+
+	t.notify(Update{
+		Order:     order,
+		Previous:  previous,
+		IsNew:     !ok,
+		FillDelta: utils.SafeParseFloat(order.Filled) - utils.SafeParseFloat(previous.Filled),
+		CostDelta: utils.SafeParseFloat(order.Cost) - utils.SafeParseFloat(previous.Cost),
+		Source:    source,
+	})
+}
+
+func (t *Tracker) notify(update Update) {
+	if t.onUpdate != nil {
+		t.onUpdate(update)
+	}
+	if update.IsNew && t.onNew != nil {
+		t.onNew(update)
+	}
+	if update.FillDelta > utils.ZERO && t.onFill != nil {
+		t.onFill(update)
+	}
+	if update.Done() && t.onDone != nil {
+		t.onDone(update)
+	}
+}
+
+func (t *Tracker) report(err error) {
+	if t.onError != nil {
+		t.onError(err)
+	}
+}
@@ -0,0 +1,201 @@
+/*
+@Time : 2021/6/18 2:30 下午
+@Author : shiguantian
+@File : tracker_test
+@Software: GoLand
+*/
+package oms
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/shiguantian/wsex"
+)
+
+// any exchange can be tracked
+var _ API = wsex.IExchange(nil)
+
+type fakeAPI struct {
+	subscribed int
+	open       []wsex.Order
+	orders     map[string]wsex.Order
+	fetched    []string
+	pages      []int
+	noPaging   bool // return all the open orders on every page
+}
+
+func (f *fakeAPI) SubscribeOrder(symbol string, sub wsex.MessageChan) (string, error) {
+	f.subscribed++
+	return "order", nil
+}
+
+func (f *fakeAPI) UnSubscribe(topic string, sub wsex.MessageChan) error {
+	return nil
+}
+
+func (f *fakeAPI) FetchOrder(symbol, orderID string) (wsex.Order, error) {
+	f.fetched = append(f.fetched, orderID)
+	return f.orders[orderID], nil
+}
+
+func (f *fakeAPI) FetchOpenOrders(symbol string, pageIndex, pageSize int) ([]wsex.Order, error) {
+	f.pages = append(f.pages, pageIndex)
+	if f.noPaging {
+		return f.open, nil
+	}
+	start := (pageIndex - 1) * pageSize
+	if start >= len(f.open) {
+		return nil, nil
+	}
+	end := start + pageSize
+	if end > len(f.open) {
+		end = len(f.open)
+	}
+	return f.open[start:end], nil
+}
+
+func orderMsg(order wsex.Order) wsex.Message {
+	return wsex.Message{Type: wsex.MsgOrder, Data: order}
+}
+
+func TestTracker_ApplyInSequence(t *testing.T) {
+	var fills []float64
+	var done int
+	tracker := NewTracker(&fakeAPI{}).
+		OnFill(func(u Update) { fills = append(fills, u.FillDelta) }).
+		OnDone(func(u Update) { done++ })
+
+	tracker.Add(wsex.Order{ID: "1", ClientID: "c1", Symbol: "BTC/USDT", Price: "100", Amount: "3", Filled: "0", Status: wsex.Open})
+	tracker.Handle(orderMsg(wsex.Order{ID: "1", Filled: "1", Status: wsex.Partial}))
+	// the stale update delivered late is dropped
+	tracker.Handle(orderMsg(wsex.Order{ID: "1", Filled: "0", Status: wsex.Open}))
+	// the update without ID is matched by the client ID
+	tracker.Handle(orderMsg(wsex.Order{ClientID: "c1", Filled: "3", Status: wsex.Close}))
+	tracker.Handle(orderMsg(wsex.Order{ID: "1", Filled: "3", Status: wsex.Canceled}))
+
+	if len(fills) != 2 || fills[0] != 1 || fills[1] != 2 {
+		t.Fatalf("unexpected fill deltas %v", fills)
+	}
+	if done != 1 {
+		t.Fatalf("the order should be done once, %v", done)
+	}
+	order, ok := tracker.OrderByClientID("c1")
+	if !ok || order.Status != wsex.Close || order.Price != "100" || order.Symbol != "BTC/USDT" {
+		t.Fatalf("unexpected order %+v", order)
+	}
+	if len(tracker.OpenOrders("")) != 0 {
+		t.Fatal("the done order should not be open")
+	}
+}
+
+func TestTracker_ReconcileAfterReconnected(t *testing.T) {
+	api := &fakeAPI{
+		open:   []wsex.Order{{ID: "2", Symbol: "BTC/USDT", Filled: "1", Status: wsex.Partial}},
+		orders: map[string]wsex.Order{"1": {ID: "1", Symbol: "BTC/USDT", Filled: "2", Status: wsex.Close}},
+	}
+	var updates []Update
+	tracker := NewTracker(api).OnUpdate(func(u Update) { updates = append(updates, u) })
+	tracker.symbols["BTC/USDT"] = "order"
+	tracker.Add(wsex.Order{ID: "1", Symbol: "BTC/USDT", Amount: "2", Filled: "0", Status: wsex.Open})
+	updates = nil
+
+	tracker.Handle(wsex.ReConnectedMessage)
+	if api.subscribed != 1 {
+		t.Fatalf("the orders should be resubscribed, %v", api.subscribed)
+	}
+	if len(api.fetched) != 1 || api.fetched[0] != "1" {
+		t.Fatalf("only the order missing from the open orders should be fetched, %v", api.fetched)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("unexpected updates %+v", updates)
+	}
+	if !updates[0].IsNew || updates[0].Order.ID != "2" || updates[0].Source != SourceRest {
+		t.Fatalf("the open order should be new, %+v", updates[0])
+	}
+	if !updates[1].Done() || updates[1].FillDelta != 2 || updates[1].Order.Amount != "2" {
+		t.Fatalf("the missed fill should be reconciled, %+v", updates[1])
+	}
+}
+
+func TestTracker_Amend(t *testing.T) {
+	tracker := NewTracker(&fakeAPI{})
+	tracker.Add(wsex.Order{ID: "1", Price: "100", Amount: "2", Filled: "0", Status: wsex.Open, TransactionTime: 10})
+	tracker.Handle(orderMsg(wsex.Order{ID: "1", Price: "101", Filled: "0", Status: wsex.Open, TransactionTime: 20}))
+	// the pre-amend update delivered late without TransactionTime is dropped
+	tracker.Handle(orderMsg(wsex.Order{ID: "1", Price: "100", Filled: "0", Status: wsex.Open}))
+	// so is the earlier one
+	tracker.Handle(orderMsg(wsex.Order{ID: "1", Price: "100", Filled: "0", Status: wsex.Open, TransactionTime: 15}))
+	if order, _ := tracker.Order("1"); order.Price != "101" {
+		t.Fatalf("the amended price should be kept, %+v", order)
+	}
+	// the rest state is trusted without TransactionTime
+	tracker.apply(wsex.Order{ID: "1", Price: "102", Filled: "0", Status: wsex.Open}, SourceRest)
+	if order, _ := tracker.Order("1"); order.Price != "102" {
+		t.Fatalf("the reconciled price should be applied, %+v", order)
+	}
+}
+
+func TestTracker_ReconcilePages(t *testing.T) {
+	api := &fakeAPI{}
+	for i := 0; i < openOrdersPageSize+1; i++ {
+		api.open = append(api.open, wsex.Order{ID: strconv.Itoa(i), Symbol: "BTC/USDT", Filled: "0", Status: wsex.Open})
+	}
+	tracker := NewTracker(api)
+	tracker.symbols["BTC/USDT"] = "order"
+	if err := tracker.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.pages) != 2 || api.pages[0] != 1 || api.pages[1] != 2 {
+		t.Fatalf("the pages should be fetched until a partial one, %v", api.pages)
+	}
+	if len(api.fetched) != 0 || len(tracker.OpenOrders("")) != openOrdersPageSize+1 {
+		t.Fatalf("all the open orders should be tracked, fetched %v", api.fetched)
+	}
+
+	// the exchange ignoring the paging returns the same orders again
+	api.pages, api.noPaging = nil, true
+	if err := tracker.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if len(api.pages) != 2 || len(api.fetched) != 0 {
+		t.Fatalf("the repeated page should stop the paging, pages %v fetched %v", api.pages, api.fetched)
+	}
+}
+
+func TestTracker_SerialCallbacks(t *testing.T) {
+	var running, overlapped int32
+	var fills []float64
+	tracker := NewTracker(&fakeAPI{}).OnUpdate(func(u Update) {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.StoreInt32(&overlapped, 1)
+		}
+		if u.FillDelta > 0 {
+			fills = append(fills, u.FillDelta)
+		}
+		atomic.AddInt32(&running, -1)
+	})
+	tracker.Add(wsex.Order{ID: "1", Amount: "100", Filled: "0", Status: wsex.Open})
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 100; i++ {
+		wg.Add(1)
+		go func(filled int) {
+			defer wg.Done()
+			tracker.Handle(orderMsg(wsex.Order{ID: "1", Filled: strconv.Itoa(filled), Status: wsex.Partial}))
+		}(i)
+	}
+	wg.Wait()
+	if overlapped != 0 {
+		t.Fatal("the callbacks should not run concurrently")
+	}
+	var total float64
+	for _, fill := range fills {
+		total += fill
+	}
+	if total != 100 {
+		t.Fatalf("the fill deltas should be delivered in order, total %v", total)
+	}
+}
@@ -45,6 +45,12 @@ type Options struct {
 	AutoReconnect       bool   // whether enable auto reconnect
 	ProxyUrl            string // proxy, http://host:port
 	ClientOrderIDPrefix string // Prefix of client order id，len better(0~10)
+
+	SubscribeTimeout   time.Duration // timeout of waiting the websocket subscribe ack, default 5s
+	MaxTopicsPerConn   int           // max topics of one websocket connection, the others are placed on new connections, 0 means the default of the exchange
+	OrderBookDepth     int           // max levels of each side of the local order book, default 400
+	OrderBookCheckTime time.Duration // interval of comparing the local order book with the rest snapshot for the exchange without checksum, 0 means never
+	Metrics            Metrics       // collector of the runtime metrics, nil means disabled
 }
 
 type FutureOptions struct {
@@ -167,9 +173,10 @@ func (d Depth) Update(newDepth RawDepth, reverse bool) Depth {
 }
 
 type OrderBook struct {
-	Symbol string
-	Bids   Depth `json:"bids"`
-	Asks   Depth `json:"asks"`
+	Symbol    string
+	Bids      Depth         `json:"bids"`
+	Asks      Depth         `json:"asks"`
+	Timestamp time.Duration `json:"timestamp"` // the event time of the exchange, 0 if unknown
 }
 
 func (o *OrderBook) Sort() {
@@ -177,6 +184,18 @@ func (o *OrderBook) Sort() {
 	sort.Sort(o.Asks)
 }
 
+// BookInvalid the local order book has a sequence gap or wrong checksum, stop using it until BookResynced
+type BookInvalid struct {
+	Symbol string
+	Err    error
+}
+
+// BookResynced the local order book is rebuilt from a new snapshot after BookInvalid
+type BookResynced struct {
+	Symbol   string
+	Sequence int64
+}
+
 type (
 	KLineType   int
 	Side        string
@@ -214,9 +233,36 @@ const (
 	CloseShort       = "CloseShort"
 )
 const (
-	TradeTypeUnKnown TradeType = "Unknown"
-	LIMIT                      = "Limit"
-	MARKET                     = "market"
+	TradeTypeUnKnown   TradeType = "Unknown"
+	LIMIT                        = "Limit"
+	MARKET                       = "market"
+	STOP_MARKET                  = "StopMarket"       //止损市价单
+	STOP_LIMIT                   = "StopLimit"        //止损限价单
+	TAKE_PROFIT_MARKET           = "TakeProfitMarket" //止盈市价单
+	TAKE_PROFIT_LIMIT            = "TakeProfitLimit"  //止盈限价单
+	TRAILING_STOP                = "TrailingStop"     //跟踪止损市价单
+)
+
+// IsConditional the order is triggered by price, not placed into the order book directly
+func (t TradeType) IsConditional() bool {
+	switch t {
+	case STOP_MARKET, STOP_LIMIT, TAKE_PROFIT_MARKET, TAKE_PROFIT_LIMIT, TRAILING_STOP:
+		return true
+	}
+	return false
+}
+
+// IsLimit the order has a limit price
+func (t TradeType) IsLimit() bool {
+	return t == LIMIT || t == STOP_LIMIT || t == TAKE_PROFIT_LIMIT
+}
+
+type TriggerType string
+
+const (
+	TriggerTypeUnKnown TriggerType = ""     //交易所默认
+	TriggerLast                    = "last" //最新成交价触发
+	TriggerMark                    = "mark" //标记价格触发
 )
 
 const (
@@ -276,15 +322,107 @@ type Order struct {
 	Amount          string
 	Filled          string
 	Cost            string
+	Fee             string    //手续费,websocket推送中部分交易所为最近一笔成交的手续费
+	FeeCurrency     string    //手续费币种
+	Liquidity       Liquidity //最近一笔成交是maker还是taker
 	Leverage        int
 	Status          OrderStatus
 	Side            Side
 	Type            TradeType
 	OrderType       OrderType
+	StopPrice       string      //条件单触发价
+	TriggerType     TriggerType //条件单触发价格类型
+	ReduceOnly      bool        //只减仓, 部分交易所提供
 	CreateTime      time.Duration
 	TransactionTime time.Duration
 }
 
+// OrderRequest parameters of a new order
+type OrderRequest struct {
+	Symbol          string
+	Price           float64
+	Amount          float64           // base数量
+	QuoteAmount     float64           // 按quote金额市价买入,设置后忽略Amount
+	Side            Side              //
+	Type            TradeType         //
+	OrderType       OrderType         // time in force
+	ClientID        string            // 指定客户端订单ID
+	UseClientID     bool              // ClientID为空时自动生成客户端订单ID
+	ReduceOnly      bool              // 只减仓
+	ClosePosition   bool              // 触发后平掉整个仓位,忽略Amount,用于STOP_MARKET/TAKE_PROFIT_MARKET
+	StopPrice       float64           // 触发价,LIMIT/MARKET设置后视为STOP_LIMIT/STOP_MARKET
+	TriggerType     TriggerType       // 触发价格类型
+	CallbackRate    float64           // 跟踪止损回调比例,0.01为1%
+	ActivationPrice float64           // 跟踪止损激活价,0为立即激活
+	PositionSide    PositionType      // 双向持仓时Buy/Sell对应的仓位方向
+	Params          map[string]string // 交易所特有参数,覆盖同名参数
+}
+
+// ClientOrderID the client order id of the request, generated by prefix if needed
+func (r OrderRequest) ClientOrderID(prefix string, size int) string {
+	if r.ClientID == "" && r.UseClientID {
+		return GenerateOrderClientId(prefix, size)
+	}
+	return r.ClientID
+}
+
+// Normalize unset Type is LIMIT, LIMIT/MARKET with StopPrice are stop orders
+func (r OrderRequest) Normalize() OrderRequest {
+	if r.Type == "" {
+		r.Type = LIMIT
+	}
+	if r.StopPrice > 0 {
+		switch r.Type {
+		case LIMIT:
+			r.Type = STOP_LIMIT
+		case MARKET:
+			r.Type = STOP_MARKET
+		}
+	}
+	return r
+}
+
+// OrderResult result of one order in a batch, in the same position as its request
+type OrderResult struct {
+	Order Order
+	Err   error
+}
+
+// CancelResult result of cancelling one order in a batch
+type CancelResult struct {
+	OrderID string
+	Err     error
+}
+
+type AmendMethod string
+
+const (
+	AmendInPlace       AmendMethod = "InPlace"       //交易所原生改单接口
+	AmendCancelReplace             = "CancelReplace" //交易所原生撤单并下单接口
+	AmendCancelCreate              = "CancelCreate"  //客户端先撤单再下单
+)
+
+// AmendResult Order is the amended order, or the new order when it has been replaced
+type AmendResult struct {
+	Order  Order
+	Method AmendMethod
+}
+
+type Liquidity string
+
+const (
+	LiquidityUnKnown Liquidity = ""
+	Maker                      = "maker"
+	Taker                      = "taker"
+)
+
+// TradingFee maker/taker fee rates of one symbol, negative rate means rebate
+type TradingFee struct {
+	Symbol string
+	Maker  float64
+	Taker  float64
+}
+
 type Balance struct {
 	Asset     string
 	Available float64
@@ -296,6 +434,21 @@ type BalanceUpdate struct {
 	Balances   map[string]Balance
 }
 
+// AccountUpdate 合约账户变动, 包含变动的余额和仓位
+type AccountUpdate struct {
+	Reason     string // 变动原因, eg: binance的ORDER, FUNDING_FEE, 为空时未知
+	Balances   map[string]Balance
+	Positions  []Position
+	UpdateTime time.Duration
+}
+
+// MarginCall 追加保证金通知, Positions为保证金不足的仓位
+type MarginCall struct {
+	CrossWalletBalance float64 // 全仓钱包余额, 0为未知
+	Positions          []Position
+	Timestamp          time.Duration
+}
+
 type ContractType string
 
 const (
@@ -348,36 +501,82 @@ type FutureAsset struct {
 
 //account info
 type FutureAccountInfo struct {
-	Account   FutureAsset                                // account summary assets
-	Assets    map[string]FutureAsset                     // key:AssetName
-	Positions map[string]map[PositionType]FuturePositons // key:AssetName
+	Account   FutureAsset                          // account summary assets
+	Assets    map[string]FutureAsset               // key:AssetName
+	Positions map[string]map[PositionType]Position // key:AssetName
 }
 
-//仓位信息
-type FuturePositons struct {
+//仓位信息, 价格和数量均为数值
+type Position struct {
 	Coin           string           // 币名
 	Symbol         string           //币对
-	AvgPrice       string           //开仓均价
-	LiquidatePrice string           //强平价格
-	Margin         string           //保证金
+	PositionType   PositionType     //开多，开空, 单向持仓为PositionTypeUnKonwn
 	MarginMode     FutureMarginMode //逐仓，全仓
-	MarginBalance  string           //保证金余额
-	Amount         float64          //仓位数量
-	FreezeAmount   string           //下单冻结仓位数量
-	PositionType   PositionType     //开多，开空
 	Leverage       int              //杠杆倍数
-	MarginRate     string           //保证金率
-	MaintainMargin string           //维持保证金
+	Amount         float64          //仓位数量, 空仓为负数
+	FreezeAmount   float64          //下单冻结仓位数量
+	AvgPrice       float64          //开仓均价
+	MarkPrice      float64          //标记价格
+	LiquidatePrice float64          //强平价格
+	Notional       float64          //名义价值, quote
+	Margin         float64          //保证金
+	MarginBalance  float64          //保证金余额
+	MaintainMargin float64          //维持保证金
+	MarginRate     float64          //保证金率
+	UnrealizedPnl  float64          //未实现盈亏
+	RealizedPnl    float64          //已实现盈亏
+	AdlQuantile    int              //自动减仓队列, 越大越先被减仓, binance为0-4, 0为未知或最后
+	UpdateTime     time.Duration    //
 }
 
+// FuturePositons Deprecated: use Position
+type FuturePositons = Position
+
 type FuturePositonsUpdate struct {
 	Symbol   string
-	Positons []FuturePositons
+	Positons []Position
 }
 
 type MarkPrice struct {
-	Symbol string
-	Price  string
+	Symbol               string
+	Price                string
+	IndexPrice           string        // 为空时未知
+	EstimatedSettlePrice string        // 预估结算价, 为空时未知
+	Timestamp            time.Duration //
+}
+
+type IndexPrice struct {
+	Symbol    string
+	Price     string
+	Timestamp time.Duration
+}
+
+// Liquidation 强平订单, Side为强平单的方向, Sell为多仓被强平
+type Liquidation struct {
+	Symbol    string
+	Side      Side
+	Price     float64 // 委托价格
+	AvgPrice  float64 // 成交均价
+	Amount    float64 // 委托数量
+	Filled    float64 // 累计成交数量
+	Timestamp time.Duration
+}
+
+// OpenInterest 持仓量
+type OpenInterest struct {
+	Symbol    string
+	Amount    float64 // 持仓量, base
+	Value     float64 // 持仓价值, quote, 0为未知
+	Timestamp time.Duration
+}
+
+// LongShortRatio 大户持仓多空比
+type LongShortRatio struct {
+	Symbol    string
+	Ratio     float64 // 多空比 = Long / Short
+	Long      float64 // 多仓占比
+	Short     float64 // 空仓占比
+	Timestamp time.Duration
 }
 
 type PositionType string
@@ -389,6 +588,38 @@ const (
 )
 
 type FundingRate struct {
-	Rate          string
-	NextTimestamp time.Duration
+	Symbol        string
+	Rate          string        // 当前费率为下次结算的预测费率, 历史记录为结算费率
+	MarkPrice     string        // 当前标记价格, 历史记录为空
+	IndexPrice    string        // 当前指数价格, 历史记录为空
+	Interval      time.Duration // 结算间隔, 毫秒, 与时间戳单位一致
+	Timestamp     time.Duration // 结算时间, 毫秒时间戳, 只用于历史记录
+	NextTimestamp time.Duration // 下次结算时间, 毫秒时间戳, zb此前为秒
+}
+
+// FundingPayment 实际收取或支付的资金费用
+type FundingPayment struct {
+	ID     string
+	Symbol string
+	Asset  string
+	Amount float64 // 正数为收入,负数为支出
+	Time   time.Duration
+}
+
+// MarginChange 逐仓仓位的保证金调整记录
+type MarginChange struct {
+	Symbol       string
+	PositionType PositionType // 单向持仓为PositionTypeUnKonwn
+	Asset        string
+	Amount       float64 // 正数为增加,负数为减少
+	Time         time.Duration
+}
+
+// LeverageBracket 分档的名义价值区间及其最大杠杆和维持保证金率
+type LeverageBracket struct {
+	Bracket          int
+	MaxLeverage      int
+	NotionalFloor    float64 // 名义价值下限, quote
+	NotionalCap      float64 // 名义价值上限, quote, 0为不限
+	MaintMarginRatio float64
 }
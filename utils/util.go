@@ -124,7 +124,19 @@ func IsClientOrderID(orderID, prefix string) bool {
 	return strings.Contains(orderID, prefix)
 }
 
-func UrlValuesToJson(values url.Values) string {
+// RawJsonKey the key of url.Values which holds a json body that can not be expressed by url.Values, such as an array
+const RawJsonKey = "__raw_json__"
+
+// RawJsonValues wrap a value into url.Values, UrlValuesToJson will return its json encoding unchanged
+func RawJsonValues(v interface{}) (url.Values, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return url.Values{RawJsonKey: []string{string(js)}}, nil
+}
+
+func UrlValuesToMap(values url.Values) map[string]interface{} {
 	m := make(map[string]interface{}, 0)
 	for key, val := range values {
 		if len(val) > 1 {
@@ -133,7 +145,14 @@ func UrlValuesToJson(values url.Values) string {
 			m[key] = val[0]
 		}
 	}
-	js, err := json.Marshal(m)
+	return m
+}
+
+func UrlValuesToJson(values url.Values) string {
+	if raw, ok := values[RawJsonKey]; ok && len(raw) == 1 {
+		return raw[0]
+	}
+	js, err := json.Marshal(UrlValuesToMap(values))
 	if err != nil {
 		return ""
 	}